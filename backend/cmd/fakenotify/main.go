@@ -0,0 +1,39 @@
+// cmd/fakenotify: run a local stand-in for the reminder notifiers' mail server and webhooks.
+//
+// Point the notifiers at it with, for example:
+//
+//	reminders:
+//	  notifiers:
+//	    - {name: mail, type: smtp, addr: "localhost:2525", from: standup@example.com, to: [lead@example.com]}
+//	    - {name: hook, type: webhook, url: "http://localhost:9292/hook"}
+//	    - {name: slack, type: slack, url: "http://localhost:9292/slack"}
+//
+// and read what arrived with GET http://localhost:9292/messages.
+package main
+
+import (
+	"flag"
+	"go-backend/fakenotify"
+	"log"
+	"net/http"
+)
+
+func main() {
+	smtpAddr := flag.String("smtp", ":2525", "SMTP listen address")
+	httpAddr := flag.String("http", ":9292", "webhook listen address")
+	status := flag.Int("status", http.StatusOK, "status code to answer webhooks with, e.g. 500 to test failures")
+	flag.Parse()
+
+	srv := fakenotify.NewServer()
+	srv.SetStatus(*status)
+
+	go func() {
+		log.Printf("Fake SMTP server on %s", *smtpAddr)
+		log.Fatal(srv.ListenSMTP(*smtpAddr))
+	}()
+
+	log.Printf("Fake webhook receiver on %s, received messages at /messages", *httpAddr)
+	if err := http.ListenAndServe(*httpAddr, srv); err != nil {
+		log.Fatal(err)
+	}
+}
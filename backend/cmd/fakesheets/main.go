@@ -0,0 +1,52 @@
+// cmd/fakesheets: run a local stand-in for the Google Sheets API.
+//
+// Point the backend at it with:
+//
+//	SHEETS_ENDPOINT=http://localhost:9090/ go run .
+package main
+
+import (
+	"flag"
+	"go-backend/config"
+	"go-backend/fakesheets"
+	"log"
+	"net/http"
+	"strings"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	spreadsheetID := flag.String("spreadsheet", config.Default().SpreadsheetID, "spreadsheet ID to serve")
+	tabs := flag.String("tabs", "DEV,Managers", "comma-separated role tabs to create if missing, each with an \"Employee Name\" header")
+	archiveID := flag.String("archive-spreadsheet", "", "optional second spreadsheet ID to serve empty, e.g. archive.spreadsheet_id")
+	dataFile := flag.String("data", "", "optional JSON file to load the spreadsheet from and save it to after every write")
+	flag.Parse()
+
+	srv := fakesheets.NewServer()
+	if *dataFile != "" {
+		if err := srv.LoadFile(*dataFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var titles []string
+	for _, t := range strings.Split(*tabs, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			titles = append(titles, t)
+		}
+	}
+	for _, t := range srv.AddSpreadsheet(*spreadsheetID, titles...) {
+		if err := srv.SetValue(*spreadsheetID, t, 0, 0, "Employee Name"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *archiveID != "" {
+		srv.AddSpreadsheet(*archiveID)
+	}
+
+	log.Printf("Fake Sheets API serving spreadsheet %s on %s", *spreadsheetID, *addr)
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -5,40 +5,143 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
 
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
 )
 
-const (
-	// Replace this with your actual Spreadsheet ID
-	// SpreadsheetID = "1dZJsrUJDrly6k4Cg1CDLMfU86cje3k1c_GxiXb7AytU"
-	SpreadsheetID = "1KCnvCP_jSmL-9zpqbmyU1OWOT-dZEUJdlOKf4ZZ2Brk"
-	SheetName     = "DEV" 
-	Credentials   = "credentials.json"
-)
+// SheetsClient is a long-lived, concurrency-safe Sheets API client shared by every service.
+// OAuth tokens are cached until they expire and connections are kept alive between calls.
+// Every call is rate limited, retried with backoff and guarded by a circuit breaker.
+type SheetsClient struct {
+	mu     sync.RWMutex
+	srv    *sheets.Service
+	drv    *drive.Service // File metadata (revision, modified time) of the spreadsheet
+	policy *callPolicy
+}
 
-// GetSheetsService initializes and returns a Google Sheets service client
-func GetSheetsService() (*sheets.Service, error) {
-	ctx := context.Background()
-	b, err := ioutil.ReadFile(Credentials)
+// NewSheetsClient builds the shared client from the credentials in the active configuration
+func NewSheetsClient() (*SheetsClient, error) {
+	c := &SheetsClient{policy: newCallPolicy(Get().SheetsAPI)}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Service returns the current Sheets service; safe for concurrent use
+func (c *SheetsClient) Service() (*sheets.Service, error) {
+	if c == nil {
+		return nil, fmt.Errorf("Sheets client is not configured")
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.srv, nil
+}
+
+// Drive returns the current Drive service, used for spreadsheet file metadata
+func (c *SheetsClient) Drive() (*drive.Service, error) {
+	if c == nil {
+		return nil, fmt.Errorf("Sheets client is not configured")
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.drv, nil
+}
+
+// Stats returns the retry, throttle and circuit breaker counters
+func (c *SheetsClient) Stats() APIStats {
+	return c.policy.stats()
+}
+
+// Reload re-reads the configured credentials and swaps in a fresh service.
+// On error the previous service stays in use.
+func (c *SheetsClient) Reload() error {
+	srv, drv, err := newServices(Get().Credentials, c.policy)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read client secret file: %v", err)
+		return err
+	}
+	c.mu.Lock()
+	c.srv, c.drv = srv, drv
+	c.mu.Unlock()
+	return nil
+}
+
+// Helper: Transport tuned for many small calls to a single host
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 60 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// Helper: Build the Sheets and Drive services for the given credentials, with policy in front of every call
+func newServices(creds CredentialsConfig, policy *callPolicy) (*sheets.Service, *drive.Service, error) {
+	ctx := context.Background()
+	base := &http.Client{Transport: newTransport()}
+
+	// Endpoint override (e.g. cmd/fakesheets): no credentials are read or sent
+	if creds.Endpoint != "" {
+		client := &http.Client{Transport: policy.wrap(base.Transport)}
+		srv, err := sheets.NewService(ctx, option.WithEndpoint(creds.Endpoint), option.WithHTTPClient(client))
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to retrieve Sheets client: %v", err)
+		}
+		drv, err := drive.NewService(ctx, option.WithEndpoint(strings.TrimSuffix(creds.Endpoint, "/")+"/drive/v3/"), option.WithHTTPClient(client))
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to retrieve Drive client: %v", err)
+		}
+		return srv, drv, nil
+	}
+
+	b := []byte(creds.JSON)
+	if len(b) == 0 {
+		var err error
+		b, err = ioutil.ReadFile(creds.File)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read client secret file: %v", err)
+		}
 	}
 
 	// If modifying these scopes, delete your previously saved token.json.
-	config, err := google.JWTConfigFromJSON(b, sheets.SpreadsheetsScope)
+	config, err := google.JWTConfigFromJSON(b, sheets.SpreadsheetsScope, drive.DriveMetadataReadonlyScope)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse client secret file to config: %v", err)
+		return nil, nil, fmt.Errorf("unable to parse client secret file to config: %v", err)
 	}
 
-	client := config.Client(ctx)
+	// Token fetches use the tuned client too; tokens are reused until shortly before expiry
+	tokenCtx := context.WithValue(ctx, oauth2.HTTPClient, base)
+	tokens := oauth2.ReuseTokenSource(nil, config.TokenSource(tokenCtx))
+	client := &http.Client{
+		Transport: policy.wrap(&oauth2.Transport{Source: tokens, Base: base.Transport}),
+	}
 
 	srv, err := sheets.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve Sheets client: %v", err)
+		return nil, nil, fmt.Errorf("unable to retrieve Sheets client: %v", err)
+	}
+	drv, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to retrieve Drive client: %v", err)
 	}
 
-	return srv, nil
-}
\ No newline at end of file
+	return srv, drv, nil
+}
@@ -14,41 +14,43 @@ const (
 )
 
 // InitDB ensures the "database" and "database_logs" sheets exist with headers
-func InitDB() {
-	srv, err := GetSheetsService()
+func InitDB(client *SheetsClient) {
+	srv, err := client.Service()
 	if err != nil {
 		log.Fatal("Unable to retrieve Sheets client for DB init: ", err)
 	}
 
 	// 1. Check/Create "database" (Employees) - Removed ID/Project
-	if err := ensureSheet(srv, SheetDBEmployees, []interface{}{"Employee Name", "Created At", "Updated At"}); err != nil {
+	if err := ensureSheet(srv, SheetDBEmployees, []interface{}{"Employee Name", "Created At", "Updated At", "Timezone"}); err != nil {
 		log.Fatalf("Failed to init %s: %v", SheetDBEmployees, err)
 	}
 
 	// 2. Check/Create "database_logs" (Daily Logs)
-	if err := ensureSheet(srv, SheetDBLogs, []interface{}{"Employee Name", "Task Date", "Created At", "Updated At"}); err != nil {
+	if err := ensureSheet(srv, SheetDBLogs, []interface{}{"Employee Name", "Task Date", "Created At", "Updated At", "Timezone", "Created At (UTC)", "Updated At (UTC)"}); err != nil {
 		log.Fatalf("Failed to init %s: %v", SheetDBLogs, err)
 	}
 
 	fmt.Println("Google Sheets 'Database' initialized successfully!")
 }
 
+// EnsureSheet checks if a sheet exists, creates it if not, and adds missing headers
+func EnsureSheet(srv *sheets.Service, title string, headers []interface{}) error {
+	return ensureSheet(srv, title, headers)
+}
+
 // ensureSheet checks if a sheet exists, creates it if not, and adds headers if empty
 func ensureSheet(srv *sheets.Service, title string, headers []interface{}) error {
 	// Get Spreadsheet Metadata
-	meta, err := srv.Spreadsheets.Get(SpreadsheetID).Do()
+	meta, err := srv.Spreadsheets.Get(Get().SpreadsheetID).Do()
 	if err != nil {
 		return err
 	}
 
 	sheetExists := false
-	var sheetID int64
-	println(sheetID)
 
 	for _, s := range meta.Sheets {
 		if s.Properties.Title == title {
 			sheetExists = true
-			sheetID = s.Properties.SheetId
 			break
 		}
 	}
@@ -66,17 +68,15 @@ func ensureSheet(srv *sheets.Service, title string, headers []interface{}) error
 				},
 			},
 		}
-		resp, err := srv.Spreadsheets.BatchUpdate(SpreadsheetID, req).Do()
-		if err != nil {
+		if _, err := srv.Spreadsheets.BatchUpdate(Get().SpreadsheetID, req).Do(); err != nil {
 			return fmt.Errorf("failed to create sheet %s: %v", title, err)
 		}
-		sheetID = resp.Replies[0].AddSheet.Properties.SheetId
 		fmt.Printf("Created sheet: %s\n", title)
 	}
 
 	// Check headers
 	readRange := fmt.Sprintf("'%s'!1:1", title)
-	resp, err := srv.Spreadsheets.Values.Get(SpreadsheetID, readRange).Do()
+	resp, err := srv.Spreadsheets.Values.Get(Get().SpreadsheetID, readRange).Do()
 	if err != nil {
 		return err
 	}
@@ -86,11 +86,21 @@ func ensureSheet(srv *sheets.Service, title string, headers []interface{}) error
 		vr := &sheets.ValueRange{
 			Values: [][]interface{}{headers},
 		}
-		_, err := srv.Spreadsheets.Values.Update(SpreadsheetID, fmt.Sprintf("'%s'!A1", title), vr).ValueInputOption("RAW").Do()
+		_, err := srv.Spreadsheets.Values.Update(Get().SpreadsheetID, fmt.Sprintf("'%s'!A1", title), vr).ValueInputOption("RAW").Do()
 		if err != nil {
 			return fmt.Errorf("failed to write headers to %s: %v", title, err)
 		}
 		fmt.Printf("Added headers to: %s\n", title)
+	} else if have := len(resp.Values[0]); have < len(headers) {
+		// Columns added since the tab was created
+		vr := &sheets.ValueRange{
+			Values: [][]interface{}{headers[have:]},
+		}
+		_, err := srv.Spreadsheets.Values.Update(Get().SpreadsheetID, fmt.Sprintf("'%s'!%c1", title, 'A'+have), vr).ValueInputOption("RAW").Do()
+		if err != nil {
+			return fmt.Errorf("failed to extend headers of %s: %v", title, err)
+		}
+		fmt.Printf("Added %d headers to: %s\n", len(headers)-have, title)
 	}
 
 	return nil
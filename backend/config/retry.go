@@ -0,0 +1,357 @@
+// config/retry.go
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling Google while the circuit breaker is open
+var ErrCircuitOpen = errors.New("Sheets API is unavailable (circuit breaker open), try again later")
+
+// First backoff step; doubles on every retry up to SheetsAPIConfig.MaxBackoff
+const baseBackoff = 500 * time.Millisecond
+
+// APIStats are the counters of the Sheets/Drive call wrapper
+type APIStats struct {
+	Requests     int64  `json:"requests"`      // Calls made by the services
+	Retries      int64  `json:"retries"`       // Extra attempts, see canRetry
+	Throttled    int64  `json:"throttled"`     // Attempts delayed by the local rate limiter
+	RateLimited  int64  `json:"rate_limited"`  // 429 responses from Google
+	Failures     int64  `json:"failures"`      // Calls that still failed after all retries
+	Rejected     int64  `json:"rejected"`      // Calls refused while the circuit was open
+	BreakerState string `json:"breaker_state"` // "closed", "open" or "half-open"
+	BreakerOpens int64  `json:"breaker_opens"`
+}
+
+// callPolicy is the shared retry, rate limit and circuit breaker state.
+// It outlives credential reloads so limits and counters carry over.
+type callPolicy struct {
+	cfg     SheetsAPIConfig
+	reads   *tokenBucket
+	writes  *tokenBucket
+	breaker *breaker
+
+	requests, retries, throttled, rateLimited, failures, rejected int64
+}
+
+func newCallPolicy(cfg SheetsAPIConfig) *callPolicy {
+	return &callPolicy{
+		cfg:     cfg,
+		reads:   newTokenBucket(cfg.ReadsPerMinute),
+		writes:  newTokenBucket(cfg.WritesPerMinute),
+		breaker: &breaker{threshold: cfg.BreakerThreshold, cooldown: cfg.BreakerCooldown},
+	}
+}
+
+// stats snapshots the counters
+func (p *callPolicy) stats() APIStats {
+	state, opens := p.breaker.snapshot()
+	return APIStats{
+		Requests:     atomic.LoadInt64(&p.requests),
+		Retries:      atomic.LoadInt64(&p.retries),
+		Throttled:    atomic.LoadInt64(&p.throttled),
+		RateLimited:  atomic.LoadInt64(&p.rateLimited),
+		Failures:     atomic.LoadInt64(&p.failures),
+		Rejected:     atomic.LoadInt64(&p.rejected),
+		BreakerState: state,
+		BreakerOpens: opens,
+	}
+}
+
+// wrap puts the policy in front of next
+func (p *callPolicy) wrap(next http.RoundTripper) http.RoundTripper {
+	return &policyTransport{policy: p, next: next}
+}
+
+// policyTransport applies a callPolicy to every request
+type policyTransport struct {
+	policy *callPolicy
+	next   http.RoundTripper
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.policy
+	atomic.AddInt64(&p.requests, 1)
+
+	if !p.breaker.allow() {
+		atomic.AddInt64(&p.rejected, 1)
+		return nil, ErrCircuitOpen
+	}
+
+	// Buffer the body so every attempt can resend it
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			p.breaker.release()
+			return nil, err
+		}
+		req.GetBody = func() (io.ReadCloser, error) { return ioutil.NopCloser(bytes.NewReader(b)), nil }
+	}
+
+	bucket := p.writes
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		bucket = p.reads
+	}
+
+	for attempt := 0; ; attempt++ {
+		waited, err := bucket.wait(req)
+		if err != nil {
+			p.breaker.release()
+			return nil, err
+		}
+		if waited {
+			atomic.AddInt64(&p.throttled, 1)
+		}
+
+		out := req.Clone(req.Context())
+		if req.GetBody != nil {
+			if out.Body, err = req.GetBody(); err != nil {
+				p.breaker.release()
+				return nil, err
+			}
+		}
+
+		resp, err := t.next.RoundTrip(out)
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			atomic.AddInt64(&p.rateLimited, 1)
+		}
+
+		// A caller giving up says nothing about Google's health
+		if req.Context().Err() != nil {
+			p.breaker.release()
+			return resp, err
+		}
+
+		failed := isFailure(resp, err)
+		if !failed || !canRetry(req, resp, err) || attempt >= p.cfg.MaxRetries {
+			p.breaker.record(!failed)
+			if failed {
+				atomic.AddInt64(&p.failures, 1)
+			}
+			return resp, err
+		}
+
+		delay := p.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		atomic.AddInt64(&p.retries, 1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			p.breaker.release()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Helper: 429, 5xx and transport errors count against the breaker
+func isFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Helper: Whether a failed attempt may be sent again. Reads and the idempotent PUT
+// (values.update) are retried after any failure. POSTs (values.append, batchUpdate) may
+// already have been applied when the connection dropped or Google answered 5xx, so they
+// are retried only on 429 and when the connection was never made.
+func canRetry(req *http.Request, resp *http.Response, err error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	if err != nil {
+		return notSent(err)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests
+}
+
+// Helper: Whether a transport error happened before the request could reach the server
+func notSent(err error) bool {
+	var dns *net.DNSError
+	if errors.As(err, &dns) {
+		return true
+	}
+	var op *net.OpError
+	return errors.As(err, &op) && op.Op == "dial"
+}
+
+// Helper: Wait before the next attempt: Retry-After when Google sends one, else jittered
+// exponential backoff; never longer than MaxBackoff
+func (p *callPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if d > p.cfg.MaxBackoff {
+				d = p.cfg.MaxBackoff
+			}
+			return d
+		}
+	}
+
+	d := baseBackoff << uint(attempt)
+	if d <= 0 || d > p.cfg.MaxBackoff {
+		d = p.cfg.MaxBackoff
+	}
+	// Equal jitter: half fixed, half random
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Helper: Parse Retry-After as seconds or an HTTP date
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// tokenBucket allows perMinute calls a minute with bursts up to the same size
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // Tokens per second
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		last:     time.Now(),
+	}
+}
+
+// wait takes a token, sleeping until one is available; it reports whether it had to wait
+func (b *tokenBucket) wait(req *http.Request) (bool, error) {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	// Reserve the token now (possibly going negative) so waiters queue up in order
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return false, nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		// Give the reservation back
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return true, req.Context().Err()
+	case <-timer.C:
+		return true, nil
+	}
+}
+
+// breaker opens after threshold consecutive failures and lets one trial call through after cooldown
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	trial     bool // A half-open trial call is in flight
+	opens     int64
+}
+
+// allow reports whether a call may go out
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// record feeds the outcome of a call
+func (b *breaker) record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasOpen := b.failures >= b.threshold
+	b.trial = false
+	if ok {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		if !wasOpen {
+			b.opens++
+		}
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// release ends a call without an outcome (e.g. the caller cancelled it), freeing the
+// half-open trial slot without closing or reopening the circuit
+func (b *breaker) release() {
+	b.mu.Lock()
+	b.trial = false
+	b.mu.Unlock()
+}
+
+func (b *breaker) snapshot() (string, int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.failures < b.threshold:
+		return "closed", b.opens
+	case time.Now().Before(b.openUntil):
+		return "open", b.opens
+	default:
+		return "half-open", b.opens
+	}
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Mon, 01 Jan 2001 00:00:00 GMT", 0, true}, // Already passed
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := newCallPolicy(SheetsAPIConfig{MaxBackoff: 4 * time.Second})
+	retryAfter := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": {v}}}
+	}
+	tests := []struct {
+		name     string
+		attempt  int
+		resp     *http.Response
+		min, max time.Duration
+	}{
+		{"first retry", 0, nil, 250 * time.Millisecond, 500 * time.Millisecond},
+		{"doubles", 2, nil, time.Second, 2 * time.Second},
+		{"capped", 10, nil, 2 * time.Second, 4 * time.Second},
+		{"overflowing shift capped", 80, nil, 2 * time.Second, 4 * time.Second},
+		{"Retry-After", 0, retryAfter("2"), 2 * time.Second, 2 * time.Second},
+		{"Retry-After capped", 0, retryAfter("60"), 4 * time.Second, 4 * time.Second},
+		{"unparsable Retry-After", 0, retryAfter("later"), 250 * time.Millisecond, 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ { // The jitter is random
+			if d := p.backoff(tt.attempt, tt.resp); d < tt.min || d > tt.max {
+				t.Errorf("%s: backoff = %v, want within [%v, %v]", tt.name, d, tt.min, tt.max)
+				break
+			}
+		}
+	}
+}
+
+func TestCanRetry(t *testing.T) {
+	status := func(code int) *http.Response { return &http.Response{StatusCode: code} }
+	dialErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Err: errors.New("connection reset")}
+	tests := []struct {
+		method string
+		resp   *http.Response
+		err    error
+		want   bool
+	}{
+		{http.MethodGet, status(http.StatusServiceUnavailable), nil, true},
+		{http.MethodGet, nil, readErr, true},
+		{http.MethodPut, status(http.StatusInternalServerError), nil, true},
+		{http.MethodPost, status(http.StatusTooManyRequests), nil, true},
+		{http.MethodPost, status(http.StatusInternalServerError), nil, false}, // May have been applied
+		{http.MethodPost, nil, dialErr, true},
+		{http.MethodPost, nil, &net.DNSError{Err: "no such host"}, true},
+		{http.MethodPost, nil, readErr, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "https://sheets.example/v4", nil)
+		if got := canRetry(req, tt.resp, tt.err); got != tt.want {
+			t.Errorf("canRetry(%s, %v, %v) = %v, want %v", tt.method, tt.resp, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	type step struct {
+		op    string // "allow", "ok", "fail", "release" or "cool" (wait out the cooldown)
+		want  bool   // allow's answer
+		state string // State afterwards
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"opens after threshold failures", []step{
+			{"allow", true, "closed"}, {"fail", false, "closed"},
+			{"allow", true, "closed"}, {"fail", false, "open"},
+			{"allow", false, "open"},
+		}},
+		{"a success resets the count", []step{
+			{"fail", false, "closed"}, {"ok", false, "closed"}, {"fail", false, "closed"},
+		}},
+		{"one trial after cooldown, closing on success", []step{
+			{"fail", false, "closed"}, {"fail", false, "open"}, {"cool", false, "half-open"},
+			{"allow", true, "half-open"}, {"allow", false, "half-open"},
+			{"ok", false, "closed"}, {"allow", true, "closed"},
+		}},
+		{"a failed trial reopens", []step{
+			{"fail", false, "closed"}, {"fail", false, "open"}, {"cool", false, "half-open"},
+			{"allow", true, "half-open"}, {"fail", false, "open"}, {"allow", false, "open"},
+		}},
+		{"a released trial frees the slot", []step{
+			{"fail", false, "closed"}, {"fail", false, "open"}, {"cool", false, "half-open"},
+			{"allow", true, "half-open"}, {"release", false, "half-open"}, {"allow", true, "half-open"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &breaker{threshold: 2, cooldown: time.Hour}
+			for i, s := range tt.steps {
+				switch s.op {
+				case "allow":
+					if got := b.allow(); got != s.want {
+						t.Fatalf("step %d: allow = %v, want %v", i, got, s.want)
+					}
+				case "ok", "fail":
+					b.record(s.op == "ok")
+				case "release":
+					b.release()
+				case "cool":
+					b.openUntil = time.Now().Add(-time.Second)
+				}
+				if state, _ := b.snapshot(); state != s.state {
+					t.Fatalf("step %d (%s): state %s, want %s", i, s.op, state, s.state)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyTransportRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int // Answers in order; the last one repeats
+		want     int   // Final status
+		calls    int
+	}{
+		{"read retried until it succeeds", http.MethodGet, []int{503, 429, 200}, 200, 3},
+		{"read gives up after max_retries", http.MethodGet, []int{500}, 500, 3},
+		{"client error not retried", http.MethodGet, []int{404}, 404, 1},
+		{"write retried on 429", http.MethodPost, []int{429, 200}, 200, 2},
+		{"write not retried on 5xx", http.MethodPost, []int{502}, 502, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(atomic.AddInt32(&calls, 1)) - 1
+				if n >= len(tt.statuses) {
+					n = len(tt.statuses) - 1
+				}
+				if b, _ := io.ReadAll(r.Body); r.Method == http.MethodPost && string(b) != "body" {
+					t.Errorf("attempt %d: body %q, want it resent", n, b)
+				}
+				w.WriteHeader(tt.statuses[n])
+			}))
+			defer hs.Close()
+
+			policy := newCallPolicy(SheetsAPIConfig{ReadsPerMinute: 6000, WritesPerMinute: 6000, MaxRetries: 2,
+				MaxBackoff: time.Millisecond, BreakerThreshold: 100, BreakerCooldown: time.Minute})
+			client := &http.Client{Transport: policy.wrap(http.DefaultTransport)}
+			req, _ := http.NewRequest(tt.method, hs.URL, strings.NewReader("body"))
+			if tt.method == http.MethodGet {
+				req.Body = nil
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want || int(calls) != tt.calls {
+				t.Errorf("status %d after %d calls, want %d after %d", resp.StatusCode, calls, tt.want, tt.calls)
+			}
+			if st := policy.stats(); st.Retries != int64(tt.calls-1) {
+				t.Errorf("stats %+v, want %d retries", st, tt.calls-1)
+			}
+		})
+	}
+}
@@ -0,0 +1,806 @@
+// config/settings.go
+package config
+
+import (
+	"crypto/subtle"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the typed application configuration.
+// Precedence: defaults < YAML file < environment variables < command-line flags.
+type Config struct {
+	SpreadsheetID string            `yaml:"spreadsheet_id"`
+	Credentials   CredentialsConfig `yaml:"credentials"`
+	RoleSheets    []string          `yaml:"role_sheets"`  // Tabs holding the per-day task grid; seeds the team registry
+	DefaultRole   string            `yaml:"default_role"` // Tab used when a request has no role
+	StatusColors  StatusColors      `yaml:"status_colors"`
+	Server        ServerConfig      `yaml:"server"`
+	Auth          AuthConfig        `yaml:"auth"`
+	EditWindow    EditWindowConfig  `yaml:"edit_window"`
+	Storage       StorageConfig     `yaml:"storage"`
+	Sync          SyncConfig        `yaml:"sync"`
+	Cache         CacheConfig       `yaml:"cache"`
+	SheetsAPI     SheetsAPIConfig   `yaml:"sheets_api"`
+	Archive       ArchiveConfig     `yaml:"archive"`
+	CarryOver     CarryOverConfig   `yaml:"carry_over"`
+	Watch         WatchConfig       `yaml:"watch"`
+	Jobs          JobsConfig        `yaml:"jobs"`
+	Reminders     RemindersConfig   `yaml:"reminders"`
+	Webhooks      WebhooksConfig    `yaml:"webhooks"`
+	Events        EventsConfig      `yaml:"events"`
+	Audit         AuditConfig       `yaml:"audit"`
+	Calendar      CalendarConfig    `yaml:"calendar"`
+	Teams         TeamsConfig       `yaml:"teams"`
+}
+
+// CredentialsConfig selects where the Sheets credentials come from
+type CredentialsConfig struct {
+	File     string `yaml:"file"`     // Service account JSON file
+	JSON     string `yaml:"json"`     // Inline service account JSON (e.g. from a secret)
+	Endpoint string `yaml:"endpoint"` // API endpoint override, e.g. cmd/fakesheets; no credentials are used
+}
+
+// StatusColors is the palette used to read and write task statuses, as "#RRGGBB"
+type StatusColors struct {
+	Complete  string  `yaml:"complete"`
+	Pending   string  `yaml:"pending"`
+	Todo      string  `yaml:"todo"`
+	Tolerance float64 `yaml:"tolerance"` // Max per-channel distance (0-1) when matching a color
+}
+
+// ServerConfig is the HTTP listener
+type ServerConfig struct {
+	Listen      string    `yaml:"listen"`
+	TLS         TLSConfig `yaml:"tls"`
+	CORSOrigins []string  `yaml:"cors_origins"`
+}
+
+// AllowedOrigin returns the Access-Control-Allow-Origin value for a request origin ("" to omit it)
+func (s ServerConfig) AllowedOrigin(origin string) string {
+	for _, o := range s.CORSOrigins {
+		if o == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// TLSConfig enables HTTPS when both files are set
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// Enabled reports whether HTTPS is configured
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// AuthConfig identifies callers by bearer token. Without a token the X-Actor header is
+// taken at its word, which is not enough for the edit window's manager override.
+type AuthConfig struct {
+	Tokens map[string]string `yaml:"tokens"` // Actor name -> secret, sent as "Authorization: Bearer <secret>"
+}
+
+// ActorForToken returns the actor a bearer token belongs to, or "" when it is not one of Tokens
+func (a AuthConfig) ActorForToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	for name, secret := range a.Tokens {
+		if secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(token)) == 1 {
+			return strings.TrimSpace(name)
+		}
+	}
+	return ""
+}
+
+// EditWindowConfig decides which days can be written. The inline fields are the default rule;
+// an entry in Sheets replaces it for one role sheet.
+type EditWindowConfig struct {
+	EditWindowRule `yaml:",inline"`
+	Sheets         map[string]EditWindowRule `yaml:"sheets"`   // By role sheet title (case-insensitive)
+	Managers       []string                  `yaml:"managers"` // Actors given the manager window; they must authenticate with an auth.tokens entry
+}
+
+// EditWindowRule is the edit window of one role sheet: today and DaysBack days before it
+type EditWindowRule struct {
+	DaysBack        int           `yaml:"days_back"`
+	WorkingDays     bool          `yaml:"working_days"`      // Count DaysBack in working days of the employee's calendar
+	Timezone        string        `yaml:"timezone"`          // IANA zone "today" is computed in when the employee has none; empty = server zone
+	Grace           time.Duration `yaml:"grace"`             // After midnight, the previous day's window stays open this long
+	ManagerDaysBack int           `yaml:"manager_days_back"` // Wider window for managers; 0 = no override
+}
+
+// StorageConfig selects the storage backend
+type StorageConfig struct {
+	Backend     string `yaml:"backend"` // "sheets", "memory" or "postgres"
+	DatabaseURL string `yaml:"database_url"`
+}
+
+// CacheConfig controls the read-through cache in front of the storage backend
+type CacheConfig struct {
+	TTL        time.Duration `yaml:"ttl"`        // How long reads are served without asking the backend; 0 disables the cache
+	Revalidate bool          `yaml:"revalidate"` // After the TTL, keep entries while the spreadsheet revision is unchanged (any tab's write changes it)
+}
+
+// SheetsAPIConfig controls how calls to Google are throttled, retried and cut off
+type SheetsAPIConfig struct {
+	ReadsPerMinute   int           `yaml:"reads_per_minute"`  // Local token bucket, match the project's read quota
+	WritesPerMinute  int           `yaml:"writes_per_minute"` // Local token bucket, match the project's write quota
+	MaxRetries       int           `yaml:"max_retries"`       // Retries of 429, 5xx and network errors
+	MaxBackoff       time.Duration `yaml:"max_backoff"`       // Cap of the jittered exponential backoff
+	BreakerThreshold int           `yaml:"breaker_threshold"` // Consecutive failed calls that open the circuit
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown"`  // How long an open circuit fails fast
+}
+
+// ArchiveConfig controls the rollover of closed periods out of the role sheets into archive tabs
+type ArchiveConfig struct {
+	Period        string        `yaml:"period"`         // "year" ("DEV 2025"), "quarter" ("DEV 2025-Q3"); "" (the default) disables it
+	SpreadsheetID string        `yaml:"spreadsheet_id"` // Separate archive spreadsheet; empty keeps archive tabs next to the role sheets
+	Interval      time.Duration `yaml:"interval"`       // How often to check for a closed period; 0 rolls over only on request
+}
+
+// CarryOverConfig schedules copying unfinished tasks into each employee's today
+type CarryOverConfig struct {
+	Interval time.Duration `yaml:"interval"` // How often to carry over; 0 carries over only on request
+}
+
+// WatchConfig schedules looking for edits made directly in the role sheets (sheets backend only)
+type WatchConfig struct {
+	Interval time.Duration `yaml:"interval"` // How often to compare the sheets with the last snapshot; 0 checks only on request
+}
+
+// JobsConfig controls the background job scheduler
+type JobsConfig struct {
+	Tab       string            `yaml:"tab"`        // Jobs tab in the spreadsheet keeping job state on the sheets backend, unless store_file is set
+	StoreFile string            `yaml:"store_file"` // JSON file keeping job state on the other backends; empty keeps it in memory (one instance only)
+	Timezone  string            `yaml:"timezone"`   // Zone cron schedules are read in; empty is the server's
+	History   int               `yaml:"history"`    // Runs kept per job
+	Schedules map[string]string `yaml:"schedules"`  // Job name -> cron spec or "@every 15m"; "" runs the job only on request
+}
+
+// RemindersConfig controls reminders to employees with no update on a working day
+type RemindersConfig struct {
+	Schedule  string           `yaml:"schedule"` // Cron spec or "@every 1h", read in jobs.timezone; empty reminds only on request
+	Notifiers []NotifierConfig `yaml:"notifiers"`
+}
+
+// NotifierConfig is one channel reminders are sent through
+type NotifierConfig struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"` // "smtp", "webhook" or "slack"
+
+	// smtp
+	Addr     string            `yaml:"addr"` // host:port
+	Username string            `yaml:"username"`
+	Password string            `yaml:"password"`
+	From     string            `yaml:"from"`
+	To       []string          `yaml:"to"`     // Receive one digest per run
+	Emails   map[string]string `yaml:"emails"` // Employee name -> address, reminded personally
+
+	// webhook, slack
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// WebhooksConfig controls delivery of task and employee events to registered webhook endpoints
+type WebhooksConfig struct {
+	StoreFile   string        `yaml:"store_file"`   // JSON file keeping endpoints, deliveries and dead letters; empty keeps them in memory
+	MaxAttempts int           `yaml:"max_attempts"` // Attempts before a delivery becomes a dead letter
+	Backoff     time.Duration `yaml:"backoff"`      // Wait before the first retry, doubling after each failure
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+	Timeout     time.Duration `yaml:"timeout"` // Per attempt
+	History     int           `yaml:"history"` // Delivered deliveries kept, and dead letters kept
+
+	// Endpoints may be private, loopback or link-local addresses (e.g. a receiver in the same
+	// cluster). Off, such URLs are refused at registration and such connections at delivery.
+	AllowPrivate bool `yaml:"allow_private"`
+}
+
+// EventsConfig controls the dashboard's live feed at /events
+type EventsConfig struct {
+	Buffer int `yaml:"buffer"` // Messages kept for clients resuming with Last-Event-ID
+}
+
+// AuditConfig controls where the audit trail of task changes is kept. The postgres backend
+// keeps it in the audit_log table, the sheets backend on a tab, the others in a file.
+type AuditConfig struct {
+	Tab       string `yaml:"tab"`        // Audit tab in the spreadsheet (sheets backend)
+	StoreFile string `yaml:"store_file"` // JSON lines file used by the memory backend; empty keeps the trail in memory
+}
+
+// CalendarConfig defines working days: weekends, holiday sets and where per-employee leave is kept
+type CalendarConfig struct {
+	Weekend     []string           `yaml:"weekend"`      // Day names, e.g. [Saturday, Sunday]
+	Holidays    []string           `yaml:"holidays"`     // "2006-01-02" days off for everyone
+	HolidaySets []HolidaySetConfig `yaml:"holiday_sets"` // Holidays loaded from files
+	StoreFile   string             `yaml:"store_file"`   // JSON file keeping leave and holiday set assignments; empty keeps them in memory
+}
+
+// HolidaySetConfig is a named list of holidays read from an .ics or .csv (date,name) file
+type HolidaySetConfig struct {
+	Name string `yaml:"name"` // e.g. "IN", "US"
+	File string `yaml:"file"`
+}
+
+// TeamsConfig says where the team registry is kept. role_sheets seeds it on first start.
+type TeamsConfig struct {
+	Tab       string `yaml:"tab"`        // Registry tab in the spreadsheet, when a Sheets client is configured
+	StoreFile string `yaml:"store_file"` // JSON file used otherwise; empty keeps the registry in memory
+}
+
+// SyncConfig controls the Sheets <-> Postgres sync engine
+type SyncConfig struct {
+	Interval time.Duration `yaml:"interval"` // 0 disables sync
+}
+
+// Default returns the configuration used when nothing is overridden
+func Default() *Config {
+	return &Config{
+		SpreadsheetID: "1KCnvCP_jSmL-9zpqbmyU1OWOT-dZEUJdlOKf4ZZ2Brk",
+		Credentials:   CredentialsConfig{File: "credentials.json"},
+		RoleSheets:    []string{"DEV", "Managers"},
+		DefaultRole:   "DEV",
+		StatusColors: StatusColors{
+			Complete:  "#34A853",
+			Pending:   "#E7953F",
+			Todo:      "#000000",
+			Tolerance: 0.35,
+		},
+		Server: ServerConfig{
+			Listen:      ":8080",
+			CORSOrigins: []string{"*"},
+		},
+		EditWindow: EditWindowConfig{EditWindowRule: EditWindowRule{DaysBack: 1}},
+		Storage:    StorageConfig{Backend: "sheets"},
+		Cache:      CacheConfig{TTL: 30 * time.Second, Revalidate: true},
+		SheetsAPI: SheetsAPIConfig{
+			ReadsPerMinute:   60,
+			WritesPerMinute:  60,
+			MaxRetries:       5,
+			MaxBackoff:       32 * time.Second,
+			BreakerThreshold: 5,
+			BreakerCooldown:  30 * time.Second,
+		},
+		Archive:  ArchiveConfig{Interval: time.Hour}, // Off until archive.period is set
+		Watch:    WatchConfig{Interval: time.Minute},
+		Jobs:     JobsConfig{Tab: "jobs", History: 20, Schedules: map[string]string{}},
+		Webhooks: WebhooksConfig{MaxAttempts: 6, Backoff: 30 * time.Second, MaxBackoff: time.Hour, Timeout: 10 * time.Second, History: 500},
+		Events:   EventsConfig{Buffer: 1000},
+		Audit:    AuditConfig{Tab: "audit"},
+		Calendar: CalendarConfig{Weekend: []string{"Saturday", "Sunday"}},
+		Teams:    TeamsConfig{Tab: "teams"},
+	}
+}
+
+// Active configuration, replaced once at startup by Load
+var current = Default()
+
+// Get returns the active configuration
+func Get() *Config {
+	return current
+}
+
+// Set replaces the active configuration (startup and tools only)
+func Set(cfg *Config) {
+	current = cfg
+}
+
+// DefaultConfigFile is read when it exists and no other file is given
+const DefaultConfigFile = "config.yaml"
+
+// Load builds the configuration from the YAML file, environment and flags in args,
+// validates it and makes it the active configuration
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("go-backend", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file (default "+DefaultConfigFile+" if present)")
+	spreadsheetID := fs.String("spreadsheet", "", "spreadsheet ID")
+	listen := fs.String("listen", "", "listen address, e.g. :8080")
+	backend := fs.String("store", "", "storage backend: sheets, memory or postgres")
+	databaseURL := fs.String("database-url", "", "PostgreSQL connection string for the postgres backend")
+	syncInterval := fs.Duration("sync-interval", 0, "mirror the role sheets into postgres every interval (0 disables sync)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	// 1. File
+	path := *configFile
+	if path == "" {
+		if _, err := os.Stat(DefaultConfigFile); err == nil {
+			path = DefaultConfigFile
+		}
+	}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config file: %v", err)
+		}
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+		f.Close()
+		// An empty file decodes as io.EOF
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+	}
+
+	// 2. Environment
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	// 3. Flags (only those given explicitly)
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "spreadsheet":
+			cfg.SpreadsheetID = *spreadsheetID
+		case "listen":
+			cfg.Server.Listen = *listen
+		case "store":
+			cfg.Storage.Backend = *backend
+		case "database-url":
+			cfg.Storage.DatabaseURL = *databaseURL
+		case "sync-interval":
+			cfg.Sync.Interval = *syncInterval
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	Set(cfg)
+	return cfg, nil
+}
+
+// Helper: Override fields from environment variables
+func applyEnv(cfg *Config) error {
+	str := func(name string, dst *string) {
+		if v, ok := os.LookupEnv(name); ok {
+			*dst = v
+		}
+	}
+	list := func(name string, dst *[]string) {
+		if v, ok := os.LookupEnv(name); ok {
+			*dst = splitList(v)
+		}
+	}
+
+	str("SPREADSHEET_ID", &cfg.SpreadsheetID)
+	str("GOOGLE_CREDENTIALS_FILE", &cfg.Credentials.File)
+	str("GOOGLE_CREDENTIALS_JSON", &cfg.Credentials.JSON)
+	str("SHEETS_ENDPOINT", &cfg.Credentials.Endpoint)
+	list("ROLE_SHEETS", &cfg.RoleSheets)
+	str("DEFAULT_ROLE", &cfg.DefaultRole)
+	str("STATUS_COLOR_COMPLETE", &cfg.StatusColors.Complete)
+	str("STATUS_COLOR_PENDING", &cfg.StatusColors.Pending)
+	str("STATUS_COLOR_TODO", &cfg.StatusColors.Todo)
+	str("LISTEN_ADDR", &cfg.Server.Listen)
+	if v, ok := os.LookupEnv("PORT"); ok && os.Getenv("LISTEN_ADDR") == "" {
+		cfg.Server.Listen = ":" + v
+	}
+	str("TLS_CERT_FILE", &cfg.Server.TLS.CertFile)
+	str("TLS_KEY_FILE", &cfg.Server.TLS.KeyFile)
+	list("CORS_ORIGINS", &cfg.Server.CORSOrigins)
+	str("STORE_BACKEND", &cfg.Storage.Backend)
+	str("DATABASE_URL", &cfg.Storage.DatabaseURL)
+	str("EDIT_WINDOW_TIMEZONE", &cfg.EditWindow.Timezone)
+	list("EDIT_WINDOW_MANAGERS", &cfg.EditWindow.Managers)
+	if v, ok := os.LookupEnv("AUTH_TOKENS"); ok {
+		cfg.Auth.Tokens = map[string]string{}
+		for _, item := range splitList(v) {
+			name, secret, found := strings.Cut(item, "=")
+			if !found {
+				return fmt.Errorf("AUTH_TOKENS: %q is not name=secret", item)
+			}
+			cfg.Auth.Tokens[strings.TrimSpace(name)] = strings.TrimSpace(secret)
+		}
+	}
+	list("CALENDAR_WEEKEND", &cfg.Calendar.Weekend)
+	list("CALENDAR_HOLIDAYS", &cfg.Calendar.Holidays)
+	str("CALENDAR_STORE_FILE", &cfg.Calendar.StoreFile)
+	str("TEAMS_TAB", &cfg.Teams.Tab)
+	str("AUDIT_TAB", &cfg.Audit.Tab)
+	str("AUDIT_STORE_FILE", &cfg.Audit.StoreFile)
+	str("TEAMS_STORE_FILE", &cfg.Teams.StoreFile)
+	str("ARCHIVE_PERIOD", &cfg.Archive.Period)
+	str("ARCHIVE_SPREADSHEET_ID", &cfg.Archive.SpreadsheetID)
+	str("JOBS_TAB", &cfg.Jobs.Tab)
+	str("JOBS_STORE_FILE", &cfg.Jobs.StoreFile)
+	str("JOBS_TIMEZONE", &cfg.Jobs.Timezone)
+	str("REMINDERS_SCHEDULE", &cfg.Reminders.Schedule)
+	str("WEBHOOKS_STORE_FILE", &cfg.Webhooks.StoreFile)
+
+	if v, ok := os.LookupEnv("EDIT_WINDOW_DAYS_BACK"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("EDIT_WINDOW_DAYS_BACK: %q is not a number", v)
+		}
+		cfg.EditWindow.DaysBack = n
+	}
+	if v, ok := os.LookupEnv("EDIT_WINDOW_WORKING_DAYS"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("EDIT_WINDOW_WORKING_DAYS: %q is not a boolean", v)
+		}
+		cfg.EditWindow.WorkingDays = b
+	}
+	if v, ok := os.LookupEnv("EDIT_WINDOW_GRACE"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("EDIT_WINDOW_GRACE: %q is not a duration (e.g. 2h)", v)
+		}
+		cfg.EditWindow.Grace = d
+	}
+	if v, ok := os.LookupEnv("CACHE_TTL"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("CACHE_TTL: %q is not a duration (e.g. 30s, 5m)", v)
+		}
+		cfg.Cache.TTL = d
+	}
+	if v, ok := os.LookupEnv("WEBHOOKS_ALLOW_PRIVATE"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("WEBHOOKS_ALLOW_PRIVATE: %q is not a boolean", v)
+		}
+		cfg.Webhooks.AllowPrivate = b
+	}
+	if v, ok := os.LookupEnv("CACHE_REVALIDATE"); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("CACHE_REVALIDATE: %q is not a boolean", v)
+		}
+		cfg.Cache.Revalidate = b
+	}
+	for _, e := range []struct {
+		name string
+		dst  *int
+	}{
+		{"SHEETS_READS_PER_MINUTE", &cfg.SheetsAPI.ReadsPerMinute},
+		{"SHEETS_WRITES_PER_MINUTE", &cfg.SheetsAPI.WritesPerMinute},
+		{"SHEETS_MAX_RETRIES", &cfg.SheetsAPI.MaxRetries},
+	} {
+		if v, ok := os.LookupEnv(e.name); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a number", e.name, v)
+			}
+			*e.dst = n
+		}
+	}
+	if v, ok := os.LookupEnv("ARCHIVE_INTERVAL"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("ARCHIVE_INTERVAL: %q is not a duration (e.g. 1h)", v)
+		}
+		cfg.Archive.Interval = d
+	}
+	if v, ok := os.LookupEnv("CARRY_OVER_INTERVAL"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("CARRY_OVER_INTERVAL: %q is not a duration (e.g. 15m)", v)
+		}
+		cfg.CarryOver.Interval = d
+	}
+	if v, ok := os.LookupEnv("WATCH_INTERVAL"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("WATCH_INTERVAL: %q is not a duration (e.g. 1m)", v)
+		}
+		cfg.Watch.Interval = d
+	}
+	if v, ok := os.LookupEnv("SYNC_INTERVAL"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("SYNC_INTERVAL: %q is not a duration (e.g. 30s, 5m)", v)
+		}
+		cfg.Sync.Interval = d
+	}
+	return nil
+}
+
+// Helper: Split a comma-separated list, dropping blanks
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Validate checks the configuration and reports every problem at once
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if strings.TrimSpace(c.SpreadsheetID) == "" {
+		add("spreadsheet_id is required")
+	}
+
+	// Credentials are only needed when something talks to Google
+	needsSheets := strings.EqualFold(c.Storage.Backend, "sheets") || c.Sync.Interval > 0
+	if needsSheets && c.Credentials.Endpoint == "" {
+		switch {
+		case c.Credentials.JSON != "":
+		case c.Credentials.File == "":
+			add("credentials: set credentials.file, credentials.json or credentials.endpoint")
+		default:
+			if _, err := os.Stat(c.Credentials.File); err != nil {
+				add("credentials.file: %v", err)
+			}
+		}
+	}
+
+	if len(c.RoleSheets) == 0 {
+		add("role_sheets must list at least one tab")
+	}
+	seen := map[string]bool{}
+	for _, title := range c.RoleSheets {
+		key := strings.ToLower(strings.TrimSpace(title))
+		if key == "" {
+			add("role_sheets contains an empty name")
+		} else if seen[key] {
+			add("role_sheets lists %q twice", title)
+		}
+		seen[key] = true
+	}
+	if c.DefaultRole == "" {
+		add("default_role is required")
+	} else if !seen[strings.ToLower(c.DefaultRole)] {
+		add("default_role %q is not one of role_sheets %v", c.DefaultRole, c.RoleSheets)
+	}
+
+	for _, sc := range []struct{ name, color string }{
+		{"complete", c.StatusColors.Complete},
+		{"pending", c.StatusColors.Pending},
+		{"todo", c.StatusColors.Todo},
+	} {
+		if !hexColorPattern.MatchString(sc.color) {
+			add("status_colors.%s: %q is not a #RRGGBB color", sc.name, sc.color)
+		}
+	}
+	if c.StatusColors.Tolerance <= 0 || c.StatusColors.Tolerance > 1 {
+		add("status_colors.tolerance must be in (0, 1], got %v", c.StatusColors.Tolerance)
+	}
+
+	if c.Server.Listen == "" {
+		add("server.listen is required")
+	}
+	if c.Server.TLS.Enabled() {
+		if c.Server.TLS.CertFile == "" || c.Server.TLS.KeyFile == "" {
+			add("server.tls needs both cert_file and key_file")
+		}
+		for _, f := range []string{c.Server.TLS.CertFile, c.Server.TLS.KeyFile} {
+			if f == "" {
+				continue
+			}
+			if _, err := os.Stat(f); err != nil {
+				add("server.tls: %v", err)
+			}
+		}
+	}
+	if len(c.Server.CORSOrigins) == 0 {
+		add("server.cors_origins must list at least one origin (use \"*\" to allow any)")
+	}
+
+	for name, secret := range c.Auth.Tokens {
+		if strings.TrimSpace(name) == "" {
+			add("auth.tokens: a token has no actor name")
+		} else if len(secret) < 16 {
+			add("auth.tokens.%s: the secret must be at least 16 characters", name)
+		}
+	}
+
+	c.EditWindow.EditWindowRule.validate("edit_window", add)
+	for title, rule := range c.EditWindow.Sheets {
+		if !seen[strings.ToLower(strings.TrimSpace(title))] {
+			add("edit_window.sheets: %q is not one of role_sheets %v", title, c.RoleSheets)
+		}
+		rule.validate(fmt.Sprintf("edit_window.sheets.%s", title), add)
+	}
+
+	switch strings.ToLower(c.Storage.Backend) {
+	case "sheets", "memory":
+	case "postgres":
+		if c.Storage.DatabaseURL == "" {
+			add("storage.database_url is required for the postgres backend")
+		}
+	default:
+		add("storage.backend must be sheets, memory or postgres, got %q", c.Storage.Backend)
+	}
+
+	if c.Sync.Interval < 0 {
+		add("sync.interval must not be negative")
+	}
+	if c.Sync.Interval > 0 && !strings.EqualFold(c.Storage.Backend, "postgres") {
+		add("sync.interval requires the postgres storage backend")
+	}
+
+	if c.Cache.TTL < 0 {
+		add("cache.ttl must not be negative")
+	}
+
+	if c.SheetsAPI.ReadsPerMinute <= 0 || c.SheetsAPI.WritesPerMinute <= 0 {
+		add("sheets_api.reads_per_minute and writes_per_minute must be positive")
+	}
+	if c.SheetsAPI.MaxRetries < 0 {
+		add("sheets_api.max_retries must not be negative")
+	}
+	if c.SheetsAPI.MaxBackoff <= 0 {
+		add("sheets_api.max_backoff must be positive")
+	}
+	if c.SheetsAPI.BreakerThreshold <= 0 || c.SheetsAPI.BreakerCooldown <= 0 {
+		add("sheets_api.breaker_threshold and breaker_cooldown must be positive")
+	}
+
+	switch strings.ToLower(c.Archive.Period) {
+	case "", "year", "quarter":
+	default:
+		add("archive.period must be year, quarter or empty, got %q", c.Archive.Period)
+	}
+	if c.Archive.Interval < 0 {
+		add("archive.interval must not be negative")
+	}
+	if c.CarryOver.Interval < 0 {
+		add("carry_over.interval must not be negative")
+	}
+	if c.Watch.Interval < 0 {
+		add("watch.interval must not be negative")
+	}
+	if c.Jobs.History <= 0 {
+		add("jobs.history must be positive")
+	}
+	if c.Jobs.Timezone != "" {
+		if _, err := time.LoadLocation(c.Jobs.Timezone); err != nil {
+			add("jobs.timezone: %v", err)
+		}
+	}
+	if c.Webhooks.MaxAttempts <= 0 || c.Webhooks.History <= 0 {
+		add("webhooks.max_attempts and history must be positive")
+	}
+	if c.Webhooks.Backoff <= 0 || c.Webhooks.MaxBackoff < c.Webhooks.Backoff {
+		add("webhooks.backoff must be positive and at most max_backoff")
+	}
+	if c.Webhooks.Timeout <= 0 {
+		add("webhooks.timeout must be positive")
+	}
+	if c.Events.Buffer < 0 {
+		add("events.buffer must not be negative")
+	}
+	notifierNames := map[string]bool{}
+	for i, n := range c.Reminders.Notifiers {
+		field := fmt.Sprintf("reminders.notifiers[%d]", i)
+		key := strings.ToLower(strings.TrimSpace(n.Name))
+		if key == "" {
+			add("%s.name is required", field)
+		} else if notifierNames[key] {
+			add("%s: duplicate name %q", field, n.Name)
+		}
+		notifierNames[key] = true
+		switch strings.ToLower(n.Type) {
+		case "smtp":
+			if n.Addr == "" || n.From == "" {
+				add("%s: smtp needs addr and from", field)
+			}
+			if len(n.To) == 0 && len(n.Emails) == 0 {
+				add("%s: smtp needs to or emails", field)
+			}
+		case "webhook", "slack":
+			if !strings.HasPrefix(n.URL, "http://") && !strings.HasPrefix(n.URL, "https://") {
+				add("%s: %s needs an http(s) url", field, n.Type)
+			}
+		default:
+			add("%s.type must be smtp, webhook or slack, got %q", field, n.Type)
+		}
+	}
+
+	if strings.TrimSpace(c.Teams.Tab) == "" {
+		add("teams.tab is required")
+	} else if seen[strings.ToLower(strings.TrimSpace(c.Teams.Tab))] {
+		add("teams.tab %q is also a role sheet", c.Teams.Tab)
+	}
+	if strings.TrimSpace(c.Audit.Tab) == "" {
+		add("audit.tab is required")
+	} else if seen[strings.ToLower(strings.TrimSpace(c.Audit.Tab))] {
+		add("audit.tab %q is also a role sheet", c.Audit.Tab)
+	} else if strings.EqualFold(strings.TrimSpace(c.Audit.Tab), strings.TrimSpace(c.Teams.Tab)) {
+		add("audit.tab and teams.tab must differ")
+	}
+	if strings.TrimSpace(c.Jobs.Tab) == "" {
+		add("jobs.tab is required")
+	} else if seen[strings.ToLower(strings.TrimSpace(c.Jobs.Tab))] {
+		add("jobs.tab %q is also a role sheet", c.Jobs.Tab)
+	} else if strings.EqualFold(strings.TrimSpace(c.Jobs.Tab), strings.TrimSpace(c.Teams.Tab)) ||
+		strings.EqualFold(strings.TrimSpace(c.Jobs.Tab), strings.TrimSpace(c.Audit.Tab)) {
+		add("jobs.tab must differ from teams.tab and audit.tab")
+	}
+
+	for _, day := range c.Calendar.Weekend {
+		if _, ok := ParseWeekday(day); !ok {
+			add("calendar.weekend: %q is not a day name", day)
+		}
+	}
+	for _, day := range c.Calendar.Holidays {
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			add("calendar.holidays: %q is not a YYYY-MM-DD date", day)
+		}
+	}
+	setNames := map[string]bool{}
+	for i, set := range c.Calendar.HolidaySets {
+		key := strings.ToLower(strings.TrimSpace(set.Name))
+		switch {
+		case key == "":
+			add("calendar.holiday_sets[%d] needs a name", i)
+		case setNames[key]:
+			add("calendar.holiday_sets lists %q twice", set.Name)
+		}
+		setNames[key] = true
+		switch strings.ToLower(filepath.Ext(set.File)) {
+		case ".ics", ".csv":
+		default:
+			add("calendar.holiday_sets[%d].file must be an .ics or .csv file, got %q", i, set.File)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// Helper: Check one edit window rule, reporting problems under prefix
+func (r EditWindowRule) validate(prefix string, add func(format string, args ...interface{})) {
+	if r.DaysBack < 0 || r.ManagerDaysBack < 0 {
+		add("%s: days_back and manager_days_back must not be negative", prefix)
+	}
+	if r.Grace < 0 || r.Grace >= 24*time.Hour {
+		add("%s.grace must be between 0 and 24h", prefix)
+	}
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			add("%s.timezone: %v", prefix, err)
+		}
+	}
+}
+
+// ParseWeekday parses a day name ("Saturday" or "Sat", any case)
+func ParseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || (len(name) >= 3 && strings.HasPrefix(full, name)) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// ParseHexColor converts "#RRGGBB" to 0-1 channels
+func ParseHexColor(hex string) (r, g, b float64) {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	return float64(v>>16&0xFF) / 255.0, float64(v>>8&0xFF) / 255.0, float64(v&0xFF) / 255.0
+}
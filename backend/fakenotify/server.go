@@ -0,0 +1,195 @@
+// fakenotify/server.go
+package fakenotify
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is one mail or webhook call the server received
+type Message struct {
+	Kind       string            `json:"kind"` // "smtp" or "http"
+	ReceivedAt string            `json:"received_at"`
+	From       string            `json:"from,omitempty"` // smtp: envelope sender
+	To         []string          `json:"to,omitempty"`   // smtp: envelope recipients
+	Path       string            `json:"path,omitempty"` // http: request path
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body"` // smtp: the full message; http: the request body
+}
+
+// Server records mail delivered over SMTP and requests posted to any HTTP path except
+// /messages, which lists (GET) or clears (DELETE) what was received. Set Status to make
+// webhook calls fail.
+type Server struct {
+	mu       sync.Mutex
+	messages []Message
+	status   int
+}
+
+// NewServer returns an empty server answering webhooks with 200
+func NewServer() *Server {
+	return &Server{status: http.StatusOK}
+}
+
+// SetStatus sets the status code webhook calls are answered with
+func (s *Server) SetStatus(code int) {
+	s.mu.Lock()
+	s.status = code
+	s.mu.Unlock()
+}
+
+// Messages returns what was received, oldest first
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message{}, s.messages...)
+}
+
+// Helper: Keep a received message
+func (s *Server) record(m Message) {
+	m.ReceivedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	s.mu.Lock()
+	s.messages = append(s.messages, m)
+	s.mu.Unlock()
+	log.Printf("Received %s message (%d bytes)", m.Kind, len(m.Body))
+}
+
+// ServeHTTP records webhook calls and serves /messages
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/messages" {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(s.Messages())
+		case http.MethodDelete:
+			s.mu.Lock()
+			s.messages = nil
+			s.mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	headers := map[string]string{}
+	for k := range r.Header {
+		headers[k] = r.Header.Get(k)
+	}
+	s.record(Message{Kind: "http", Path: r.URL.Path, Headers: headers, Body: string(body)})
+
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+	w.WriteHeader(status)
+	if status == http.StatusOK {
+		io.WriteString(w, "ok")
+	}
+}
+
+// ListenSMTP accepts mail on addr until the listener fails. Any credentials are accepted.
+func (s *Server) ListenSMTP(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.ServeSMTP(ln)
+}
+
+// ServeSMTP accepts mail on ln until it is closed
+func (s *Server) ServeSMTP(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.smtpSession(conn)
+	}
+}
+
+// Helper: Speak just enough SMTP for net/smtp.SendMail
+func (s *Server) smtpSession(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Minute))
+	rd := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+
+	reply("220 fakenotify ESMTP ready")
+	var msg Message
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-fakenotify")
+			reply("250-AUTH PLAIN LOGIN")
+			reply("250 8BITMIME")
+		case "HELO":
+			reply("250 fakenotify")
+		case "AUTH":
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			msg = Message{Kind: "smtp", From: smtpPath(arg)}
+			reply("250 OK")
+		case "RCPT":
+			msg.To = append(msg.To, smtpPath(arg))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var body strings.Builder
+			for {
+				l, err := rd.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimRight(l, "\r\n") == "." {
+					break
+				}
+				body.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.Body = body.String()
+			s.record(msg)
+			reply("250 OK: queued")
+		case "RSET":
+			msg = Message{Kind: "smtp"}
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// Helper: Address of "FROM:<a@b>" or "TO:<a@b>"
+func smtpPath(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr = strings.TrimSpace(addr)
+	if i := strings.Index(addr, ">"); i >= 0 {
+		addr = addr[:i]
+	}
+	return strings.TrimPrefix(addr, "<")
+}
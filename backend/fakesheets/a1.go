@@ -0,0 +1,134 @@
+// fakesheets/a1.go
+package fakesheets
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// gridRange is a zero-based, end-exclusive range; -1 means unbounded
+type gridRange struct {
+	startRow, endRow int
+	startCol, endCol int
+}
+
+var cellRefPattern = regexp.MustCompile(`^([A-Za-z]*)([0-9]*)$`)
+
+// Helper: Column letters to zero-based index ("A" -> 0, "ZZ" -> 701)
+func columnIndex(letters string) int {
+	n := 0
+	for _, ch := range strings.ToUpper(letters) {
+		n = n*26 + int(ch-'A'+1)
+	}
+	return n - 1
+}
+
+// Helper: Zero-based index to column letters
+func columnName(idx int) string {
+	name := ""
+	for n := idx + 1; n > 0; {
+		n--
+		name = string(rune('A'+(n%26))) + name
+		n /= 26
+	}
+	return name
+}
+
+// Helper: Split "'My Sheet'!A1:B2" into the sheet title and the cell part
+func splitSheetAndRange(a1 string) (title string, cells string, hasTitle bool, err error) {
+	a1 = strings.TrimSpace(a1)
+	if strings.HasPrefix(a1, "'") {
+		var b strings.Builder
+		i := 1
+		for ; i < len(a1); i++ {
+			if a1[i] == '\'' {
+				if i+1 < len(a1) && a1[i+1] == '\'' {
+					b.WriteByte('\'')
+					i++
+					continue
+				}
+				break
+			}
+			b.WriteByte(a1[i])
+		}
+		if i >= len(a1) {
+			return "", "", false, fmt.Errorf("unable to parse range: %s", a1)
+		}
+		rest := a1[i+1:]
+		if rest == "" {
+			return b.String(), "", true, nil
+		}
+		if !strings.HasPrefix(rest, "!") {
+			return "", "", false, fmt.Errorf("unable to parse range: %s", a1)
+		}
+		return b.String(), rest[1:], true, nil
+	}
+
+	if idx := strings.LastIndex(a1, "!"); idx != -1 {
+		return a1[:idx], a1[idx+1:], true, nil
+	}
+	return "", a1, false, nil
+}
+
+// Helper: Parse the cell part of an A1 range ("A2:E", "1:1", "C5", "A:ZZ")
+func parseCells(cells string) (gridRange, error) {
+	g := gridRange{startRow: 0, endRow: -1, startCol: 0, endCol: -1}
+	if cells == "" {
+		return g, nil
+	}
+
+	parts := strings.Split(cells, ":")
+	if len(parts) > 2 {
+		return g, fmt.Errorf("unable to parse range: %s", cells)
+	}
+
+	start := cellRefPattern.FindStringSubmatch(parts[0])
+	if start == nil || (start[1] == "" && start[2] == "") {
+		return g, fmt.Errorf("unable to parse range: %s", cells)
+	}
+	if start[1] != "" {
+		g.startCol = columnIndex(start[1])
+	}
+	if start[2] != "" {
+		row, _ := strconv.Atoi(start[2])
+		if row < 1 {
+			return g, fmt.Errorf("unable to parse range: %s", cells)
+		}
+		g.startRow = row - 1
+	}
+
+	if len(parts) == 1 {
+		// Single reference: a cell, a whole column or a whole row
+		if start[1] != "" {
+			g.endCol = g.startCol + 1
+		}
+		if start[2] != "" {
+			g.endRow = g.startRow + 1
+		}
+		return g, nil
+	}
+
+	end := cellRefPattern.FindStringSubmatch(parts[1])
+	if end == nil || (end[1] == "" && end[2] == "") {
+		return g, fmt.Errorf("unable to parse range: %s", cells)
+	}
+	if end[1] != "" {
+		g.endCol = columnIndex(end[1]) + 1
+	}
+	if end[2] != "" {
+		row, _ := strconv.Atoi(end[2])
+		g.endRow = row
+	}
+	if (g.endCol != -1 && g.endCol <= g.startCol) || (g.endRow != -1 && g.endRow <= g.startRow) {
+		return g, fmt.Errorf("unable to parse range: %s", cells)
+	}
+	return g, nil
+}
+
+// Helper: Format a bounded range back to A1 notation
+func formatRange(title string, g gridRange) string {
+	return fmt.Sprintf("'%s'!%s%d:%s%d", strings.ReplaceAll(title, "'", "''"),
+		columnName(g.startCol), g.startRow+1, columnName(g.endCol-1), g.endRow)
+}
@@ -0,0 +1,132 @@
+// fakesheets/fields.go
+package fakesheets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fieldMask is a parsed partial-response mask; a nil mask keeps everything
+type fieldMask map[string]fieldMask
+
+// parseFieldMask parses masks such as "sheets(properties,data(rowData(values(userEnteredValue))))"
+// and "sheets.properties.title"
+func parseFieldMask(s string) (fieldMask, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "*" {
+		return nil, nil
+	}
+	m, rest, err := parseMaskList(s)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid field mask near %q", rest)
+	}
+	return m, nil
+}
+
+// Helper: Parse a comma-separated list of mask items, stopping at ')' or the end
+func parseMaskList(s string) (fieldMask, string, error) {
+	m := fieldMask{}
+	for {
+		var err error
+		s, err = parseMaskItem(s, m)
+		if err != nil {
+			return nil, s, err
+		}
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+			continue
+		}
+		return m, s, nil
+	}
+}
+
+// Helper: Parse one "a.b.c(sub)" item into m
+func parseMaskItem(s string, m fieldMask) (string, error) {
+	end := strings.IndexAny(s, ",()")
+	if end == -1 {
+		end = len(s)
+	}
+	path := strings.TrimSpace(s[:end])
+	if path == "" {
+		return s, fmt.Errorf("invalid field mask near %q", s)
+	}
+	s = s[end:]
+
+	var sub fieldMask
+	if strings.HasPrefix(s, "(") {
+		var err error
+		sub, s, err = parseMaskList(s[1:])
+		if err != nil {
+			return s, err
+		}
+		if !strings.HasPrefix(s, ")") {
+			return s, fmt.Errorf("unbalanced parentheses in field mask")
+		}
+		s = s[1:]
+	}
+
+	// Nest dotted paths: a.b(c) == a(b(c))
+	names := strings.Split(path, ".")
+	node := m
+	for i, name := range names {
+		if name == "*" {
+			// Wildcard keeps everything below this level
+			return s, nil
+		}
+		last := i == len(names)-1
+		existing, ok := node[name]
+		switch {
+		case last && sub == nil:
+			node[name] = nil
+		case last:
+			if ok && existing == nil {
+				break
+			}
+			if existing == nil {
+				existing = fieldMask{}
+				node[name] = existing
+			}
+			for k, v := range sub {
+				existing[k] = v
+			}
+		default:
+			if ok && existing == nil {
+				return s, nil
+			}
+			if existing == nil {
+				existing = fieldMask{}
+				node[name] = existing
+			}
+			node = existing
+		}
+	}
+	return s, nil
+}
+
+// apply filters a decoded JSON value down to the mask
+func (m fieldMask) apply(v interface{}) interface{} {
+	if m == nil {
+		return v
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for k, sub := range m {
+			if child, ok := val[k]; ok {
+				out[k] = sub.apply(child)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			out = append(out, m.apply(item))
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,1074 @@
+// fakesheets/server.go
+// Package fakesheets is a local stand-in for the subset of the Google Sheets v4 REST API
+// used by the backend: spreadsheets.get, values.get/update/append and batchUpdate
+// with AddSheet, AppendDimension, InsertDimension, DeleteDimension and UpdateCells, plus Drive files.get for the
+// spreadsheet's version and modifiedTime. Errors can be injected with FailNext
+// or POST /_fake/fail to exercise retries.
+package fakesheets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Default grid size of a new tab, as in Google Sheets
+const (
+	defaultRowCount    = 1000
+	defaultColumnCount = 26
+)
+
+// Server is an in-memory Sheets API; it implements http.Handler
+type Server struct {
+	mu           sync.Mutex
+	spreadsheets map[string]*spreadsheet
+	nextSheetID  int64
+	dataFile     string // Optional JSON snapshot, rewritten after every write
+	faults       []fault
+}
+
+// fault is an injected error response, see FailNext
+type fault struct {
+	status     int
+	retryAfter string
+}
+
+type spreadsheet struct {
+	id       string
+	title    string
+	sheets   []*sheet
+	version  int64     // Drive file version, bumped on every write
+	modified time.Time // Drive modifiedTime
+}
+
+// Helper: Record a write for Drive's version/modifiedTime
+func (ss *spreadsheet) touch() {
+	ss.version++
+	ss.modified = time.Now().UTC()
+}
+
+type sheet struct {
+	id       int64
+	title    string
+	rowCount int
+	colCount int
+	rows     [][]*sheets.CellData // Sparse: only as long as the written cells
+}
+
+// NewServer returns an empty Server
+func NewServer() *Server {
+	return &Server{spreadsheets: map[string]*spreadsheet{}, nextSheetID: 1}
+}
+
+// AddSpreadsheet creates a spreadsheet with the given tabs and returns the tabs it had to create
+func (s *Server) AddSpreadsheet(id string, tabs ...string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ss := s.spreadsheets[id]
+	if ss == nil {
+		ss = &spreadsheet{id: id, title: "Spreadsheet " + id}
+		ss.touch()
+		s.spreadsheets[id] = ss
+	}
+	var created []string
+	for _, title := range tabs {
+		if ss.findSheet(title) == nil {
+			ss.sheets = append(ss.sheets, s.newSheet(title, 0, defaultRowCount, defaultColumnCount))
+			created = append(created, title)
+		}
+	}
+	return created
+}
+
+// SetValue writes a plain string into a cell, e.g. to seed header rows
+func (s *Server) SetValue(id, tab string, row, col int, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ss := s.spreadsheets[id]
+	if ss == nil {
+		return fmt.Errorf("spreadsheet %s not found", id)
+	}
+	sh := ss.findSheet(tab)
+	if sh == nil {
+		return fmt.Errorf("sheet %s not found", tab)
+	}
+	sh.setCell(row, col, valueCell(sh.cell(row, col), value, "RAW"))
+	ss.touch()
+	return nil
+}
+
+// FailNext makes the next count API requests fail with status (e.g. 429 or 503),
+// sending retryAfter as the Retry-After header when it is not empty
+func (s *Server) FailNext(count, status int, retryAfter string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < count; i++ {
+		s.faults = append(s.faults, fault{status: status, retryAfter: retryAfter})
+	}
+}
+
+// Helper: Pop the next injected fault, if any
+func (s *Server) nextFault() (fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.faults) == 0 {
+		return fault{}, false
+	}
+	f := s.faults[0]
+	s.faults = s.faults[1:]
+	return f, true
+}
+
+// Helper: POST /_fake/fail?count=3&status=429&retry_after=1 queues injected faults
+func (s *Server) injectFaults(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	count, err := strconv.Atoi(q.Get("count"))
+	if err != nil || count < 1 {
+		count = 1
+	}
+	status, err := strconv.Atoi(q.Get("status"))
+	if err != nil || status < 400 {
+		status = http.StatusServiceUnavailable
+	}
+	s.FailNext(count, status, q.Get("retry_after"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Helper: Allocate a sheet with a fresh ID unless one is given
+func (s *Server) newSheet(title string, id int64, rows, cols int) *sheet {
+	if id == 0 {
+		id = s.nextSheetID
+	}
+	if id >= s.nextSheetID {
+		s.nextSheetID = id + 1
+	}
+	return &sheet{id: id, title: title, rowCount: rows, colCount: cols}
+}
+
+func (ss *spreadsheet) findSheet(title string) *sheet {
+	for _, sh := range ss.sheets {
+		if strings.EqualFold(sh.title, title) {
+			return sh
+		}
+	}
+	return nil
+}
+
+func (ss *spreadsheet) findSheetByID(id int64) *sheet {
+	for _, sh := range ss.sheets {
+		if sh.id == id {
+			return sh
+		}
+	}
+	return nil
+}
+
+// Helper: Resolve an A1 range to its sheet and bounds
+func (ss *spreadsheet) resolve(a1 string) (*sheet, gridRange, error) {
+	title, cells, hasTitle, err := splitSheetAndRange(a1)
+	if err != nil {
+		return nil, gridRange{}, err
+	}
+	if !hasTitle {
+		// A bare name is a sheet title if one matches, otherwise a range on the first sheet
+		if sh := ss.findSheet(cells); sh != nil {
+			return sh, gridRange{0, -1, 0, -1}, nil
+		}
+		if len(ss.sheets) == 0 {
+			return nil, gridRange{}, fmt.Errorf("unable to parse range: %s", a1)
+		}
+		g, err := parseCells(cells)
+		return ss.sheets[0], g, err
+	}
+
+	sh := ss.findSheet(title)
+	if sh == nil {
+		return nil, gridRange{}, fmt.Errorf("unable to parse range: %s", a1)
+	}
+	g, err := parseCells(cells)
+	return sh, g, err
+}
+
+// Helper: Clamp unbounded or oversized ends to the grid (reads never fail on size)
+func (sh *sheet) clamp(g gridRange) gridRange {
+	if g.endRow == -1 || g.endRow > sh.rowCount {
+		g.endRow = sh.rowCount
+	}
+	if g.endCol == -1 || g.endCol > sh.colCount {
+		g.endCol = sh.colCount
+	}
+	return g
+}
+
+func (sh *sheet) cell(r, c int) *sheets.CellData {
+	if r < 0 || r >= len(sh.rows) || c < 0 || c >= len(sh.rows[r]) {
+		return nil
+	}
+	return sh.rows[r][c]
+}
+
+// setCell replaces a cell; cells are never mutated in place so snapshots can share them
+func (sh *sheet) setCell(r, c int, cd *sheets.CellData) {
+	for len(sh.rows) <= r {
+		sh.rows = append(sh.rows, nil)
+	}
+	for len(sh.rows[r]) <= c {
+		sh.rows[r] = append(sh.rows[r], nil)
+	}
+	if cellEmpty(cd) {
+		cd = nil
+	}
+	sh.rows[r][c] = cd
+}
+
+// Helper: Copy a sheet's structure (cells are shared, see setCell)
+func (sh *sheet) clone() *sheet {
+	cp := *sh
+	cp.rows = make([][]*sheets.CellData, len(sh.rows))
+	for i, row := range sh.rows {
+		cp.rows[i] = append([]*sheets.CellData(nil), row...)
+	}
+	return &cp
+}
+
+func cellEmpty(cd *sheets.CellData) bool {
+	return cd == nil || (cd.UserEnteredValue == nil && cd.UserEnteredFormat == nil &&
+		len(cd.TextFormatRuns) == 0 && cd.Note == "")
+}
+
+// Helper: The display string of a cell, as values.get returns it
+func formattedValue(cd *sheets.CellData) string {
+	if cd == nil || cd.UserEnteredValue == nil {
+		return ""
+	}
+	v := cd.UserEnteredValue
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.NumberValue != nil:
+		return strconv.FormatFloat(*v.NumberValue, 'f', -1, 64)
+	case v.BoolValue != nil:
+		if *v.BoolValue {
+			return "TRUE"
+		}
+		return "FALSE"
+	case v.FormulaValue != nil:
+		return *v.FormulaValue
+	}
+	return ""
+}
+
+// Helper: Build a cell from a values API input, keeping the old cell's format.
+// Writing a value drops rich-text runs, as in Google Sheets.
+func valueCell(old *sheets.CellData, value interface{}, inputOption string) *sheets.CellData {
+	cd := &sheets.CellData{}
+	if old != nil {
+		cd.UserEnteredFormat = old.UserEnteredFormat
+		cd.Note = old.Note
+	}
+
+	switch v := value.(type) {
+	case nil:
+	case string:
+		if v == "" {
+			break
+		}
+		if inputOption == "USER_ENTERED" {
+			if strings.HasPrefix(v, "=") {
+				cd.UserEnteredValue = &sheets.ExtendedValue{FormulaValue: &v}
+				break
+			}
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				cd.UserEnteredValue = &sheets.ExtendedValue{NumberValue: &f}
+				break
+			}
+		}
+		cd.UserEnteredValue = &sheets.ExtendedValue{StringValue: &v}
+	case float64:
+		cd.UserEnteredValue = &sheets.ExtendedValue{NumberValue: &v}
+	case bool:
+		cd.UserEnteredValue = &sheets.ExtendedValue{BoolValue: &v}
+	default:
+		str := fmt.Sprintf("%v", v)
+		cd.UserEnteredValue = &sheets.ExtendedValue{StringValue: &str}
+	}
+	return cd
+}
+
+// Helper: Read a range as grid data, trimming trailing empty rows and cells
+func (sh *sheet) gridData(g gridRange) *sheets.GridData {
+	g = sh.clamp(g)
+	gd := &sheets.GridData{StartRow: int64(g.startRow), StartColumn: int64(g.startCol)}
+
+	var rows []*sheets.RowData
+	lastRow := -1
+	for r := g.startRow; r < g.endRow && r < len(sh.rows); r++ {
+		var values []*sheets.CellData
+		lastCol := -1
+		for c := g.startCol; c < g.endCol; c++ {
+			cd := sh.cell(r, c)
+			if cd == nil {
+				values = append(values, &sheets.CellData{})
+				continue
+			}
+			out := *cd
+			if cd.UserEnteredValue != nil {
+				out.EffectiveValue = cd.UserEnteredValue
+				out.FormattedValue = formattedValue(cd)
+			}
+			values = append(values, &out)
+			lastCol = len(values) - 1
+		}
+		rows = append(rows, &sheets.RowData{Values: values[:lastCol+1]})
+		if lastCol >= 0 {
+			lastRow = len(rows) - 1
+		}
+	}
+	gd.RowData = rows[:lastRow+1]
+	return gd
+}
+
+// Helper: Read a range as formatted values, trimming trailing empty rows and cells
+func (sh *sheet) values(g gridRange) [][]interface{} {
+	g = sh.clamp(g)
+	var out [][]interface{}
+	lastRow := -1
+	for r := g.startRow; r < g.endRow && r < len(sh.rows); r++ {
+		var row []interface{}
+		lastCol := -1
+		for c := g.startCol; c < g.endCol; c++ {
+			v := formattedValue(sh.cell(r, c))
+			row = append(row, v)
+			if v != "" {
+				lastCol = len(row) - 1
+			}
+		}
+		out = append(out, row[:lastCol+1])
+		if lastCol >= 0 {
+			lastRow = len(out) - 1
+		}
+	}
+	return out[:lastRow+1]
+}
+
+// Helper: Write a block of values starting at (row, col)
+func (sh *sheet) writeValues(row, col int, values [][]interface{}, inputOption string) (int, int, error) {
+	maxCols := 0
+	for _, r := range values {
+		if len(r) > maxCols {
+			maxCols = len(r)
+		}
+	}
+	if row+len(values) > sh.rowCount || col+maxCols > sh.colCount {
+		return 0, 0, fmt.Errorf("Range ('%s'!%s%d) exceeds grid limits. Max rows: %d, max columns: %d",
+			sh.title, columnName(col+maxCols-1), row+len(values), sh.rowCount, sh.colCount)
+	}
+	for i, r := range values {
+		for j, v := range r {
+			sh.setCell(row+i, col+j, valueCell(sh.cell(row+i, col+j), v, inputOption))
+		}
+	}
+	return len(values), maxCols, nil
+}
+
+// apiError is the JSON error body of the Google APIs
+type apiError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	var e apiError
+	e.Error.Code = code
+	e.Error.Message = message
+	switch code {
+	case http.StatusNotFound:
+		e.Error.Status = "NOT_FOUND"
+	case http.StatusBadRequest:
+		e.Error.Status = "INVALID_ARGUMENT"
+	case http.StatusTooManyRequests:
+		e.Error.Status = "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		e.Error.Status = "UNAVAILABLE"
+	default:
+		e.Error.Status = "INTERNAL"
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(e)
+}
+
+// Helper: Encode v as JSON, applying the request's "fields" mask
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	mask, err := parseFieldMask(r.URL.Query().Get("fields"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var out interface{} = v
+	if mask != nil {
+		b, err := json.Marshal(v)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		var generic interface{}
+		json.Unmarshal(b, &generic)
+		out = mask.apply(generic)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	json.NewEncoder(w).Encode(out)
+}
+
+// ServeHTTP routes /v4/spreadsheets/... and /drive/v3/files/... requests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Work on the escaped path: ranges are escaped, the ":append"/":batchUpdate" suffixes are not
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/")
+	if path == "_fake/fail" && r.Method == http.MethodPost {
+		s.injectFaults(w, r)
+		return
+	}
+	if f, ok := s.nextFault(); ok {
+		if f.retryAfter != "" {
+			w.Header().Set("Retry-After", f.retryAfter)
+		}
+		writeError(w, f.status, "injected fault")
+		return
+	}
+	if strings.HasPrefix(path, "drive/v3/files/") && r.Method == http.MethodGet {
+		s.getFile(w, r, strings.TrimPrefix(path, "drive/v3/files/"))
+		return
+	}
+	if !strings.HasPrefix(path, "v4/spreadsheets/") {
+		writeError(w, http.StatusNotFound, "unknown endpoint: "+r.URL.Path)
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(path, "v4/spreadsheets/"), "/", 3)
+
+	idPart := parts[0]
+	action := ""
+	if i := strings.Index(idPart, ":"); i != -1 {
+		idPart, action = idPart[:i], idPart[i+1:]
+	}
+	id, err := url.PathUnescape(idPart)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ss := s.spreadsheets[id]
+	if ss == nil {
+		writeError(w, http.StatusNotFound, "Requested entity was not found.")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && action == "" && r.Method == http.MethodGet:
+		s.getSpreadsheet(w, r, ss)
+
+	case len(parts) == 1 && action == "batchUpdate" && r.Method == http.MethodPost:
+		s.batchUpdate(w, r, ss)
+
+	case len(parts) == 3 && parts[1] == "values" && action == "":
+		rangePart := parts[2]
+		isAppend := strings.HasSuffix(rangePart, ":append")
+		rangePart = strings.TrimSuffix(rangePart, ":append")
+		a1, err := url.PathUnescape(rangePart)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && !isAppend:
+			s.getValues(w, r, ss, a1)
+		case r.Method == http.MethodPut && !isAppend:
+			s.updateValues(w, r, ss, a1)
+		case r.Method == http.MethodPost && isAppend:
+			s.appendValues(w, r, ss, a1)
+		default:
+			writeError(w, http.StatusNotFound, "unsupported values method")
+		}
+
+	default:
+		writeError(w, http.StatusNotFound, "unsupported endpoint: "+r.Method+" "+r.URL.Path)
+	}
+}
+
+// Drive files.get: the spreadsheet's file metadata
+func (s *Server) getFile(w http.ResponseWriter, r *http.Request, escapedID string) {
+	id, err := url.PathUnescape(escapedID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ss := s.spreadsheets[id]
+	if ss == nil {
+		writeError(w, http.StatusNotFound, "File not found: "+id+".")
+		return
+	}
+	writeJSON(w, r, map[string]interface{}{
+		"kind":         "drive#file",
+		"id":           ss.id,
+		"name":         ss.title,
+		"mimeType":     "application/vnd.google-apps.spreadsheet",
+		"version":      strconv.FormatInt(ss.version, 10),
+		"modifiedTime": ss.modified.Format(time.RFC3339Nano),
+	})
+}
+
+// spreadsheets.get
+func (s *Server) getSpreadsheet(w http.ResponseWriter, r *http.Request, ss *spreadsheet) {
+	q := r.URL.Query()
+	// A fields mask that asks for data implies includeGridData
+	includeGrid := q.Get("includeGridData") == "true" || strings.Contains(q.Get("fields"), "data")
+	ranges := q["ranges"]
+
+	resp := &sheets.Spreadsheet{
+		SpreadsheetId:  ss.id,
+		Properties:     &sheets.SpreadsheetProperties{Title: ss.title, Locale: "en_US", TimeZone: "Etc/GMT"},
+		SpreadsheetUrl: "http://localhost/spreadsheets/d/" + ss.id,
+	}
+
+	if len(ranges) == 0 {
+		for _, sh := range ss.sheets {
+			out := &sheets.Sheet{Properties: sheetProperties(ss, sh)}
+			if includeGrid {
+				out.Data = []*sheets.GridData{sh.gridData(gridRange{0, -1, 0, -1})}
+			}
+			resp.Sheets = append(resp.Sheets, out)
+		}
+		writeJSON(w, r, resp)
+		return
+	}
+
+	// Only the sheets named by the ranges are returned, in spreadsheet order
+	byID := map[int64]*sheets.Sheet{}
+	for _, a1 := range ranges {
+		sh, g, err := ss.resolve(a1)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		out := byID[sh.id]
+		if out == nil {
+			out = &sheets.Sheet{Properties: sheetProperties(ss, sh)}
+			byID[sh.id] = out
+		}
+		if includeGrid {
+			out.Data = append(out.Data, sh.gridData(g))
+		}
+	}
+	for _, sh := range ss.sheets {
+		if out := byID[sh.id]; out != nil {
+			resp.Sheets = append(resp.Sheets, out)
+		}
+	}
+	writeJSON(w, r, resp)
+}
+
+func sheetProperties(ss *spreadsheet, sh *sheet) *sheets.SheetProperties {
+	index := 0
+	for i, other := range ss.sheets {
+		if other == sh {
+			index = i
+		}
+	}
+	return &sheets.SheetProperties{
+		SheetId:   sh.id,
+		Title:     sh.title,
+		Index:     int64(index),
+		SheetType: "GRID",
+		GridProperties: &sheets.GridProperties{
+			RowCount:    int64(sh.rowCount),
+			ColumnCount: int64(sh.colCount),
+		},
+		ForceSendFields: []string{"SheetId", "Index"},
+	}
+}
+
+// values.get
+func (s *Server) getValues(w http.ResponseWriter, r *http.Request, ss *spreadsheet, a1 string) {
+	sh, g, err := ss.resolve(a1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, r, &sheets.ValueRange{
+		Range:          formatRange(sh.title, sh.clamp(g)),
+		MajorDimension: "ROWS",
+		Values:         sh.values(g),
+	})
+}
+
+// Helper: Decode a ValueRange body and validate valueInputOption
+func decodeValueRange(r *http.Request) (*sheets.ValueRange, string, error) {
+	inputOption := r.URL.Query().Get("valueInputOption")
+	if inputOption != "RAW" && inputOption != "USER_ENTERED" {
+		return nil, "", fmt.Errorf("Invalid valueInputOption: '%s'", inputOption)
+	}
+	var vr sheets.ValueRange
+	if err := json.NewDecoder(r.Body).Decode(&vr); err != nil {
+		return nil, "", fmt.Errorf("Invalid JSON payload received. %v", err)
+	}
+	return &vr, inputOption, nil
+}
+
+// values.update
+func (s *Server) updateValues(w http.ResponseWriter, r *http.Request, ss *spreadsheet, a1 string) {
+	sh, g, err := ss.resolve(a1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	vr, inputOption, err := decodeValueRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, cols, err := sh.writeValues(g.startRow, g.startCol, vr.Values, inputOption)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ss.touch()
+	s.persist()
+
+	writeJSON(w, r, &sheets.UpdateValuesResponse{
+		SpreadsheetId:  ss.id,
+		UpdatedRange:   formatRange(sh.title, gridRange{g.startRow, g.startRow + max(rows, 1), g.startCol, g.startCol + max(cols, 1)}),
+		UpdatedRows:    int64(rows),
+		UpdatedColumns: int64(cols),
+		UpdatedCells:   int64(rows * cols),
+	})
+}
+
+// values.append: writes after the last non-empty row of the range's columns, growing the grid if needed
+func (s *Server) appendValues(w http.ResponseWriter, r *http.Request, ss *spreadsheet, a1 string) {
+	sh, g, err := ss.resolve(a1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	vr, inputOption, err := decodeValueRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	table := sh.clamp(g)
+	lastRow := table.startRow - 1
+	for rIdx := table.startRow; rIdx < len(sh.rows) && rIdx < table.endRow; rIdx++ {
+		for c := table.startCol; c < table.endCol; c++ {
+			if formattedValue(sh.cell(rIdx, c)) != "" {
+				lastRow = rIdx
+				break
+			}
+		}
+	}
+	startRow := lastRow + 1
+	if need := startRow + len(vr.Values); need > sh.rowCount {
+		sh.rowCount = need
+	}
+
+	rows, cols, err := sh.writeValues(startRow, table.startCol, vr.Values, inputOption)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ss.touch()
+	s.persist()
+
+	tableRange := ""
+	if lastRow >= table.startRow {
+		tableRange = formatRange(sh.title, gridRange{table.startRow, lastRow + 1, table.startCol, table.endCol})
+	}
+	writeJSON(w, r, &sheets.AppendValuesResponse{
+		SpreadsheetId: ss.id,
+		TableRange:    tableRange,
+		Updates: &sheets.UpdateValuesResponse{
+			SpreadsheetId:  ss.id,
+			UpdatedRange:   formatRange(sh.title, gridRange{startRow, startRow + max(rows, 1), table.startCol, table.startCol + max(cols, 1)}),
+			UpdatedRows:    int64(rows),
+			UpdatedColumns: int64(cols),
+			UpdatedCells:   int64(rows * cols),
+		},
+	})
+}
+
+// batchUpdate: all requests apply atomically
+func (s *Server) batchUpdate(w http.ResponseWriter, r *http.Request, ss *spreadsheet) {
+	var req sheets.BatchUpdateSpreadsheetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON payload received. "+err.Error())
+		return
+	}
+
+	backup := make([]*sheet, len(ss.sheets))
+	for i, sh := range ss.sheets {
+		backup[i] = sh.clone()
+	}
+	backupNextID := s.nextSheetID
+
+	resp := &sheets.BatchUpdateSpreadsheetResponse{SpreadsheetId: ss.id}
+	for i, sub := range req.Requests {
+		reply, err := s.applyRequest(ss, sub)
+		if err != nil {
+			ss.sheets = backup
+			s.nextSheetID = backupNextID
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid requests[%d]: %v", i, err))
+			return
+		}
+		resp.Replies = append(resp.Replies, reply)
+	}
+	ss.touch()
+	s.persist()
+
+	writeJSON(w, r, resp)
+}
+
+func (s *Server) applyRequest(ss *spreadsheet, req *sheets.Request) (*sheets.Response, error) {
+	switch {
+	case req.AddSheet != nil:
+		props := req.AddSheet.Properties
+		if props == nil || props.Title == "" {
+			return nil, fmt.Errorf("addSheet: title is required")
+		}
+		if ss.findSheet(props.Title) != nil {
+			return nil, fmt.Errorf("addSheet: A sheet with the name \"%s\" already exists. Please enter another name.", props.Title)
+		}
+		if props.SheetId != 0 && ss.findSheetByID(props.SheetId) != nil {
+			return nil, fmt.Errorf("addSheet: sheet ID %d already exists", props.SheetId)
+		}
+		rows, cols := defaultRowCount, defaultColumnCount
+		if gp := props.GridProperties; gp != nil {
+			if gp.RowCount > 0 {
+				rows = int(gp.RowCount)
+			}
+			if gp.ColumnCount > 0 {
+				cols = int(gp.ColumnCount)
+			}
+		}
+		sh := s.newSheet(props.Title, props.SheetId, rows, cols)
+		ss.sheets = append(ss.sheets, sh)
+		return &sheets.Response{AddSheet: &sheets.AddSheetResponse{Properties: sheetProperties(ss, sh)}}, nil
+
+	case req.AppendDimension != nil:
+		ad := req.AppendDimension
+		sh := ss.findSheetByID(ad.SheetId)
+		if sh == nil {
+			return nil, fmt.Errorf("appendDimension: No grid with id: %d", ad.SheetId)
+		}
+		if ad.Length <= 0 {
+			return nil, fmt.Errorf("appendDimension: length must be positive")
+		}
+		switch ad.Dimension {
+		case "ROWS":
+			sh.rowCount += int(ad.Length)
+		case "COLUMNS":
+			sh.colCount += int(ad.Length)
+		default:
+			return nil, fmt.Errorf("appendDimension: invalid dimension '%s'", ad.Dimension)
+		}
+		return &sheets.Response{}, nil
+
+	case req.UpdateCells != nil:
+		return &sheets.Response{}, applyUpdateCells(ss, req.UpdateCells)
+
+	case req.InsertDimension != nil:
+		rng := req.InsertDimension.Range
+		if rng == nil {
+			return nil, fmt.Errorf("insertDimension: range is required")
+		}
+		sh := ss.findSheetByID(rng.SheetId)
+		if sh == nil {
+			return nil, fmt.Errorf("insertDimension: No grid with id: %d", rng.SheetId)
+		}
+		start, end := int(rng.StartIndex), int(rng.EndIndex)
+		switch rng.Dimension {
+		case "ROWS":
+			if start < 0 || end <= start || start > sh.rowCount {
+				return nil, fmt.Errorf("insertDimension: invalid row range %d-%d", start, end)
+			}
+			if start < len(sh.rows) {
+				blank := make([][]*sheets.CellData, end-start)
+				sh.rows = append(sh.rows[:start], append(blank, sh.rows[start:]...)...)
+			}
+			sh.rowCount += end - start
+		case "COLUMNS":
+			if start < 0 || end <= start || start > sh.colCount {
+				return nil, fmt.Errorf("insertDimension: invalid column range %d-%d", start, end)
+			}
+			for r, row := range sh.rows {
+				if start < len(row) {
+					blank := make([]*sheets.CellData, end-start)
+					sh.rows[r] = append(row[:start:start], append(blank, row[start:]...)...)
+				}
+			}
+			sh.colCount += end - start
+		default:
+			return nil, fmt.Errorf("insertDimension: invalid dimension '%s'", rng.Dimension)
+		}
+		return &sheets.Response{}, nil
+
+	case req.DeleteDimension != nil:
+		rng := req.DeleteDimension.Range
+		if rng == nil {
+			return nil, fmt.Errorf("deleteDimension: range is required")
+		}
+		sh := ss.findSheetByID(rng.SheetId)
+		if sh == nil {
+			return nil, fmt.Errorf("deleteDimension: No grid with id: %d", rng.SheetId)
+		}
+		start, end := int(rng.StartIndex), int(rng.EndIndex)
+		switch rng.Dimension {
+		case "ROWS":
+			if start < 0 || end <= start || end > sh.rowCount {
+				return nil, fmt.Errorf("deleteDimension: invalid row range %d-%d", start, end)
+			}
+			if start < len(sh.rows) {
+				sh.rows = append(sh.rows[:start], sh.rows[min(end, len(sh.rows)):]...)
+			}
+			sh.rowCount -= end - start
+		case "COLUMNS":
+			if start < 0 || end <= start || end > sh.colCount {
+				return nil, fmt.Errorf("deleteDimension: invalid column range %d-%d", start, end)
+			}
+			for r, row := range sh.rows {
+				if start < len(row) {
+					sh.rows[r] = append(row[:start:start], row[min(end, len(row)):]...)
+				}
+			}
+			sh.colCount -= end - start
+		default:
+			return nil, fmt.Errorf("deleteDimension: invalid dimension '%s'", rng.Dimension)
+		}
+		return &sheets.Response{}, nil
+
+	default:
+		return nil, fmt.Errorf("request type not supported by fakesheets")
+	}
+}
+
+// Helper: UpdateCells writes rows at range/start; with a range, uncovered cells in it have the fields cleared
+func applyUpdateCells(ss *spreadsheet, uc *sheets.UpdateCellsRequest) error {
+	if uc.Fields == "" {
+		return fmt.Errorf("updateCells: fields is required")
+	}
+
+	var sh *sheet
+	var g gridRange
+	switch {
+	case uc.Range != nil:
+		sh = ss.findSheetByID(uc.Range.SheetId)
+		if sh == nil {
+			return fmt.Errorf("updateCells: No grid with id: %d", uc.Range.SheetId)
+		}
+		g = gridRange{int(uc.Range.StartRowIndex), int(uc.Range.EndRowIndex), int(uc.Range.StartColumnIndex), int(uc.Range.EndColumnIndex)}
+		if uc.Range.EndRowIndex == 0 {
+			g.endRow = sh.rowCount
+		}
+		if uc.Range.EndColumnIndex == 0 {
+			g.endCol = sh.colCount
+		}
+	case uc.Start != nil:
+		sh = ss.findSheetByID(uc.Start.SheetId)
+		if sh == nil {
+			return fmt.Errorf("updateCells: No grid with id: %d", uc.Start.SheetId)
+		}
+		g = gridRange{startRow: int(uc.Start.RowIndex), startCol: int(uc.Start.ColumnIndex)}
+		g.endRow = g.startRow + len(uc.Rows)
+		for _, row := range uc.Rows {
+			if g.startCol+len(row.Values) > g.endCol {
+				g.endCol = g.startCol + len(row.Values)
+			}
+		}
+	default:
+		return fmt.Errorf("updateCells: range or start is required")
+	}
+
+	if g.endRow > sh.rowCount || g.endCol > sh.colCount {
+		return fmt.Errorf("updateCells: Range ('%s'!%s%d) exceeds grid limits. Max rows: %d, max columns: %d",
+			sh.title, columnName(g.endCol-1), g.endRow, sh.rowCount, sh.colCount)
+	}
+
+	fields := strings.Split(uc.Fields, ",")
+	for r := g.startRow; r < g.endRow; r++ {
+		for c := g.startCol; c < g.endCol; c++ {
+			var src *sheets.CellData
+			if i := r - g.startRow; i < len(uc.Rows) && uc.Rows[i] != nil {
+				if j := c - g.startCol; j < len(uc.Rows[i].Values) {
+					src = uc.Rows[i].Values[j]
+				}
+			}
+			merged, err := mergeCell(sh.cell(r, c), src, fields)
+			if err != nil {
+				return err
+			}
+			sh.setCell(r, c, merged)
+		}
+	}
+	return nil
+}
+
+// Helper: Copy the given field paths from src into a copy of dst (absent in src = cleared)
+func mergeCell(dst, src *sheets.CellData, fields []string) (*sheets.CellData, error) {
+	toMap := func(cd *sheets.CellData) map[string]interface{} {
+		m := map[string]interface{}{}
+		if cd != nil {
+			b, _ := json.Marshal(cd)
+			json.Unmarshal(b, &m)
+		}
+		return m
+	}
+	out := toMap(dst)
+	in := toMap(src)
+
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "*" {
+			out = in
+			continue
+		}
+		path := strings.Split(f, ".")
+		setPath(out, in, path)
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	var cd sheets.CellData
+	if err := json.Unmarshal(b, &cd); err != nil {
+		return nil, err
+	}
+	return &cd, nil
+}
+
+// Helper: out[path] = in[path], deleting it when in does not have it
+func setPath(out, in map[string]interface{}, path []string) {
+	key := path[0]
+	if len(path) == 1 {
+		if v, ok := in[key]; ok {
+			out[key] = v
+		} else {
+			delete(out, key)
+		}
+		return
+	}
+	inChild, _ := in[key].(map[string]interface{})
+	if inChild == nil {
+		inChild = map[string]interface{}{}
+	}
+	outChild, _ := out[key].(map[string]interface{})
+	if outChild == nil {
+		outChild = map[string]interface{}{}
+	}
+	setPath(outChild, inChild, path[1:])
+	if len(outChild) == 0 {
+		delete(out, key)
+	} else {
+		out[key] = outChild
+	}
+}
+
+// snapshot is the on-disk format of the optional data file
+type snapshot struct {
+	NextSheetID  int64                 `json:"nextSheetId"`
+	Spreadsheets []*sheets.Spreadsheet `json:"spreadsheets"`
+}
+
+// LoadFile restores the server from path (if it exists) and rewrites it after every write
+func (s *Server) LoadFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dataFile = path
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return fmt.Errorf("invalid data file %s: %v", path, err)
+	}
+
+	for _, in := range snap.Spreadsheets {
+		ss := &spreadsheet{id: in.SpreadsheetId}
+		if in.Properties != nil {
+			ss.title = in.Properties.Title
+		}
+		ss.touch()
+		for _, insh := range in.Sheets {
+			p := insh.Properties
+			sh := s.newSheet(p.Title, p.SheetId, int(p.GridProperties.RowCount), int(p.GridProperties.ColumnCount))
+			for _, gd := range insh.Data {
+				for i, row := range gd.RowData {
+					for j, cd := range row.Values {
+						cd.EffectiveValue, cd.FormattedValue = nil, ""
+						sh.setCell(int(gd.StartRow)+i, int(gd.StartColumn)+j, cd)
+					}
+				}
+			}
+			ss.sheets = append(ss.sheets, sh)
+		}
+		s.spreadsheets[ss.id] = ss
+	}
+	if snap.NextSheetID > s.nextSheetID {
+		s.nextSheetID = snap.NextSheetID
+	}
+	return nil
+}
+
+// Helper: Rewrite the data file, if any (called with s.mu held)
+func (s *Server) persist() {
+	if s.dataFile == "" {
+		return
+	}
+
+	snap := snapshot{NextSheetID: s.nextSheetID}
+	for _, ss := range s.spreadsheets {
+		out := &sheets.Spreadsheet{SpreadsheetId: ss.id, Properties: &sheets.SpreadsheetProperties{Title: ss.title}}
+		for _, sh := range ss.sheets {
+			out.Sheets = append(out.Sheets, &sheets.Sheet{
+				Properties: sheetProperties(ss, sh),
+				Data:       []*sheets.GridData{sh.gridData(gridRange{0, -1, 0, -1})},
+			})
+		}
+		snap.Spreadsheets = append(snap.Spreadsheets, out)
+	}
+
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err == nil {
+		err = os.WriteFile(s.dataFile, b, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fakesheets: failed to write %s: %v\n", s.dataFile, err)
+	}
+}
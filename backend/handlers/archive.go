@@ -0,0 +1,45 @@
+// handlers/archive.go
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/config"
+	"go-backend/services"
+	"net/http"
+)
+
+func (h *Handler) GetArchives(w http.ResponseWriter, r *http.Request) {
+	if h.archiver == nil {
+		writeProblem(w, r, http.StatusNotFound, "archive_disabled", "Archiving is not enabled")
+		return
+	}
+
+	sheets, err := h.archiver.Archives()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	cfg := config.Get().Archive
+	spreadsheetID := cfg.SpreadsheetID
+	if spreadsheetID == "" {
+		spreadsheetID = config.Get().SpreadsheetID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Period        string                  `json:"period"`
+		SpreadsheetID string                  `json:"spreadsheet_id"`
+		LastRun       *services.ArchiveReport `json:"last_run"`
+		Sheets        []services.ArchiveSheet `json:"sheets"`
+	}{cfg.Period, spreadsheetID, h.archiver.LastReport(), sheets})
+}
+
+func (h *Handler) RunRollover(w http.ResponseWriter, r *http.Request) {
+	if h.archiver == nil {
+		writeProblem(w, r, http.StatusNotFound, "archive_disabled", "Archiving is not enabled")
+		return
+	}
+
+	h.runJobResult(w, r, services.JobArchiveRollover)
+}
@@ -0,0 +1,80 @@
+// handlers/audit.go
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/models"
+	"go-backend/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Page size of /audit, by default and at most
+const (
+	auditDefaultLimit = 100
+	auditMaxLimit     = 1000
+)
+
+// GetAudit returns the audit trail of task changes, newest first.
+// Query: employee, sheet, actor, source (api, sheet), action (e.g. task.status_changed), task_id,
+// from and to (task dates, inclusive), since and until (RFC 3339 times of the change),
+// limit (default 100, at most 1000) and before (next_before of the previous page).
+func (h *Handler) GetAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := services.AuditFilter{
+		EmployeeName: q.Get("employee"),
+		Sheet:        q.Get("sheet"),
+		Actor:        q.Get("actor"),
+		Source:       q.Get("source"),
+		Action:       q.Get("action"),
+		TaskID:       q.Get("task_id"),
+		Limit:        auditDefaultLimit,
+	}
+
+	for name, d := range map[string]*models.Date{"from": &filter.From, "to": &filter.To} {
+		if v := q.Get(name); v != "" {
+			parsed, err := models.ParseDate(v)
+			if err != nil {
+				writeValidation(w, r, name+": "+err.Error())
+				return
+			}
+			*d = parsed
+		}
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeValidation(w, r, name+" must be an RFC 3339 time, e.g. 2006-01-02T15:04:05Z")
+				return
+			}
+			*t = parsed
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > auditMaxLimit {
+			writeValidation(w, r, "limit must be a number from 1 to "+strconv.Itoa(auditMaxLimit))
+			return
+		}
+		filter.Limit = n
+	}
+	if v := q.Get("before"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			writeValidation(w, r, "before must be a positive number")
+			return
+		}
+		filter.Before = n
+	}
+
+	page, err := h.audit.Query(filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
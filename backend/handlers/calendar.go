@@ -0,0 +1,143 @@
+// handlers/calendar.go
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/models"
+	"go-backend/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Helper: Optional date query parameter, def when missing
+func dateParam(r *http.Request, name string, def models.Date) (models.Date, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	return models.ParseDate(v)
+}
+
+// Helper: Leave and holiday sets decide the team view's history cut-off
+func (h *Handler) invalidateCalendarReads() {
+	if h.cache != nil {
+		h.cache.InvalidateAll()
+	}
+}
+
+// GetCalendar lists days with their working status.
+// Query: employee (company calendar when omitted), from (default today), to (default from + 30 days).
+func (h *Handler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	from, err := dateParam(r, "from", models.Today())
+	if err != nil {
+		writeValidation(w, r, err.Error())
+		return
+	}
+	to, err := dateParam(r, "to", from.AddDays(30))
+	if err != nil {
+		writeValidation(w, r, err.Error())
+		return
+	}
+	if to.Before(from) || from.DaysUntil(to) > 366 {
+		writeValidation(w, r, "to must be on or after from and at most a year later")
+		return
+	}
+
+	employee := r.URL.Query().Get("employee")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Employee string                 `json:"employee,omitempty"`
+		Days     []services.CalendarDay `json:"days"`
+	}{employee, h.calendar.Days(employee, from, to)})
+}
+
+func (h *Handler) GetHolidays(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.calendar.HolidaySets())
+}
+
+// ReloadHolidays re-reads the holiday files
+func (h *Handler) ReloadHolidays(w http.ResponseWriter, r *http.Request) {
+	sets, err := h.calendar.Reload()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.invalidateCalendarReads()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sets)
+}
+
+func (h *Handler) GetCalendarEmployees(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.calendar.Employees())
+}
+
+func (h *Handler) GetCalendarEmployee(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.calendar.Employee(mux.Vars(r)["name"]))
+}
+
+// AddLeave books leave from "from" to "to" (inclusive, default a single day)
+func (h *Handler) AddLeave(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		From models.Date `json:"from"`
+		To   models.Date `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+
+	emp, err := h.calendar.AddLeave(mux.Vars(r)["name"], req.From, req.To)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.invalidateCalendarReads()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(emp)
+}
+
+func (h *Handler) RemoveLeave(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	d, err := models.ParseDate(vars["date"])
+	if err != nil {
+		writeValidation(w, r, err.Error())
+		return
+	}
+
+	emp, err := h.calendar.RemoveLeave(vars["name"], d)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.invalidateCalendarReads()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(emp)
+}
+
+// SetHolidaySets chooses the holiday sets an employee observes (empty for all)
+func (h *Handler) SetHolidaySets(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		HolidaySets []string `json:"holiday_sets"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+
+	emp, err := h.calendar.SetHolidaySets(mux.Vars(r)["name"], req.HolidaySets)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.invalidateCalendarReads()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(emp)
+}
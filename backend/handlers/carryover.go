@@ -0,0 +1,40 @@
+// handlers/carryover.go
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/services"
+	"net/http"
+)
+
+// PostCarryOver copies one employee's unfinished tasks into their today (or the given date)
+func (h *Handler) PostCarryOver(w http.ResponseWriter, r *http.Request) {
+	var req services.CarryOverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+	if req.EmployeeName == "" {
+		writeValidation(w, r, "Employee name is required")
+		return
+	}
+
+	var ok bool
+	if req.Actor, ok = requestActor(w, r); !ok {
+		return
+	}
+
+	result, err := h.taskStore.CarryOver(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// RunCarryOver runs the carry-over job for every employee now
+func (h *Handler) RunCarryOver(w http.ResponseWriter, r *http.Request) {
+	h.runJobResult(w, r, services.JobCarryOver)
+}
@@ -6,52 +6,84 @@ import (
 	"net/http"
 )
 
-func GetMetadata(w http.ResponseWriter, r *http.Request) {
-	data, err := services.GetAllEmployeesMetadata()
+func (h *Handler) GetMetadata(w http.ResponseWriter, r *http.Request) {
+	var data []services.EmployeeMetadata
+	var err error
+	if h.cache != nil {
+		var info services.CacheInfo
+		data, info, err = h.cache.GetAllEmployeesMetadataWithInfo()
+		if err == nil {
+			setCacheHeaders(w, info)
+		}
+	} else {
+		data, err = h.metadataStore.GetAllEmployeesMetadata()
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
 
-func GetDailyLogs(w http.ResponseWriter, r *http.Request) {
-	data, err := services.GetAllDailyLogs()
+func (h *Handler) GetDailyLogs(w http.ResponseWriter, r *http.Request) {
+	var data []services.DailyLog
+	var err error
+	if h.cache != nil {
+		var info services.CacheInfo
+		data, info, err = h.cache.GetAllDailyLogsWithInfo()
+		if err == nil {
+			setCacheHeaders(w, info)
+		}
+	} else {
+		data, err = h.logStore.GetAllDailyLogs()
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
 
-func UpsertMetadata(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpsertMetadata(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		EmployeeName string `json:"employee_name"`
+		Timezone     string `json:"timezone"` // Optional IANA zone; omitted keeps the recorded one
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid body", http.StatusBadRequest)
+		writeInvalidBody(w, r)
+		return
+	}
+	if req.EmployeeName == "" {
+		writeValidation(w, r, "Employee name is required")
 		return
 	}
-	if err := services.UpsertEmployeeMetadata(req.EmployeeName); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.metadataStore.UpsertEmployeeMetadata(req.EmployeeName, req.Timezone); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
-func UpsertDailyLog(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpsertDailyLog(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		EmployeeName string `json:"employee_name"`
-		TaskDate     string `json:"task_date"`
+		TaskDate     string `json:"task_date"` // Optional, defaults to today in the employee's zone
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid body", http.StatusBadRequest)
+		writeInvalidBody(w, r)
 		return
 	}
-	if err := services.UpsertDailyLog(req.EmployeeName, req.TaskDate); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if req.EmployeeName == "" {
+		writeValidation(w, r, "Employee name is required")
+		return
+	}
+	if req.TaskDate == "" {
+		req.TaskDate = h.env.EmployeeToday(h.metadataStore, req.EmployeeName).String()
+	}
+	if err := h.logStore.UpsertDailyLog(req.EmployeeName, req.TaskDate); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
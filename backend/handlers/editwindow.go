@@ -0,0 +1,60 @@
+// handlers/editwindow.go
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/config"
+	"go-backend/models"
+	"go-backend/services"
+	"net/http"
+	"strings"
+)
+
+// Helper: Who is making the request. A bearer token from auth.tokens names its owner;
+// without one the X-Actor header is taken at its word, except for managers, who must
+// prove it. ok is false once an error response has been written.
+func requestActor(w http.ResponseWriter, r *http.Request) (name string, ok bool) {
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		name = config.Get().Auth.ActorForToken(strings.TrimSpace(token))
+		if name == "" {
+			writeProblem(w, r, http.StatusUnauthorized, "invalid_token", "The bearer token is not one of auth.tokens")
+			return "", false
+		}
+		return name, true
+	}
+
+	name = strings.TrimSpace(r.Header.Get("X-Actor"))
+	if services.IsManager(name) {
+		writeProblem(w, r, http.StatusForbidden, "manager_token_required",
+			"Managers must authenticate with their bearer token (Authorization: Bearer <secret>)")
+		return "", false
+	}
+	return name, true
+}
+
+// GetEditWindow explains whether a write would be allowed, without writing.
+// Query: employee, role (default role when omitted), date (YYYY-MM-DD, today when omitted).
+func (h *Handler) GetEditWindow(w http.ResponseWriter, r *http.Request) {
+	who, ok := requestActor(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	req := services.EditRequest{
+		Sheet:    q.Get("role"),
+		Employee: q.Get("employee"),
+		Actor:    who,
+	}
+	req.Timezone = h.env.EmployeeTimezone(h.metadataStore, req.Employee)
+	if v := q.Get("date"); v != "" {
+		d, err := models.ParseDate(v)
+		if err != nil {
+			writeValidation(w, r, err.Error())
+			return
+		}
+		req.Date = d
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.env.EvaluateEdit(req))
+}
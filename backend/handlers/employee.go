@@ -3,24 +3,35 @@ package handlers
 
 import (
 	"encoding/json"
+	"go-backend/models"
 	"go-backend/services"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
-func GetLatestTasksByEmployee(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetLatestTasksByEmployee(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	name := vars["name"]
 
 	if name == "" {
-		http.Error(w, "Employee name is required", http.StatusBadRequest)
+		writeValidation(w, r, "Employee name is required")
 		return
 	}
 
-	result, err := services.GetLatestTasks(name)
+	var result models.EmployeeTasksResponse
+	var err error
+	if h.cache != nil {
+		var info services.CacheInfo
+		result, info, err = h.cache.GetLatestTasksWithInfo(name)
+		if err == nil {
+			setCacheHeaders(w, info)
+		}
+	} else {
+		result, err = h.taskStore.GetLatestTasks(name)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -28,10 +39,20 @@ func GetLatestTasksByEmployee(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(result)
 }
 
-func GetAllEmployeesLatestTasks(w http.ResponseWriter, r *http.Request) {
-	result, err := services.GetAllEmployeesLatestTasks()
+func (h *Handler) GetAllEmployeesLatestTasks(w http.ResponseWriter, r *http.Request) {
+	var result models.TeamTasksResponse
+	var err error
+	if h.cache != nil {
+		var info services.CacheInfo
+		result, info, err = h.cache.GetAllEmployeesLatestTasksWithInfo()
+		if err == nil {
+			setCacheHeaders(w, info)
+		}
+	} else {
+		result, err = h.taskStore.GetAllEmployeesLatestTasks()
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,99 @@
+// handlers/errors.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-backend/services"
+	"net/http"
+)
+
+// Problem is an RFC 7807 application/problem+json error body
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"` // Machine-readable, e.g. "employee_not_found"
+
+	// Extension member with structured details, e.g. the edit-window decision
+	Explanation interface{} `json:"explanation,omitempty"`
+}
+
+// Service error kinds and their HTTP statuses
+var errorStatuses = []struct {
+	kind   error
+	status int
+}{
+	{services.ErrNotFound, http.StatusNotFound},
+	{services.ErrForbidden, http.StatusForbidden},
+	{services.ErrValidation, http.StatusUnprocessableEntity},
+	{services.ErrConflict, http.StatusConflict},
+	{services.ErrUnavailable, http.StatusServiceUnavailable},
+}
+
+// writeProblem sends a problem+json response
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	sendProblem(w, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	})
+}
+
+// Helper: Encode a problem body with its status
+func sendProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// writeError maps a service error to its status and code; unknown errors are 500s
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	se := services.AsError(err)
+	if se == nil {
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	status := http.StatusInternalServerError
+	for _, m := range errorStatuses {
+		if errors.Is(se, m.kind) {
+			status = m.status
+			break
+		}
+	}
+	sendProblem(w, Problem{
+		Type:        "about:blank",
+		Title:       http.StatusText(status),
+		Status:      status,
+		Detail:      se.Detail,
+		Instance:    r.URL.Path,
+		Code:        se.Code,
+		Explanation: se.Explanation,
+	})
+}
+
+// Helper: 400 for a body that is not valid JSON
+func writeInvalidBody(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body")
+}
+
+// Helper: 422 for a well-formed request missing required fields
+func writeValidation(w http.ResponseWriter, r *http.Request, detail string) {
+	writeProblem(w, r, http.StatusUnprocessableEntity, "validation_failed", detail)
+}
+
+// NotFound answers unknown routes with a problem body
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, "route_not_found", "No endpoint at "+r.Method+" "+r.URL.Path)
+}
+
+// MethodNotAllowed answers known routes called with the wrong method
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not supported on "+r.URL.Path)
+}
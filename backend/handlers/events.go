@@ -0,0 +1,137 @@
+// handlers/events.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-backend/config"
+	"go-backend/services"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// checkWebSocketOrigin refuses (403) a browser upgrade from a page outside server.cors_origins:
+// a WebSocket is not covered by CORS, so the origins are checked here. Clients that send
+// no Origin are not browsers and are let through, as the GET endpoints are.
+func checkWebSocketOrigin(_ *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin != "" && config.Get().Server.AllowedOrigin(origin) == "" {
+		return fmt.Errorf("origin %q is not allowed", origin)
+	}
+	return nil
+}
+
+// Comment line keeping idle streams open through proxies
+const feedHeartbeat = 25 * time.Second
+
+// GetEvents streams cell changes and new employees: Server-Sent Events by default, or JSON
+// messages over a WebSocket when the request asks to upgrade.
+// Query: employee, sheet. Resume with the Last-Event-ID header or ?last_event_id.
+// A "reset" message means the missed changes are gone and the client should reload.
+func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := services.FeedFilter{EmployeeName: strings.TrimSpace(q.Get("employee")), Sheet: strings.TrimSpace(q.Get("sheet"))}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = q.Get("last_event_id")
+	}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		websocket.Server{
+			Handshake: checkWebSocketOrigin,
+			Handler:   func(ws *websocket.Conn) { h.streamWebSocket(ws, lastID, filter) },
+		}.ServeHTTP(w, r)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, r, http.StatusInternalServerError, "streaming_unsupported", "The connection cannot stream events")
+		return
+	}
+
+	sub, backlog, reset := h.feed.Subscribe(lastID, filter)
+	defer h.feed.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, "retry: 3000\n\n")
+	if reset {
+		writeSSE(w, resetMessage(sub))
+	}
+	for _, m := range backlog {
+		writeSSE(w, m)
+	}
+	flusher.Flush()
+
+	ping := time.NewTicker(feedHeartbeat)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m, ok := <-sub.C:
+			if !ok {
+				return // Fell behind; the client reconnects with its last ID
+			}
+			writeSSE(w, m)
+			flusher.Flush()
+		case <-ping.C:
+			io.WriteString(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// Helper: Send the feed over a WebSocket until either side closes it
+func (h *Handler) streamWebSocket(ws *websocket.Conn, lastID string, filter services.FeedFilter) {
+	defer ws.Close()
+	sub, backlog, reset := h.feed.Subscribe(lastID, filter)
+	defer h.feed.Unsubscribe(sub)
+
+	// Clients don't send anything; reading only notices when they go away
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, ws)
+		close(closed)
+	}()
+
+	if reset {
+		backlog = append([]services.FeedMessage{resetMessage(sub)}, backlog...)
+	}
+	for _, m := range backlog {
+		if websocket.JSON.Send(ws, m) != nil {
+			return
+		}
+	}
+	for {
+		select {
+		case <-closed:
+			return
+		case m, ok := <-sub.C:
+			if !ok || websocket.JSON.Send(ws, m) != nil {
+				return
+			}
+		}
+	}
+}
+
+// Helper: Tell a client its missed changes are gone, with the ID to go on from
+func resetMessage(sub *services.FeedSubscription) services.FeedMessage {
+	return services.FeedMessage{ID: sub.LastID, Type: "reset", Data: map[string]string{"reason": "missed changes are no longer available; reload"}}
+}
+
+// Helper: Write one Server-Sent Event
+func writeSSE(w io.Writer, m services.FeedMessage) {
+	data, _ := json.Marshal(m.Data)
+	if m.ID != "" {
+		fmt.Fprintf(w, "id: %s\n", m.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", m.Type, data)
+}
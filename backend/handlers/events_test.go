@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"go-backend/config"
+	"go-backend/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocketChecksOrigin(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.CORSOrigins = []string{"https://standup.example.com"}
+	prev := config.Get()
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(prev) })
+	h := New(Services{Store: services.NewMemoryStore(nil), Env: &services.Env{}, Feed: services.NewFeed(cfg.Events)})
+
+	hs := httptest.NewServer(http.HandlerFunc(h.GetEvents))
+	defer hs.Close()
+	wsURL := "ws" + strings.TrimPrefix(hs.URL, "http") + "/events"
+
+	tests := []struct {
+		origin string
+		ok     bool
+	}{
+		{"https://standup.example.com", true},
+		{"HTTPS://STANDUP.EXAMPLE.COM", true},
+		{"https://evil.example.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		ws, err := websocket.Dial(wsURL, "", tt.origin)
+		if (err == nil) != tt.ok {
+			t.Errorf("origin %s: dial error %v, want allowed %v", tt.origin, err, tt.ok)
+		}
+		if ws != nil {
+			ws.Close()
+		}
+	}
+}
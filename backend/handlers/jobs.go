@@ -0,0 +1,71 @@
+// handlers/jobs.go
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// GetJobs lists the registered jobs with their schedule, next and last run
+func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.scheduler.Jobs()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Instance string               `json:"instance"`
+		Store    string               `json:"store"` // Where job state and leases are kept
+		Jobs     []services.JobStatus `json:"jobs"`
+	}{h.scheduler.Instance(), h.scheduler.Describe(), jobs})
+}
+
+// GetJob describes one job with its run history, newest first
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	job, err := h.scheduler.Job(name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	runs, err := h.scheduler.Runs(name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		services.JobStatus
+		Runs []services.JobRun `json:"runs"`
+	}{job, runs})
+}
+
+// RunJob runs a job now and returns the recorded run; 409 when it is already running somewhere
+func (h *Handler) RunJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.scheduler.RunNow(mux.Vars(r)["name"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+// Helper: Run a job now and answer with its result, for endpoints that predate /jobs
+func (h *Handler) runJobResult(w http.ResponseWriter, r *http.Request, name string) {
+	run, err := h.scheduler.RunNow(name)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(run.Result)
+}
@@ -0,0 +1,51 @@
+// handlers/reports.go
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/models"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// GetMissingUpdates lists, per role sheet, the employees with no update on a working day.
+// Query: date (each employee's today when omitted), role (every role sheet when omitted).
+func (h *Handler) GetMissingUpdates(w http.ResponseWriter, r *http.Request) {
+	var date *models.Date
+	if v := r.URL.Query().Get("date"); v != "" {
+		d, err := models.ParseDate(v)
+		if err != nil {
+			writeValidation(w, r, err.Error())
+			return
+		}
+		date = &d
+	}
+
+	report, err := h.env.MissingUpdates(h.store, date, r.URL.Query().Get("role"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetNotifiers lists the configured reminder notifiers
+func (h *Handler) GetNotifiers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.reminders.Notifiers())
+}
+
+// TestNotifier sends a sample reminder through one notifier and reports whether it got through
+func (h *Handler) TestNotifier(w http.ResponseWriter, r *http.Request) {
+	result, err := h.reminders.Test(mux.Vars(r)["name"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,34 @@
+// handlers/sheets_client.go
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ReloadCredentials re-reads the Sheets credentials without restarting the server
+func (h *Handler) ReloadCredentials(w http.ResponseWriter, r *http.Request) {
+	if h.sheetsClient == nil {
+		writeProblem(w, r, http.StatusNotFound, "sheets_client_disabled", "Sheets client is not configured")
+		return
+	}
+
+	if err := h.sheetsClient.Reload(); err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "credentials_reload_failed", "Failed to reload credentials: "+err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Credentials reloaded"))
+}
+
+// GetSheetsAPIStats reports the retry, throttle and circuit breaker counters of the Sheets client
+func (h *Handler) GetSheetsAPIStats(w http.ResponseWriter, r *http.Request) {
+	if h.sheetsClient == nil {
+		writeProblem(w, r, http.StatusNotFound, "sheets_client_disabled", "Sheets client is not configured")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.sheetsClient.Stats())
+}
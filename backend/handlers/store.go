@@ -0,0 +1,76 @@
+// handlers/store.go
+package handlers
+
+import (
+	"go-backend/config"
+	"go-backend/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Services are what the handlers serve. Store and Env are required; the others are nil when
+// their feature is off (no Sheets client, no sync, no archive).
+type Services struct {
+	Store        services.Store // The read-through cache when caching is on
+	Env          *services.Env  // Calendar, teams and event bus the store was built with
+	SheetsClient *config.SheetsClient
+	Webhooks     *services.Webhooks
+	Audit        *services.Audit
+	Feed         *services.Feed
+	Syncer       *services.Syncer
+	Scheduler    *services.Scheduler
+	Archiver     *services.Archiver
+	Reminders    *services.Reminders
+}
+
+// Handler serves the HTTP API over one set of services; its methods are the routes
+type Handler struct {
+	env           *services.Env
+	taskStore     services.TaskStore
+	metadataStore services.MetadataStore
+	logStore      services.LogStore
+	store         services.Store        // All three, for reports reading more than one
+	cache         *services.CachedStore // nil when caching is disabled
+	calendar      *services.Calendar
+	teamRegistry  *services.TeamRegistry
+	sheetsClient  *config.SheetsClient
+	webhooks      *services.Webhooks
+	audit         *services.Audit
+	feed          *services.Feed
+	syncer        *services.Syncer
+	scheduler     *services.Scheduler
+	archiver      *services.Archiver
+	reminders     *services.Reminders
+}
+
+// New returns a Handler serving s
+func New(s Services) *Handler {
+	cache, _ := s.Store.(*services.CachedStore)
+	return &Handler{
+		env:           s.Env,
+		taskStore:     s.Store,
+		metadataStore: s.Store,
+		logStore:      s.Store,
+		store:         s.Store,
+		cache:         cache,
+		calendar:      s.Env.Calendar,
+		teamRegistry:  s.Env.Teams,
+		sheetsClient:  s.SheetsClient,
+		webhooks:      s.Webhooks,
+		audit:         s.Audit,
+		feed:          s.Feed,
+		syncer:        s.Syncer,
+		scheduler:     s.Scheduler,
+		archiver:      s.Archiver,
+		reminders:     s.Reminders,
+	}
+}
+
+// Helper: Tell the client whether the data came from cache and how old it is
+func setCacheHeaders(w http.ResponseWriter, info services.CacheInfo) {
+	w.Header().Set("X-Cache", strings.ToUpper(info.Status))
+	w.Header().Set("Age", strconv.Itoa(int(info.Age().Seconds())))
+	w.Header().Set("X-Cache-Fetched-At", info.FetchedAt.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,71 @@
+// handlers/sync.go
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/services"
+	"net/http"
+)
+
+func (h *Handler) GetSyncStatus(w http.ResponseWriter, r *http.Request) {
+	if h.syncer == nil {
+		writeProblem(w, r, http.StatusNotFound, "sync_disabled", "Sync is not enabled")
+		return
+	}
+
+	cells, err := h.syncer.Status(r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		LastRun *services.SyncReport      `json:"last_run"`
+		Cells   []services.SyncCellStatus `json:"cells"`
+	}{h.syncer.LastReport(), cells})
+}
+
+func (h *Handler) RunSync(w http.ResponseWriter, r *http.Request) {
+	if h.syncer == nil {
+		writeProblem(w, r, http.StatusNotFound, "sync_disabled", "Sync is not enabled")
+		return
+	}
+
+	report, err := h.syncer.SyncOnce()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *Handler) ResolveSyncConflict(w http.ResponseWriter, r *http.Request) {
+	if h.syncer == nil {
+		writeProblem(w, r, http.StatusNotFound, "sync_disabled", "Sync is not enabled")
+		return
+	}
+
+	var req struct {
+		Role         string `json:"role"`
+		EmployeeName string `json:"employee_name"`
+		Date         string `json:"date"`
+		Keep         string `json:"keep"` // "sheet" or "database"
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+	if req.Role == "" || req.EmployeeName == "" || req.Date == "" {
+		writeValidation(w, r, "Role, employee name and date are required")
+		return
+	}
+
+	if err := h.syncer.Resolve(req.Role, req.EmployeeName, req.Date, req.Keep); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
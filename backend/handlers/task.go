@@ -6,26 +6,168 @@ import (
 	"go-backend/models"
 	"go-backend/services"
 	"net/http"
+
+	"github.com/gorilla/mux"
 )
 
-func PostTaskUpdate(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PostTaskUpdate(w http.ResponseWriter, r *http.Request) {
 	var req models.TaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeInvalidBody(w, r)
 		return
 	}
 
 	if req.EmployeeName == "" || len(req.Tasks) == 0 {
-		http.Error(w, "Employee name and at least one task are required", http.StatusBadRequest)
+		writeValidation(w, r, "Employee name and at least one task are required")
+		return
+	}
+
+	var ok bool
+	if req.Actor, ok = requestActor(w, r); !ok {
 		return
 	}
 
-	err := services.AddTask(req)
+	err := h.taskStore.AddTask(req)
 	if err != nil {
-		http.Error(w, "Failed to update task: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Tasks updated successfully"))
-}
\ No newline at end of file
+}
+
+// GetTask looks a task up by its stable ID
+func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
+	loc, err := h.taskStore.FindTask(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loc)
+}
+
+// UpdateTask renames a task and/or changes its status by ID
+func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	var req services.TaskUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+
+	who, ok := requestActor(w, r)
+	if !ok {
+		return
+	}
+	loc, err := h.taskStore.UpdateTask(mux.Vars(r)["id"], req, who)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loc)
+}
+
+// DeleteTask removes a task by ID and returns what was removed
+func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	who, ok := requestActor(w, r)
+	if !ok {
+		return
+	}
+	loc, err := h.taskStore.DeleteTask(mux.Vars(r)["id"], who)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loc)
+}
+
+// Helper: The employee/day cell a request addresses; role comes from the query (default role when omitted).
+// ok is false once an error response has been written.
+func cellRef(w http.ResponseWriter, r *http.Request) (services.CellRef, bool) {
+	vars := mux.Vars(r)
+	d, err := models.ParseDate(vars["date"])
+	if err != nil {
+		writeValidation(w, r, err.Error())
+		return services.CellRef{}, false
+	}
+	who, ok := requestActor(w, r)
+	if !ok {
+		return services.CellRef{}, false
+	}
+	return services.CellRef{
+		EmployeeName: vars["name"],
+		Role:         r.URL.Query().Get("role"),
+		Date:         d,
+		Actor:        who,
+	}, true
+}
+
+// UpdateCellTask renames and/or recolors one line of an employee/day cell.
+// The line is named by its task ID or its text.
+func (h *Handler) UpdateCellTask(w http.ResponseWriter, r *http.Request) {
+	cell, ok := cellRef(w, r)
+	if !ok {
+		return
+	}
+	var req services.TaskUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+
+	day, err := h.taskStore.UpdateCellTask(cell, mux.Vars(r)["line"], req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(day)
+}
+
+// DeleteCellTask removes one line of an employee/day cell and returns what is left
+func (h *Handler) DeleteCellTask(w http.ResponseWriter, r *http.Request) {
+	cell, ok := cellRef(w, r)
+	if !ok {
+		return
+	}
+
+	day, err := h.taskStore.DeleteCellTask(cell, mux.Vars(r)["line"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(day)
+}
+
+// ReorderCell puts the lines of an employee/day cell in the given order (task IDs or texts, each line once)
+func (h *Handler) ReorderCell(w http.ResponseWriter, r *http.Request) {
+	cell, ok := cellRef(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Order []string `json:"order"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+
+	day, err := h.taskStore.ReorderCell(cell, req.Order)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(day)
+}
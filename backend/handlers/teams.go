@@ -0,0 +1,98 @@
+// handlers/teams.go
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Helper: The team list decides which sheets the team view reads
+func (h *Handler) invalidateTeamReads() {
+	if h.cache != nil {
+		h.cache.InvalidateAll()
+	}
+}
+
+func (h *Handler) GetTeams(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.teamRegistry.List())
+}
+
+// GetTeam looks a team up by name, display name, sheet or alias
+func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	team, ok := h.teamRegistry.Find(name)
+	if !ok {
+		writeProblem(w, r, http.StatusNotFound, "team_not_found", "team '"+name+"' not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(team)
+}
+
+// CreateTeam adds a team and provisions its role sheet
+func (h *Handler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	var req services.Team
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+
+	team, err := h.teamRegistry.Create(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.invalidateTeamReads()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(team)
+}
+
+// UpdateTeam replaces a team's names, sheet and settings
+func (h *Handler) UpdateTeam(w http.ResponseWriter, r *http.Request) {
+	var req services.Team
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+
+	team, err := h.teamRegistry.Update(mux.Vars(r)["name"], req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.invalidateTeamReads()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(team)
+}
+
+// DeleteTeam removes a team from the registry; its sheet is kept
+func (h *Handler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	if err := h.teamRegistry.Delete(mux.Vars(r)["name"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.invalidateTeamReads()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReloadTeams re-reads the registry tab or file
+func (h *Handler) ReloadTeams(w http.ResponseWriter, r *http.Request) {
+	list, err := h.teamRegistry.Reload()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.invalidateTeamReads()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
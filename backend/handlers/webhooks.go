@@ -0,0 +1,110 @@
+// handlers/webhooks.go
+package handlers
+
+import (
+	"encoding/json"
+	"go-backend/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.webhooks.Endpoints())
+}
+
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	ep, err := h.webhooks.Endpoint(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ep)
+}
+
+// CreateWebhook registers an endpoint; the response is the only one carrying its secret
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req services.WebhookEndpoint
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+
+	ep, err := h.webhooks.CreateEndpoint(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ep)
+}
+
+// UpdateWebhook replaces an endpoint's settings; an empty secret keeps the current one
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req services.WebhookEndpoint
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidBody(w, r)
+		return
+	}
+
+	ep, err := h.webhooks.UpdateEndpoint(mux.Vars(r)["id"], req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ep)
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.webhooks.DeleteEndpoint(mux.Vars(r)["id"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries returns the delivery log, newest first.
+// Query: endpoint, status (pending, delivered, dead), event, limit (default 100).
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := services.DeliveryFilter{EndpointID: q.Get("endpoint"), Status: q.Get("status"), EventType: q.Get("event"), Limit: 100}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeValidation(w, r, "limit must be a positive number")
+			return
+		}
+		filter.Limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.webhooks.Deliveries(filter))
+}
+
+// GetWebhookDeadLetters lists the deliveries that ran out of attempts, newest first
+func (h *Handler) GetWebhookDeadLetters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.webhooks.Deliveries(services.DeliveryFilter{Status: services.DeliveryDead}))
+}
+
+// RetryWebhookDelivery queues a dead letter for delivery again
+func (h *Handler) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	d, err := h.webhooks.Retry(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(d)
+}
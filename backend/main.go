@@ -1,52 +1,405 @@
 package main
 
 import (
+	"context"
 	"go-backend/config"
 	"go-backend/handlers"
-	// "go-backend/services"
+	"go-backend/services"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
-func enableCORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+func enableCORS(server config.ServerConfig) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if origin := server.AllowedOrigin(r.Header.Get("Origin")); origin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			w.Header().Add("Vary", "Origin")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, PATCH, DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Actor, Last-Event-ID")
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// reloadOnSignal reloads the Sheets credentials whenever the process receives SIGHUP
+func reloadOnSignal(client *config.SheetsClient) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		if err := client.Reload(); err != nil {
+			log.Printf("Credential reload failed, keeping previous credentials: %v", err)
+			continue
 		}
-		next.ServeHTTP(w, r)
-	})
+		log.Println("Credentials reloaded")
+	}
+}
+
+// How long shutdown waits for requests in progress, and then for the background queues
+const shutdownTimeout = 20 * time.Second
+
+// shutdownOnSignal stops srv gracefully on SIGTERM or interrupt: no new connections, and
+// requests in progress get shutdownTimeout to finish
+func shutdownOnSignal(srv *http.Server, stopStreams context.CancelFunc) {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+	<-stop
+	log.Println("Shutting down...")
+	stopStreams()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
 }
 
 func main() {
-	// services.InitSheetsService()
-	config.InitDB()
+	// Configuration: config.yaml (or -config), then environment, then flags
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// One shared Sheets client for every service that talks to the spreadsheet
+	var sheetsClient *config.SheetsClient
+	if strings.EqualFold(cfg.Storage.Backend, services.BackendSheets) || cfg.Sync.Interval > 0 {
+		sheetsClient, err = config.NewSheetsClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go reloadOnSignal(sheetsClient)
+	}
+
+	// Working days: weekends, holidays and leave
+	calendar, err := services.NewCalendar(cfg.Calendar)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// What the stores and jobs share; the team registry and event bus join it below
+	env := &services.Env{Calendar: calendar}
+	api := handlers.Services{Env: env, SheetsClient: sheetsClient}
+
+	store, err := services.NewStore(cfg.Storage.Backend, cfg.Storage.DatabaseURL, sheetsClient, env)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, ok := store.(*services.SheetsStore); ok {
+		config.InitDB(sheetsClient)
+	}
+
+	// Teams and the role sheets they map to; new teams get their sheet from the backend
+	provision, _ := store.(services.SheetProvisioner)
+	registry, err := services.NewTeamRegistry(cfg.Teams, sheetsClient, provision)
+	if err != nil {
+		log.Fatal(err)
+	}
+	env.Teams = registry
+
+	// Queues written in the background; on SIGTERM they are drained after the last request
+	queues, stopQueues := context.WithCancel(context.Background())
+	var draining sync.WaitGroup
+	drainOnShutdown := func(run func(ctx context.Context)) {
+		draining.Add(1)
+		go func() {
+			defer draining.Done()
+			run(queues)
+		}()
+	}
+
+	// Task and employee events, delivered to registered webhooks
+	events := services.NewEventBus()
+	env.Events = events
+	webhooks, err := services.NewWebhooks(cfg.Webhooks)
+	if err != nil {
+		log.Fatal(err)
+	}
+	events.Subscribe(webhooks.Handle)
+	api.Webhooks = webhooks
+	drainOnShutdown(webhooks.Run)
+
+	// Audit trail of every task change
+	audit, err := services.NewAudit(cfg.Audit, store)
+	if err != nil {
+		log.Fatal(err)
+	}
+	events.Subscribe(audit.Handle)
+	api.Audit = audit
+	drainOnShutdown(audit.Run)
+	log.Printf("Audit trail kept in %s", audit.Describe())
+
+	// Live feed for the dashboard
+	feed := services.NewFeed(cfg.Events)
+	events.SubscribeCells(feed.HandleCell)
+	events.Subscribe(feed.HandleEvent)
+	api.Feed = feed
+
+	// Read-through cache in front of the backend
+	served := store
+	var cache *services.CachedStore
+	if cfg.Cache.TTL > 0 {
+		cache = services.NewCachedStore(store, cfg.Cache, env)
+		served = cache
+		log.Printf("Caching reads for %s", cfg.Cache.TTL)
+	}
+	api.Store = served
+	log.Printf("Using %s storage backend", cfg.Storage.Backend)
+
+	// Two-way Sheets <-> Postgres sync
+	if cfg.Sync.Interval > 0 {
+		pg, ok := store.(*services.PostgresStore)
+		if !ok {
+			log.Fatal("sync requires the postgres storage backend")
+		}
+		syncer := services.NewSyncer(pg, sheetsClient)
+		if cache != nil {
+			syncer.SetOnChange(cache.InvalidateTasks)
+		}
+		api.Syncer = syncer
+		go syncer.Run(context.Background(), cfg.Sync.Interval)
+		log.Printf("Sync enabled every %s", cfg.Sync.Interval)
+	}
 
+	// Background jobs; state, leases and run history are shared by every instance
+	scheduler, err := services.NewScheduler(cfg.Jobs, store)
+	if err != nil {
+		log.Fatal(err)
+	}
+	api.Scheduler = scheduler
+	schedule := func(name, def string) string {
+		if spec, ok := cfg.Jobs.Schedules[name]; ok {
+			return spec
+		}
+		return def
+	}
+	every := func(interval time.Duration) string {
+		if interval > 0 {
+			return "@every " + interval.String()
+		}
+		return ""
+	}
+	register := func(job services.Job) {
+		if err := scheduler.Register(job); err != nil {
+			log.Fatal(err)
+		}
+		if job.Schedule != "" {
+			log.Printf("Job %s scheduled %q", job.Name, job.Schedule)
+		}
+	}
+
+	// Rollover of closed periods out of the role sheets
+	if sheetsClient != nil && cfg.Archive.Period != "" {
+		archiver := services.NewArchiver(sheetsClient, env)
+		if cache != nil {
+			archiver.SetOnChange(cache.InvalidateAll)
+		}
+		api.Archiver = archiver
+		register(services.Job{
+			Name:     services.JobArchiveRollover,
+			Schedule: schedule(services.JobArchiveRollover, every(cfg.Archive.Interval)),
+			Run: func(map[string]string) (interface{}, error) {
+				return archiver.RollOver()
+			},
+		})
+	}
+
+	// Edits typed straight into the role sheets; with sync on, the sync pass reports them instead
+	if _, ok := store.(*services.SheetsStore); ok {
+		watcher := services.NewSheetWatcher(sheetsClient, served, scheduler, env)
+		if cache != nil {
+			watcher.SetOnChange(cache.InvalidateTasks)
+		}
+		events.SubscribeCells(watcher.HandleCell)
+		go func() {
+			// Take the snapshot now if no instance has, so the first scheduled pass has something to compare with
+			if _, err := watcher.CheckOnce(); err != nil {
+				log.Printf("Sheet watch: first pass failed: %v", err)
+			}
+		}()
+		register(services.Job{
+			Name:     services.JobSheetWatch,
+			Schedule: schedule(services.JobSheetWatch, every(cfg.Watch.Interval)),
+			Run: func(map[string]string) (interface{}, error) {
+				return watcher.CheckOnce()
+			},
+		})
+	}
+
+	// Carry-over of unfinished tasks into each employee's today
+	carry := services.NewCarryOver(store, env)
+	if cache != nil {
+		carry.SetOnChange(cache.InvalidateTasks)
+	}
+	register(services.Job{
+		Name:     services.JobCarryOver,
+		Schedule: schedule(services.JobCarryOver, every(cfg.CarryOver.Interval)),
+		Run: func(state map[string]string) (interface{}, error) {
+			return carry.RunOnce(state)
+		},
+	})
+
+	// Reminders to employees who have not written today's update
+	notifiers, err := services.NewNotifiers(cfg.Reminders.Notifiers)
+	if err != nil {
+		log.Fatal(err)
+	}
+	reminders := services.NewReminders(store, notifiers, env)
+	api.Reminders = reminders
+	if len(notifiers) > 0 {
+		register(services.Job{
+			Name:     services.JobReminders,
+			Schedule: schedule(services.JobReminders, cfg.Reminders.Schedule),
+			Run: func(state map[string]string) (interface{}, error) {
+				return reminders.RunOnce(state)
+			},
+		})
+	}
+
+	// Cache warming, on each instance since every instance has its own cache
+	if cache != nil {
+		register(services.Job{
+			Name:        services.JobCacheWarm,
+			Schedule:    schedule(services.JobCacheWarm, ""),
+			PerInstance: true,
+			Run: func(map[string]string) (interface{}, error) {
+				team, info, err := cache.GetAllEmployeesLatestTasksWithInfo()
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"employees": len(team.Employees), "cache": info.Status}, nil
+			},
+		})
+	}
+
+	go scheduler.Start(context.Background())
+	log.Printf("Job scheduler running as %s, state in %s", scheduler.Instance(), scheduler.Describe())
+
+	h := handlers.New(api)
 	r := mux.NewRouter()
-	r.Use(enableCORS)
+	r.Use(enableCORS(cfg.Server))
+	r.NotFoundHandler = enableCORS(cfg.Server)(http.HandlerFunc(handlers.NotFound))
+	r.MethodNotAllowedHandler = enableCORS(cfg.Server)(http.HandlerFunc(handlers.MethodNotAllowed))
 
 	// Sheets
-	r.HandleFunc("/employee/{name}/tasks", handlers.GetLatestTasksByEmployee).Methods("GET", "OPTIONS")
-	r.HandleFunc("/employees/tasks", handlers.GetAllEmployeesLatestTasks).Methods("GET", "OPTIONS")
-	r.HandleFunc("/task", handlers.PostTaskUpdate).Methods("POST", "OPTIONS")
+	r.HandleFunc("/employee/{name}/tasks", h.GetLatestTasksByEmployee).Methods("GET", "OPTIONS")
+	r.HandleFunc("/employees/tasks", h.GetAllEmployeesLatestTasks).Methods("GET", "OPTIONS")
+	r.HandleFunc("/task", h.PostTaskUpdate).Methods("POST", "OPTIONS")
+	r.HandleFunc("/tasks/{id}", h.GetTask).Methods("GET", "OPTIONS")
+	r.HandleFunc("/tasks/{id}", h.UpdateTask).Methods("PATCH", "OPTIONS")
+	r.HandleFunc("/tasks/{id}", h.DeleteTask).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/employee/{name}/days/{date}/tasks/{line}", h.UpdateCellTask).Methods("PATCH", "OPTIONS")
+	r.HandleFunc("/employee/{name}/days/{date}/tasks/{line}", h.DeleteCellTask).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/employee/{name}/days/{date}/order", h.ReorderCell).Methods("PUT", "OPTIONS")
+	r.HandleFunc("/edit-window", h.GetEditWindow).Methods("GET", "OPTIONS")
 
 	// DB
-	r.HandleFunc("/metadata", handlers.GetMetadata).Methods("GET", "OPTIONS")
-	r.HandleFunc("/metadata", handlers.UpsertMetadata).Methods("POST", "OPTIONS")
+	r.HandleFunc("/metadata", h.GetMetadata).Methods("GET", "OPTIONS")
+	r.HandleFunc("/metadata", h.UpsertMetadata).Methods("POST", "OPTIONS")
 	
 	// New Daily Logs Endpoints
-	r.HandleFunc("/logs", handlers.GetDailyLogs).Methods("GET", "OPTIONS")
-	r.HandleFunc("/logs", handlers.UpsertDailyLog).Methods("POST", "OPTIONS")
+	r.HandleFunc("/logs", h.GetDailyLogs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/logs", h.UpsertDailyLog).Methods("POST", "OPTIONS")
+
+	// Sync
+	r.HandleFunc("/sync/status", h.GetSyncStatus).Methods("GET", "OPTIONS")
+	r.HandleFunc("/sync/run", h.RunSync).Methods("POST", "OPTIONS")
+	r.HandleFunc("/sync/resolve", h.ResolveSyncConflict).Methods("POST", "OPTIONS")
+
+	// Carry-over
+	r.HandleFunc("/carry-over", h.PostCarryOver).Methods("POST", "OPTIONS")
+	r.HandleFunc("/carry-over/run", h.RunCarryOver).Methods("POST", "OPTIONS")
+
+	// Jobs
+	r.HandleFunc("/jobs", h.GetJobs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/jobs/{name}", h.GetJob).Methods("GET", "OPTIONS")
+	r.HandleFunc("/jobs/{name}/run", h.RunJob).Methods("POST", "OPTIONS")
+
+	// Reports and reminders
+	r.HandleFunc("/reports/missing", h.GetMissingUpdates).Methods("GET", "OPTIONS")
+	r.HandleFunc("/notifiers", h.GetNotifiers).Methods("GET", "OPTIONS")
+	r.HandleFunc("/notifiers/{name}/test", h.TestNotifier).Methods("POST", "OPTIONS")
 
-	log.Println("Server starting on port 8080...")
-	if err := http.ListenAndServe(":8080", r); err != nil {
+	// Webhooks
+	r.HandleFunc("/webhooks", h.GetWebhooks).Methods("GET", "OPTIONS")
+	r.HandleFunc("/webhooks", h.CreateWebhook).Methods("POST", "OPTIONS")
+	r.HandleFunc("/webhooks/deliveries", h.GetWebhookDeliveries).Methods("GET", "OPTIONS")
+	r.HandleFunc("/webhooks/deliveries/{id}/retry", h.RetryWebhookDelivery).Methods("POST", "OPTIONS")
+	r.HandleFunc("/webhooks/dead-letters", h.GetWebhookDeadLetters).Methods("GET", "OPTIONS")
+	r.HandleFunc("/webhooks/{id}", h.GetWebhook).Methods("GET", "OPTIONS")
+	r.HandleFunc("/webhooks/{id}", h.UpdateWebhook).Methods("PUT", "OPTIONS")
+	r.HandleFunc("/webhooks/{id}", h.DeleteWebhook).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/events", h.GetEvents).Methods("GET", "OPTIONS")
+	r.HandleFunc("/audit", h.GetAudit).Methods("GET", "OPTIONS")
+
+	// Archive
+	r.HandleFunc("/archive", h.GetArchives).Methods("GET", "OPTIONS")
+	r.HandleFunc("/archive/rollover", h.RunRollover).Methods("POST", "OPTIONS")
+
+	// Teams
+	r.HandleFunc("/teams", h.GetTeams).Methods("GET", "OPTIONS")
+	r.HandleFunc("/teams", h.CreateTeam).Methods("POST", "OPTIONS")
+	r.HandleFunc("/teams/reload", h.ReloadTeams).Methods("POST", "OPTIONS")
+	r.HandleFunc("/teams/{name}", h.GetTeam).Methods("GET", "OPTIONS")
+	r.HandleFunc("/teams/{name}", h.UpdateTeam).Methods("PUT", "OPTIONS")
+	r.HandleFunc("/teams/{name}", h.DeleteTeam).Methods("DELETE", "OPTIONS")
+
+	// Calendar
+	r.HandleFunc("/calendar", h.GetCalendar).Methods("GET", "OPTIONS")
+	r.HandleFunc("/calendar/holidays", h.GetHolidays).Methods("GET", "OPTIONS")
+	r.HandleFunc("/calendar/reload", h.ReloadHolidays).Methods("POST", "OPTIONS")
+	r.HandleFunc("/calendar/employees", h.GetCalendarEmployees).Methods("GET", "OPTIONS")
+	r.HandleFunc("/calendar/employees/{name}", h.GetCalendarEmployee).Methods("GET", "OPTIONS")
+	r.HandleFunc("/calendar/employees/{name}/leave", h.AddLeave).Methods("POST", "OPTIONS")
+	r.HandleFunc("/calendar/employees/{name}/leave/{date}", h.RemoveLeave).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/calendar/employees/{name}/holiday-sets", h.SetHolidaySets).Methods("PUT", "OPTIONS")
+
+	// Admin
+	r.HandleFunc("/credentials/reload", h.ReloadCredentials).Methods("POST", "OPTIONS")
+	r.HandleFunc("/sheets/stats", h.GetSheetsAPIStats).Methods("GET", "OPTIONS")
+
+	// Streams (SSE) follow this context, so they end when shutdown starts instead of holding it up
+	serving, stopServing := context.WithCancel(context.Background())
+	srv := &http.Server{Addr: cfg.Server.Listen, Handler: r, BaseContext: func(net.Listener) context.Context { return serving }}
+	go shutdownOnSignal(srv, stopServing)
+
+	if cfg.Server.TLS.Enabled() {
+		log.Printf("Server starting on %s (TLS)...", cfg.Server.Listen)
+		err = srv.ListenAndServeTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+	} else {
+		log.Printf("Server starting on %s...", cfg.Server.Listen)
+		err = srv.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
+
+	// The last requests are done; write what the audit trail and webhooks still hold
+	stopQueues()
+	drained := make(chan struct{})
+	go func() {
+		draining.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		log.Println("Audit and webhook queues drained")
+	case <-time.After(shutdownTimeout):
+		log.Printf("Gave up draining the audit and webhook queues after %s", shutdownTimeout)
+	}
 }
 
 
@@ -0,0 +1,211 @@
+// models/date.go
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Date layouts
+const (
+	DateLayout         = "2006-01-02"      // JSON, query parameters and the database
+	HeaderLayout       = "Mon 02-Jan-2006" // Column headers written to the role sheets
+	LegacyHeaderLayout = "Mon 02-Jan"      // Old column headers without a year
+)
+
+// Date is a calendar day, without time of day or time zone
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the calendar day of t in t's location
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// Today returns the current local calendar day
+func Today() Date {
+	return DateOf(time.Now())
+}
+
+// ParseDate parses an ISO date ("2006-01-02") or a full header ("Mon 02-Jan-2006")
+func ParseDate(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{DateLayout, HeaderLayout} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return DateOf(t), nil
+		}
+	}
+	return Date{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD", s)
+}
+
+// ParseLegacyHeader parses a year-less "Mon 02-Jan" header, picking the year that puts it
+// nearest to near; the weekday breaks ties
+func ParseLegacyHeader(s string, near Date) (Date, error) {
+	s = strings.TrimSpace(s)
+	t, err := time.Parse(LegacyHeaderLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date header %q", s)
+	}
+
+	var best Date
+	bestScore := -1
+	for _, year := range []int{near.Year - 1, near.Year, near.Year + 1} {
+		d, m := legacyCandidate(t, year, s)
+		if !m.valid {
+			continue
+		}
+		dist := near.DaysUntil(d)
+		if dist < 0 {
+			dist = -dist
+		}
+		// Matching weekday first, then distance
+		score := dist
+		if !m.weekday {
+			score += 1000
+		}
+		if bestScore < 0 || score < bestScore {
+			best, bestScore = d, score
+		}
+	}
+	if bestScore < 0 {
+		return Date{}, fmt.Errorf("invalid date header %q", s)
+	}
+	return best, nil
+}
+
+// legacyMatch says whether a legacy header fits a given year
+type legacyMatch struct {
+	valid   bool // The day exists in that year (29-Feb)
+	weekday bool // The header's weekday agrees with that year
+}
+
+// Helper: The day of a parsed legacy header in year
+func legacyCandidate(t time.Time, year int, header string) (Date, legacyMatch) {
+	d := Date{Year: year, Month: t.Month(), Day: t.Day()}
+	if DateOf(d.Time()) != d {
+		return d, legacyMatch{}
+	}
+	return d, legacyMatch{valid: true, weekday: strings.HasPrefix(strings.ToLower(header), strings.ToLower(d.Time().Format("Mon")))}
+}
+
+// LegacyHeaderIn resolves a year-less header to the latest day on or before limit that it can name.
+// Header rows are oldest to newest, so limit is the day of the column to its right.
+func LegacyHeaderIn(s string, limit Date) (Date, error) {
+	s = strings.TrimSpace(s)
+	t, err := time.Parse(LegacyHeaderLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date header %q", s)
+	}
+
+	year := limit.Year
+	if t.Month() > limit.Month || (t.Month() == limit.Month && t.Day() > limit.Day) {
+		year--
+	}
+
+	// Prefer a year whose weekday agrees; 29-Feb needs a leap year
+	var fallback *Date
+	for y := year; y > year-8; y-- {
+		d, m := legacyCandidate(t, y, s)
+		if !m.valid {
+			continue
+		}
+		if m.weekday {
+			return d, nil
+		}
+		if fallback == nil {
+			fallback = &d
+		}
+	}
+	if fallback == nil {
+		return Date{}, fmt.Errorf("invalid date header %q", s)
+	}
+	return *fallback, nil
+}
+
+// IsZero reports whether d is unset
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// Time returns midnight UTC of d
+func (d Date) Time() time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// AddDays returns d moved by n days
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.Time().AddDate(0, 0, n))
+}
+
+// DaysUntil returns the number of days from d to other (negative when other is earlier)
+func (d Date) DaysUntil(other Date) int {
+	return int(other.Time().Sub(d.Time()).Hours() / 24)
+}
+
+// Before reports whether d is earlier than other
+func (d Date) Before(other Date) bool {
+	return d.Time().Before(other.Time())
+}
+
+// After reports whether d is later than other
+func (d Date) After(other Date) bool {
+	return d.Time().After(other.Time())
+}
+
+// Weekday returns the day of the week of d
+func (d Date) Weekday() time.Weekday {
+	return d.Time().Weekday()
+}
+
+// String formats d as YYYY-MM-DD
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Time().Format(DateLayout)
+}
+
+// Header formats d as a role sheet column header, e.g. "Fri 16-Oct-2026"
+func (d Date) Header() string {
+	return d.Time().Format(HeaderLayout)
+}
+
+// MarshalJSON writes d as "YYYY-MM-DD", or null when unset
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts "YYYY-MM-DD", a full header, or a legacy "Mon 02-Jan"
+// header from older clients (taken as the nearest such day); null and "" leave d unset
+func (d *Date) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*d = Date{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("date must be a string")
+	}
+	if strings.TrimSpace(s) == "" {
+		*d = Date{}
+		return nil
+	}
+
+	parsed, err := ParseDate(s)
+	if err != nil {
+		if parsed, err = ParseLegacyHeader(s, Today()); err != nil {
+			return fmt.Errorf("invalid date %q: use YYYY-MM-DD", s)
+		}
+	}
+	*d = parsed
+	return nil
+}
@@ -3,24 +3,31 @@ package models
 
 // TaskItem represents a single task with its status
 type TaskItem struct {
+	ID     string `json:"id,omitempty"` // Stable across renames and reorders; ignored when adding tasks
 	Task   string `json:"task"`
 	Status string `json:"status"` // "todo", "pending", "complete"
+
+	CarriedFrom *Date `json:"carried_from,omitempty"` // Day an unfinished task was first carried over from
 }
 
 // TaskRequest represents the payload for adding new tasks
 type TaskRequest struct {
 	EmployeeName string     `json:"employee_name"`
 	Role         string     `json:"role"` // "Dev" or "Managers"
-	Date         string     `json:"date"` // Optional: "Mon 02-Jan"
+	Date         Date       `json:"date"` // Optional: "2006-01-02", defaults to today
 	Tasks        []TaskItem `json:"tasks"`
+	Actor        string     `json:"-"` // Who is writing: the bearer token owner or the X-Actor header
 }
 
 // DayTasks represents tasks for a specific date, categorized by status
 type DayTasks struct {
-	Date     string   `json:"date"`
+	Date     Date     `json:"date"`  // null when the column header is not a date
+	Label    string   `json:"label"` // Column header as written in the sheet
 	Todo     []string `json:"todo"`
 	Pending  []string `json:"pending"`
 	Complete []string `json:"complete"`
+
+	Tasks []TaskItem `json:"tasks"` // Every line in cell order, with its ID
 }
 
 // EmployeeTasksResponse is the structure for returning an employee's history
@@ -28,4 +35,22 @@ type EmployeeTasksResponse struct {
 	EmployeeName string     `json:"employee_name"`
 	SheetName    string     `json:"sheet_name"` // Added to track source sheet
 	History      []DayTasks `json:"history"`
+
+	// Set on single-employee lookups: which role sheets were searched
+	Sheets  []SheetStatus `json:"sheets,omitempty"`
+	Partial bool          `json:"partial,omitempty"` // Some sheets failed; History may be incomplete
+}
+
+// SheetStatus reports whether one role sheet could be read
+type SheetStatus struct {
+	Sheet string `json:"sheet"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// TeamTasksResponse is the dashboard view of every employee with the outcome of each role sheet
+type TeamTasksResponse struct {
+	Employees []EmployeeTasksResponse `json:"employees"`
+	Sheets    []SheetStatus           `json:"sheets"`
+	Partial   bool                    `json:"partial"` // Some sheets failed; Employees is incomplete
 }
\ No newline at end of file
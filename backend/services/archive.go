@@ -0,0 +1,567 @@
+// services/archive.go
+package services
+
+import (
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// layoutMu guards the column layout of the role sheets: writers that address cells by
+// column index hold it for reading, the rollover (which deletes columns) for writing.
+// It only covers this process; writers check their column again right before writing
+// (checkDayColumn), and the rollover checks its columns right before deleting them.
+var layoutMu sync.RWMutex
+
+// ArchiveTab is an archive tab holding one closed period of a role sheet
+type ArchiveTab struct {
+	Title string      `json:"title"` // e.g. "DEV 2025" or "DEV 2025-Q3"
+	Start models.Date `json:"start"` // First day of the period
+}
+
+// ArchiveSheet lists the archive tabs of one role sheet, newest period first
+type ArchiveSheet struct {
+	Sheet    string       `json:"sheet"`
+	Archives []ArchiveTab `json:"archives"`
+}
+
+// ArchiveMove is one batch of columns moved from a role sheet into an archive tab
+type ArchiveMove struct {
+	Sheet   string      `json:"sheet"`
+	Archive string      `json:"archive"`
+	Columns int         `json:"columns"`
+	From    models.Date `json:"from"`
+	To      models.Date `json:"to"`
+}
+
+// ArchiveReport summarizes one rollover pass
+type ArchiveReport struct {
+	StartedAt  string        `json:"started_at"`
+	Duration   string        `json:"duration"`
+	Cutoff     models.Date   `json:"cutoff"` // Day columns before this were due for the archive
+	Moved      []ArchiveMove `json:"moved"`
+	FatalError string        `json:"fatal_error,omitempty"`
+}
+
+// Helper: Spreadsheet holding the archive tabs
+func archiveSpreadsheetID() string {
+	if id := config.Get().Archive.SpreadsheetID; id != "" {
+		return id
+	}
+	return config.Get().SpreadsheetID
+}
+
+// Helper: Whether closed periods are archived (and reads should span the archive tabs)
+func archiveEnabled() bool {
+	return config.Get().Archive.Period != ""
+}
+
+// Helper: First day of the period containing d
+func periodStart(period string, d models.Date) models.Date {
+	if strings.EqualFold(period, "quarter") {
+		return models.Date{Year: d.Year, Month: (d.Month-1)/3*3 + 1, Day: 1}
+	}
+	return models.Date{Year: d.Year, Month: time.January, Day: 1}
+}
+
+// Helper: Archive tab of a role sheet for the period containing d, e.g. "DEV 2025" or "DEV 2025-Q3"
+func archiveTitle(role, period string, d models.Date) string {
+	if strings.EqualFold(period, "quarter") {
+		return fmt.Sprintf("%s %d-Q%d", role, d.Year, (d.Month-1)/3+1)
+	}
+	return fmt.Sprintf("%s %d", role, d.Year)
+}
+
+// Helper: Parse the period of an archive tab of role ("DEV 2025", "DEV 2025-Q3"); ok is false for other tabs
+func parseArchiveTitle(role, title string) (models.Date, bool) {
+	prefix := role + " "
+	if len(title) <= len(prefix) || !strings.EqualFold(title[:len(prefix)], prefix) {
+		return models.Date{}, false
+	}
+	suffix := title[len(prefix):]
+
+	yearPart, quarter := suffix, 1
+	if i := strings.Index(suffix, "-Q"); i != -1 {
+		q, err := strconv.Atoi(suffix[i+2:])
+		if err != nil || q < 1 || q > 4 {
+			return models.Date{}, false
+		}
+		yearPart, quarter = suffix[:i], q
+	}
+	if len(yearPart) != 4 {
+		return models.Date{}, false
+	}
+	year, err := strconv.Atoi(yearPart)
+	if err != nil {
+		return models.Date{}, false
+	}
+	return models.Date{Year: year, Month: time.Month(quarter-1)*3 + 1, Day: 1}, true
+}
+
+// Helper: Archive tabs of role in the archive spreadsheet metadata, newest period first
+func findArchiveTabs(meta *sheets.Spreadsheet, role string) []ArchiveTab {
+	tabs := []ArchiveTab{}
+	for _, sheet := range meta.Sheets {
+		if start, ok := parseArchiveTitle(role, sheet.Properties.Title); ok {
+			tabs = append(tabs, ArchiveTab{Title: sheet.Properties.Title, Start: start})
+		}
+	}
+	sort.Slice(tabs, func(i, j int) bool {
+		return tabs[j].Start.Before(tabs[i].Start)
+	})
+	return tabs
+}
+
+// Helper: Archive tabs of a role sheet, newest period first
+func archiveTabs(srv *sheets.Service, role string) ([]ArchiveTab, error) {
+	meta, err := srv.Spreadsheets.Get(archiveSpreadsheetID()).Fields("sheets.properties").Do()
+	if err != nil {
+		return nil, err
+	}
+	return findArchiveTabs(meta, role), nil
+}
+
+// Helper: An employee's history from the archive tabs of a role sheet, newest period first,
+// with the outcome of each tab
+func archivedHistory(srv *sheets.Service, role string, employeeName string) ([]models.DayTasks, []models.SheetStatus) {
+	if !archiveEnabled() {
+		return nil, nil
+	}
+	tabs, err := archiveTabs(srv, role)
+	if err != nil {
+		return nil, []models.SheetStatus{sheetStatus(role+" archive", err)}
+	}
+
+	var hist []models.DayTasks
+	var statuses []models.SheetStatus
+	for _, tab := range tabs {
+		h, _, err := fetchSheetData(srv, archiveSpreadsheetID(), tab.Title, employeeName)
+		statuses = append(statuses, sheetStatus(tab.Title, err))
+		hist = append(hist, h...)
+	}
+	return hist, statuses
+}
+
+// Helper: Fill the latest days of employees with fewer than 7 working days on the live tab from the newest archive tab.
+// The archive is best effort here: failures are logged and the live days returned.
+func (e *Env) withArchivedLatestTasks(srv *sheets.Service, role string, employees []models.EmployeeTasksResponse) []models.EmployeeTasksResponse {
+	if !archiveEnabled() {
+		return employees
+	}
+	short := false
+	for _, emp := range employees {
+		if e.latestCount(emp.EmployeeName, emp.History) < latestDays {
+			short = true
+			break
+		}
+	}
+	if !short {
+		return employees
+	}
+
+	tabs, err := archiveTabs(srv, role)
+	if err != nil {
+		log.Printf("Failed to list archive tabs of %s: %v", role, err)
+		return employees
+	}
+	if len(tabs) == 0 {
+		return employees
+	}
+	archived, err := e.gridLatestTasks(srv, archiveSpreadsheetID(), tabs[0].Title)
+	if err != nil {
+		log.Printf("Failed to read archive tab %s: %v", tabs[0].Title, err)
+		return employees
+	}
+
+	for i, emp := range employees {
+		for _, old := range archived {
+			if !namesMatch(old.EmployeeName, emp.EmployeeName) {
+				continue
+			}
+			counted := e.latestCount(emp.EmployeeName, emp.History)
+			for _, day := range old.History {
+				if counted >= latestDays {
+					break
+				}
+				emp.History = append(emp.History, day)
+				if e.countsTowardLatest(emp.EmployeeName, day.Date) {
+					counted++
+				}
+			}
+		}
+		employees[i] = emp
+	}
+	return employees
+}
+
+// Archiver moves the day columns of closed periods out of the role sheets into
+// archive tabs, so the live tabs stay within the grid and A:ZZ read limits
+type Archiver struct {
+	sheets *config.SheetsClient
+	env    *Env
+	mu     sync.Mutex // One pass at a time
+
+	lastMu     sync.RWMutex
+	lastReport *ArchiveReport
+
+	onChange func() // Called after columns were moved
+}
+
+// NewArchiver returns an Archiver for the spreadsheet behind client and the role sheets of env
+func NewArchiver(client *config.SheetsClient, env *Env) *Archiver {
+	return &Archiver{sheets: client, env: env}
+}
+
+// SetOnChange registers fn to be called whenever a rollover moved columns
+func (a *Archiver) SetOnChange(fn func()) {
+	a.onChange = fn
+}
+
+// LastReport returns the report of the most recent pass, if any
+func (a *Archiver) LastReport() *ArchiveReport {
+	a.lastMu.RLock()
+	defer a.lastMu.RUnlock()
+	return a.lastReport
+}
+
+// Archives lists the archive tabs of every role sheet
+func (a *Archiver) Archives() ([]ArchiveSheet, error) {
+	srv, err := a.sheets.Service()
+	if err != nil {
+		return nil, err
+	}
+	meta, err := srv.Spreadsheets.Get(archiveSpreadsheetID()).Fields("sheets.properties").Do()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []ArchiveSheet{}
+	for _, role := range a.env.roleSheets() {
+		result = append(result, ArchiveSheet{Sheet: role, Archives: findArchiveTabs(meta, role)})
+	}
+	return result, nil
+}
+
+// RollOver moves every day column of a closed period into its archive tab.
+// A period is closed once no day of it is inside the edit window. Columns are
+// copied first and only then deleted, so an interrupted pass is repeated safely.
+func (a *Archiver) RollOver() (report ArchiveReport, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	started := time.Now()
+	report = ArchiveReport{StartedAt: started.Format(time.RFC3339), Moved: []ArchiveMove{}}
+	defer func() {
+		report.Duration = time.Since(started).String()
+		a.lastMu.Lock()
+		a.lastReport = &report
+		a.lastMu.Unlock()
+		if len(report.Moved) > 0 && a.onChange != nil {
+			a.onChange()
+		}
+	}()
+
+	fail := func(err error) (ArchiveReport, error) {
+		report.FatalError = err.Error()
+		return report, err
+	}
+
+	period := config.Get().Archive.Period
+	if period == "" {
+		return fail(newError(ErrValidation, "archive_disabled", "archive.period is not set"))
+	}
+	// Keep every period someone may still write to, on any sheet
+	var open models.Date
+	for _, role := range a.env.roleSheets() {
+		if d := a.env.earliestEditable(role); open.IsZero() || d.Before(open) {
+			open = d
+		}
+	}
+	report.Cutoff = periodStart(period, open)
+
+	srv, err := a.sheets.Service()
+	if err != nil {
+		return fail(err)
+	}
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Fields("sheets.properties").Do()
+	if err != nil {
+		return fail(err)
+	}
+
+	layoutMu.Lock()
+	defer layoutMu.Unlock()
+
+	for _, role := range a.env.roleSheets() {
+		sheet := findSheetByTitle(meta, role)
+		if sheet == nil {
+			continue
+		}
+		moves, err := rollOverSheet(srv, sheet.Properties, period, report.Cutoff)
+		report.Moved = append(report.Moved, moves...)
+		if err != nil {
+			return fail(fmt.Errorf("failed to roll over sheet '%s': %v", sheet.Properties.Title, err))
+		}
+	}
+	for _, m := range report.Moved {
+		log.Printf("Archived %d columns of %s (%s to %s) into %s", m.Columns, m.Sheet, m.From, m.To, m.Archive)
+	}
+	return report, nil
+}
+
+// Helper: Move the day columns of one role sheet dated before cutoff into their archive tabs
+func rollOverSheet(srv *sheets.Service, props *sheets.SheetProperties, period string, cutoff models.Date) ([]ArchiveMove, error) {
+	title := props.Title
+	rows, headerRow, err := fetchSheetGrid(srv, title)
+	if err != nil {
+		return nil, err
+	}
+	headers := decodeHeaderRow(headerRow)
+
+	// 1. Closed columns grouped by archive tab, oldest first
+	groups := map[string][]int{}
+	var order []string
+	var closed []int
+	for i := 1; i < len(headers.dates); i++ {
+		d := headers.dates[i]
+		if d.IsZero() || !d.Before(cutoff) {
+			continue
+		}
+		tab := archiveTitle(title, period, d)
+		if _, ok := groups[tab]; !ok {
+			order = append(order, tab)
+		}
+		groups[tab] = append(groups[tab], i)
+		closed = append(closed, i)
+	}
+	if len(closed) == 0 {
+		return nil, nil
+	}
+
+	// 2. Copy each group into its archive tab
+	var moves []ArchiveMove
+	for _, tab := range order {
+		cols := groups[tab]
+		if err := copyToArchive(srv, tab, rows, headers, cols); err != nil {
+			return moves, fmt.Errorf("archive tab '%s': %v", tab, err)
+		}
+		from, to := headers.dates[cols[0]], headers.dates[cols[0]]
+		for _, c := range cols {
+			if headers.dates[c].Before(from) {
+				from = headers.dates[c]
+			}
+			if headers.dates[c].After(to) {
+				to = headers.dates[c]
+			}
+		}
+		moves = append(moves, ArchiveMove{Sheet: title, Archive: tab, Columns: len(cols), From: from, To: to})
+	}
+
+	// 3. Delete the copied columns from the live tab, rightmost first so indexes stay valid.
+	// Another instance may have shifted them since the read; the next pass retries then.
+	if err := checkClosedColumns(srv, title, headers, closed); err != nil {
+		return moves, err
+	}
+	var requests []*sheets.Request
+	for end := len(closed); end > 0; {
+		start := end - 1
+		for start > 0 && closed[start-1] == closed[start]-1 {
+			start--
+		}
+		requests = append(requests, &sheets.Request{
+			DeleteDimension: &sheets.DeleteDimensionRequest{
+				Range: &sheets.DimensionRange{
+					SheetId:    props.SheetId,
+					Dimension:  "COLUMNS",
+					StartIndex: int64(closed[start]),
+					EndIndex:   int64(closed[end-1] + 1),
+				},
+			},
+		})
+		end = start
+	}
+	_, err = srv.Spreadsheets.BatchUpdate(config.Get().SpreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).Do()
+	if err != nil {
+		return moves, fmt.Errorf("columns were archived but not removed: %v", err)
+	}
+	return moves, nil
+}
+
+// Helper: Check that the closed columns of a role sheet still hold the days that were copied
+func checkClosedColumns(srv *sheets.Service, title string, headers dateHeaders, closed []int) error {
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!1:1", title)).Do()
+	if err != nil {
+		return err
+	}
+	var headerRow []interface{}
+	if len(resp.Values) > 0 {
+		headerRow = resp.Values[0]
+	}
+	now := decodeHeaderRow(headerRow)
+	for _, c := range closed {
+		if c >= len(now.dates) || now.dates[c] != headers.dates[c] {
+			return fmt.Errorf("columns were archived but moved before they could be removed; the next pass removes them")
+		}
+	}
+	return nil
+}
+
+// Helper: Merge the given columns of a role sheet into an archive tab (created when missing).
+// Existing archive columns for the same day are overwritten, so a repeated copy is harmless.
+func copyToArchive(srv *sheets.Service, tab string, rows []*sheets.RowData, headers dateHeaders, cols []int) error {
+	archiveID := archiveSpreadsheetID()
+	props, err := ensureArchiveTab(srv, archiveID, tab)
+	if err != nil {
+		return err
+	}
+	archiveRows, _, err := fetchSheetGridIn(srv, archiveID, tab)
+	if err != nil {
+		return err
+	}
+
+	// Current archive content
+	var grid [][]*sheets.CellData
+	for _, row := range archiveRows {
+		grid = append(grid, append([]*sheets.CellData(nil), row.Values...))
+	}
+	if len(grid) == 0 {
+		grid = [][]*sheets.CellData{{textCell(headers.labels[0])}}
+	}
+	cellAt := func(r, c int) *sheets.CellData {
+		if r < len(grid) && c < len(grid[r]) {
+			return grid[r][c]
+		}
+		return nil
+	}
+	setCell := func(r, c int, cd *sheets.CellData) {
+		for len(grid[r]) <= c {
+			grid[r] = append(grid[r], nil)
+		}
+		grid[r][c] = cd
+	}
+
+	archiveLabels := make([]string, len(grid[0]))
+	for c := range grid[0] {
+		archiveLabels[c] = cellText(grid[0][c])
+	}
+	archiveHeaders := decodeHeaders(archiveLabels, models.Today())
+
+	for _, c := range cols {
+		d := headers.dates[c]
+		ac := archiveHeaders.find(d)
+		if ac == -1 {
+			ac = len(archiveHeaders.labels)
+			archiveHeaders.labels = append(archiveHeaders.labels, d.Header())
+			archiveHeaders.dates = append(archiveHeaders.dates, d)
+		}
+		setCell(0, ac, textCell(d.Header()))
+
+		for r := 1; r < len(rows); r++ {
+			if c >= len(rows[r].Values) || len(rows[r].Values) == 0 {
+				continue
+			}
+			cell := rows[r].Values[c]
+			name := strings.TrimSpace(cellText(rows[r].Values[0]))
+			if name == "" || strings.TrimSpace(cellText(cell)) == "" {
+				continue
+			}
+
+			ar := -1
+			for i := 1; i < len(grid); i++ {
+				if namesMatch(cellText(cellAt(i, 0)), name) {
+					ar = i
+					break
+				}
+			}
+			if ar == -1 {
+				grid = append(grid, []*sheets.CellData{textCell(name)})
+				ar = len(grid) - 1
+			}
+			setCell(ar, ac, &sheets.CellData{
+				UserEnteredValue:  cell.UserEnteredValue,
+				TextFormatRuns:    cell.TextFormatRuns,
+				UserEnteredFormat: cell.UserEnteredFormat,
+				Note:              cell.Note, // Task IDs
+			})
+		}
+	}
+
+	// Grow the tab to fit, then write the whole grid
+	width := 0
+	out := make([]*sheets.RowData, len(grid))
+	for r, row := range grid {
+		values := make([]*sheets.CellData, len(row))
+		for c, cd := range row {
+			if cd == nil {
+				cd = &sheets.CellData{}
+			}
+			values[c] = cd
+		}
+		out[r] = &sheets.RowData{Values: values}
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+
+	var requests []*sheets.Request
+	if gp := props.GridProperties; gp != nil {
+		if extra := int64(len(grid)) - gp.RowCount; extra > 0 {
+			requests = append(requests, &sheets.Request{AppendDimension: &sheets.AppendDimensionRequest{SheetId: props.SheetId, Dimension: "ROWS", Length: extra}})
+		}
+		if extra := int64(width) - gp.ColumnCount; extra > 0 {
+			requests = append(requests, &sheets.Request{AppendDimension: &sheets.AppendDimensionRequest{SheetId: props.SheetId, Dimension: "COLUMNS", Length: extra}})
+		}
+	}
+	requests = append(requests, &sheets.Request{
+		UpdateCells: &sheets.UpdateCellsRequest{
+			Start:  &sheets.GridCoordinate{SheetId: props.SheetId},
+			Rows:   out,
+			Fields: "userEnteredValue,textFormatRuns,userEnteredFormat.textFormat.foregroundColor,note",
+		},
+	})
+
+	_, err = srv.Spreadsheets.BatchUpdate(archiveID, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).Do()
+	return err
+}
+
+// Helper: Find an archive tab, adding it when missing
+func ensureArchiveTab(srv *sheets.Service, spreadsheetID string, title string) (*sheets.SheetProperties, error) {
+	meta, err := srv.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties").Do()
+	if err != nil {
+		return nil, err
+	}
+	if sheet := findSheetByTitle(meta, title); sheet != nil {
+		return sheet.Properties, nil
+	}
+
+	resp, err := srv.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			AddSheet: &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: title}},
+		}},
+	}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sheet %s: %v", title, err)
+	}
+	log.Printf("Created archive sheet: %s", title)
+	return resp.Replies[0].AddSheet.Properties, nil
+}
+
+// Helper: Plain text cell
+func textCell(text string) *sheets.CellData {
+	return &sheets.CellData{UserEnteredValue: &sheets.ExtendedValue{StringValue: &text}}
+}
+
+// Helper: Text of a cell, "" when empty or not text
+func cellText(cd *sheets.CellData) string {
+	if cd == nil || cd.UserEnteredValue == nil || cd.UserEnteredValue.StringValue == nil {
+		return ""
+	}
+	return *cd.UserEnteredValue.StringValue
+}
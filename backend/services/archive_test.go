@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"testing"
+)
+
+func TestRollOverMovesClosedColumns(t *testing.T) {
+	fake, client := startFakeSheets(t)
+	config.InitDB(client)
+	config.Get().Archive.Period = "year"
+	env := everyDayWorking(t)
+	store := NewSheetsStore(client, env)
+	today := env.EmployeeToday(store, "Ann")
+
+	// Two days of a year that is closed whatever today is, and today
+	year := today.Year - 2
+	old1, old2 := models.Date{Year: year, Month: 3, Day: 2}, models.Date{Year: year, Month: 3, Day: 3}
+	id := config.Get().SpreadsheetID
+	seed := func(values map[[2]int]string) {
+		t.Helper()
+		for at, v := range values {
+			if err := fake.SetValue(id, "DEV", at[0], at[1], v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	seed(map[[2]int]string{
+		{0, 1}: old1.Header(), {0, 2}: old2.Header(), {0, 3}: today.Header(),
+		{1, 0}: "Ann", {1, 1}: "old task", {1, 2}: "older wrap-up", {1, 3}: "today's task",
+		{2, 0}: "Bob", {2, 1}: "bob's old task",
+	})
+
+	srv, err := client.Service()
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func(spreadsheet, a1 string) [][]interface{} {
+		t.Helper()
+		resp, err := srv.Spreadsheets.Values.Get(spreadsheet, a1).Do()
+		if err != nil {
+			t.Fatalf("read %s: %v", a1, err)
+		}
+		return resp.Values
+	}
+	archiveTab := fmt.Sprintf("DEV %d", year)
+	check := func(step string) {
+		t.Helper()
+		live := read(id, "'DEV'!A1:E3")
+		if got := fmt.Sprint(live); got != fmt.Sprint([][]interface{}{{"Employee Name", today.Header()}, {"Ann", "today's task"}, {"Bob"}}) {
+			t.Errorf("%s: live tab %v", step, got)
+		}
+		archived := read(id, fmt.Sprintf("'%s'!A1:E3", archiveTab))
+		want := [][]interface{}{{"Employee Name", old1.Header(), old2.Header()}, {"Ann", "old task", "older wrap-up"}, {"Bob", "bob's old task"}}
+		if got := fmt.Sprint(archived); got != fmt.Sprint(want) {
+			t.Errorf("%s: archive tab %v, want %v", step, got, want)
+		}
+	}
+
+	archiver := NewArchiver(client, env)
+	report, err := archiver.RollOver()
+	if err != nil {
+		t.Fatalf("RollOver: %v", err)
+	}
+	if len(report.Moved) != 1 || report.Moved[0].Archive != archiveTab || report.Moved[0].Columns != 2 ||
+		report.Moved[0].From != old1 || report.Moved[0].To != old2 {
+		t.Fatalf("moved %+v, want both %d columns into %s", report.Moved, year, archiveTab)
+	}
+	check("first pass")
+
+	// Reads span the live tab and the archive
+	emp, err := store.GetLatestTasks("Ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[models.Date]string{}
+	for _, day := range emp.History {
+		if len(day.Tasks) > 0 {
+			got[day.Date] = day.Tasks[0].Task
+		}
+	}
+	if got[today] != "today's task" || got[old1] != "old task" || got[old2] != "older wrap-up" {
+		t.Errorf("history across the archive %v", got)
+	}
+
+	// Nothing left to move
+	report, err = archiver.RollOver()
+	if err != nil || len(report.Moved) != 0 {
+		t.Fatalf("second pass moved %+v (%v), want nothing", report.Moved, err)
+	}
+	check("second pass")
+
+	// A pass interrupted after the copy left a column behind: it is copied again and removed
+	seed(map[[2]int]string{{0, 1}: old2.Header(), {0, 2}: today.Header(), {1, 1}: "older wrap-up", {1, 2}: "today's task", {2, 1}: ""})
+	report, err = archiver.RollOver()
+	if err != nil || len(report.Moved) != 1 || report.Moved[0].Columns != 1 {
+		t.Fatalf("rerun moved %+v (%v), want the leftover column", report.Moved, err)
+	}
+	check("after an interrupted pass")
+}
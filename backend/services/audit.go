@@ -0,0 +1,607 @@
+// services/audit.go
+package services
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// How long a failed audit write waits before it is tried again
+const auditRetry = 5 * time.Second
+
+// Records waiting to be written before the oldest are dropped, when the store stays down
+const auditMaxPending = 10000
+
+// AuditRecord is one change to one task: who made it, when, where and what the task and
+// its status were before and after
+type AuditRecord struct {
+	Seq          int64  `json:"seq"`      // Position in the trail, growing; the pagination cursor
+	EventID      string `json:"event_id"` // ID of the task event the record was made from
+	At           string `json:"at"`
+	Actor        string `json:"actor"`  // Bearer token owner or X-Actor of the write; empty for edits made in the sheet
+	Source       string `json:"source"` // "api" or "sheet"
+	Action       string `json:"action"` // Event type, e.g. "task.status_changed"
+	EmployeeName string `json:"employee_name"`
+	Sheet        string `json:"sheet"`
+	Date         string `json:"date"` // Task date, "" when the column header is not a date
+	TaskID       string `json:"task_id"`
+	Task         string `json:"task"`
+	OldTask      string `json:"old_task,omitempty"` // task.renamed
+	OldStatus    string `json:"old_status"`         // "" for task.created
+	NewStatus    string `json:"new_status"`         // "" for task.deleted
+}
+
+// AuditFilter selects audit records; empty fields match every record
+type AuditFilter struct {
+	EmployeeName string
+	Sheet        string
+	Actor        string
+	Source       string
+	Action       string
+	TaskID       string
+	From, To     models.Date // Task dates, inclusive
+	Since, Until time.Time   // When the change was made; Until is exclusive
+	Before       int64       // Only records with a lower seq, i.e. the previous page's NextBefore
+	Limit        int
+}
+
+// AuditPage is one page of the trail, newest first
+type AuditPage struct {
+	Records    []AuditRecord `json:"records"`
+	NextBefore int64         `json:"next_before,omitempty"` // Cursor of the next page; absent on the last page
+}
+
+// auditStore keeps the trail. Records are only ever added.
+type auditStore interface {
+	append(records []AuditRecord) error
+	query(f AuditFilter) (AuditPage, error)
+	describe() string
+}
+
+// Audit records every task event in the trail. Events are queued and written in the
+// background, so a slow or failing store never holds up a task write; failed writes are
+// retried until they succeed.
+type Audit struct {
+	store   auditStore
+	mu      sync.Mutex
+	pending []AuditRecord
+	wake    chan struct{}
+}
+
+// NewAudit returns the trail of the given backend: the audit_log table for postgres, the
+// audit tab for sheets and cfg.StoreFile (or memory) for the others
+func NewAudit(cfg config.AuditConfig, store Store) (*Audit, error) {
+	var as auditStore
+	switch s := store.(type) {
+	case *PostgresStore:
+		as = &postgresAuditStore{db: s.db}
+	case *SheetsStore:
+		as = &sheetAuditStore{client: s.client, tab: cfg.Tab}
+	default:
+		fs := &fileAuditStore{path: cfg.StoreFile}
+		if err := fs.load(); err != nil {
+			return nil, fmt.Errorf("failed to load the audit trail from %s: %v", fs.describe(), err)
+		}
+		as = fs
+	}
+	return &Audit{store: as, wake: make(chan struct{}, 1)}, nil
+}
+
+// Describe says where the trail is kept
+func (a *Audit) Describe() string {
+	return a.store.describe()
+}
+
+// Handle queues a task event for the trail; subscribe it with EventBus.Subscribe
+func (a *Audit) Handle(ev Event) {
+	if ev.Task == nil {
+		return // Not a task event
+	}
+	a.mu.Lock()
+	a.pending = append(a.pending, auditRecord(ev))
+	if n := len(a.pending) - auditMaxPending; n > 0 {
+		log.Printf("Audit: store unavailable, dropped %d records", n)
+		a.pending = append([]AuditRecord(nil), a.pending[n:]...)
+	}
+	a.mu.Unlock()
+
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run writes queued records until ctx is cancelled, then writes what is left once more
+func (a *Audit) Run(ctx context.Context) {
+	retry := time.NewTimer(auditRetry)
+	retry.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := a.flush(); err != nil {
+				log.Printf("Audit: final write failed: %v", err)
+			}
+			return
+		case <-a.wake:
+		case <-retry.C:
+		}
+		if err := a.flush(); err != nil {
+			log.Printf("Audit: write to %s failed, retrying in %s: %v", a.store.describe(), auditRetry, err)
+			retry.Reset(auditRetry)
+		}
+	}
+}
+
+// Query returns a page of the trail. Changes appear once written, normally within moments.
+func (a *Audit) Query(f AuditFilter) (AuditPage, error) {
+	page, err := a.store.query(f)
+	if err != nil {
+		return AuditPage{}, newError(ErrUnavailable, "audit_unavailable", "failed to read the audit trail: %v", err)
+	}
+	if page.Records == nil {
+		page.Records = []AuditRecord{}
+	}
+	return page, nil
+}
+
+// Helper: Write the queued records; they stay queued when the write fails
+func (a *Audit) flush() error {
+	a.mu.Lock()
+	batch := append([]AuditRecord(nil), a.pending...)
+	a.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := a.store.append(batch); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	// Handle may have dropped some of the batch meanwhile; remove what is still queued of it
+	written := map[string]bool{}
+	for _, r := range batch {
+		written[r.EventID] = true
+	}
+	kept := a.pending[:0]
+	for _, r := range a.pending {
+		if !written[r.EventID] {
+			kept = append(kept, r)
+		}
+	}
+	a.pending = kept
+	a.mu.Unlock()
+	return nil
+}
+
+// Helper: The audit record of a task event
+func auditRecord(ev Event) AuditRecord {
+	r := AuditRecord{
+		EventID:      ev.ID,
+		At:           ev.OccurredAt,
+		Actor:        ev.Actor,
+		Source:       ev.Source,
+		Action:       ev.Type,
+		EmployeeName: ev.EmployeeName,
+		Sheet:        ev.Sheet,
+		TaskID:       ev.Task.ID,
+		Task:         ev.Task.Task,
+	}
+	if ev.Date != nil {
+		r.Date = ev.Date.String()
+	}
+	switch ev.Type {
+	case EventTaskCreated:
+		r.NewStatus = ev.Task.Status
+	case EventTaskStatusChanged:
+		r.OldStatus, r.NewStatus = ev.OldStatus, ev.Task.Status
+	case EventTaskRenamed:
+		r.OldTask = ev.OldTask
+		r.OldStatus, r.NewStatus = ev.Task.Status, ev.Task.Status
+	case EventTaskDeleted:
+		r.OldStatus = ev.Task.Status
+	}
+	return r
+}
+
+// Helper: Whether a record passes the filter (cursor and limit aside)
+func (f AuditFilter) match(r AuditRecord) bool {
+	fold := func(want, got string) bool {
+		return want == "" || strings.EqualFold(strings.TrimSpace(want), got)
+	}
+	if !fold(f.EmployeeName, r.EmployeeName) || !fold(f.Sheet, r.Sheet) || !fold(f.Actor, r.Actor) ||
+		!fold(f.Source, r.Source) || !fold(f.Action, r.Action) || (f.TaskID != "" && f.TaskID != r.TaskID) {
+		return false
+	}
+	if (!f.From.IsZero() || !f.To.IsZero()) && r.Date == "" {
+		return false
+	}
+	if (!f.From.IsZero() && r.Date < f.From.String()) || (!f.To.IsZero() && r.Date > f.To.String()) {
+		return false
+	}
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		at, err := time.Parse(time.RFC3339Nano, r.At)
+		if err != nil || (!f.Since.IsZero() && at.Before(f.Since)) || (!f.Until.IsZero() && !at.Before(f.Until)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Helper: A page of records kept oldest first
+func pageAudit(records []AuditRecord, f AuditFilter) AuditPage {
+	page := AuditPage{}
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if (f.Before > 0 && r.Seq >= f.Before) || !f.match(r) {
+			continue
+		}
+		if f.Limit > 0 && len(page.Records) == f.Limit {
+			page.NextBefore = page.Records[len(page.Records)-1].Seq
+			break
+		}
+		page.Records = append(page.Records, r)
+	}
+	return page
+}
+
+// fileAuditStore keeps the trail as JSON lines appended to a file, or only in memory when
+// path is empty
+type fileAuditStore struct {
+	path    string
+	mu      sync.Mutex
+	records []AuditRecord
+	ids     map[string]bool // Event IDs of records
+}
+
+func (s *fileAuditStore) describe() string {
+	if s.path == "" {
+		return "memory"
+	}
+	return s.path
+}
+
+func (s *fileAuditStore) load() error {
+	s.ids = map[string]bool{}
+	if s.path == "" {
+		return nil
+	}
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var r AuditRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return fmt.Errorf("line %d: %v", n, err)
+		}
+		s.records = append(s.records, r)
+		s.ids[r.EventID] = true
+	}
+	return sc.Err()
+}
+
+func (s *fileAuditStore) append(records []AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := int64(len(s.records)) + 1
+	if n := len(s.records); n > 0 {
+		next = s.records[n-1].Seq + 1
+	}
+	var added []AuditRecord
+	var buf strings.Builder
+	for _, r := range records {
+		if s.ids[r.EventID] {
+			continue // Written before the retry
+		}
+		r.Seq = next + int64(len(added))
+		added = append(added, r)
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	if s.path != "" {
+		f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.WriteString(buf.String()); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	s.records = append(s.records, added...)
+	for _, r := range added {
+		s.ids[r.EventID] = true
+	}
+	return nil
+}
+
+func (s *fileAuditStore) query(f AuditFilter) (AuditPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return pageAudit(s.records, f), nil
+}
+
+// sheetAuditStore appends the trail to a tab of the spreadsheet, one row per record:
+// At | Actor | Source | Action | Employee Name | Sheet | Date | Task ID | Task | Old Task | Old Status | New Status | Event ID | Seq.
+// Seq is the pagination cursor: the record's data row number when it was appended, which
+// instances appending at once cannot share. It is written to the tab once the append reports
+// the rows, so sorting or deleting rows later does not move it; a row without one takes its
+// row number. Rows are read once and kept: each call only reads the rows appended since,
+// which assumes the tab is only ever appended to.
+type sheetAuditStore struct {
+	client  *config.SheetsClient
+	tab     string
+	mu      sync.Mutex
+	ensured bool
+	records []AuditRecord   // Rows read so far, in tab order
+	rows    int             // Data rows read so far, blank ones included
+	ids     map[string]bool // Event IDs in the tab
+}
+
+var auditTabHeaders = []interface{}{"At", "Actor", "Source", "Action", "Employee Name", "Sheet", "Date", "Task ID", "Task", "Old Task", "Old Status", "New Status", "Event ID", "Seq"}
+
+func (s *sheetAuditStore) describe() string {
+	return fmt.Sprintf("the '%s' tab", s.tab)
+}
+
+// Helper: The Sheets service, with the audit tab created on first use. Callers hold s.mu.
+func (s *sheetAuditStore) service() (*sheets.Service, error) {
+	srv, err := s.client.Service()
+	if err != nil {
+		return nil, err
+	}
+	if !s.ensured {
+		if err := config.EnsureSheet(srv, s.tab, auditTabHeaders); err != nil {
+			return nil, err
+		}
+		s.ensured = true
+	}
+	return srv, nil
+}
+
+// Helper: Read the rows appended since the last call. Callers hold s.mu.
+func (s *sheetAuditStore) refresh(srv *sheets.Service) error {
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A%d:N", s.tab, s.rows+2)).Do()
+	if err != nil {
+		return err
+	}
+	if s.ids == nil {
+		s.ids = map[string]bool{}
+	}
+	for i, row := range resp.Values {
+		cell := func(c int) string {
+			if c < len(row) {
+				return fmt.Sprintf("%v", row[c])
+			}
+			return ""
+		}
+		if cell(0) == "" || cell(12) == "" {
+			continue // Blank, or not a record this store wrote
+		}
+		seq, err := strconv.ParseInt(cell(13), 10, 64)
+		if err != nil {
+			seq = int64(s.rows + i + 1) // Appended, seq not written yet
+		}
+		r := AuditRecord{
+			Seq: seq, At: cell(0), Actor: cell(1), Source: cell(2), Action: cell(3),
+			EmployeeName: cell(4), Sheet: cell(5), Date: cell(6), TaskID: cell(7), Task: cell(8),
+			OldTask: cell(9), OldStatus: cell(10), NewStatus: cell(11), EventID: cell(12),
+		}
+		s.records = append(s.records, r)
+		s.ids[r.EventID] = true
+	}
+	s.rows += len(resp.Values)
+	return nil
+}
+
+func (s *sheetAuditStore) append(records []AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	srv, err := s.service()
+	if err != nil {
+		return err
+	}
+	// Catch up first: a batch retried after a lost response may already be in the tab
+	if err := s.refresh(srv); err != nil {
+		return err
+	}
+
+	values := make([][]interface{}, 0, len(records))
+	for _, r := range records {
+		if s.ids[r.EventID] {
+			continue
+		}
+		values = append(values, []interface{}{r.At, r.Actor, r.Source, r.Action, r.EmployeeName, r.Sheet, r.Date, r.TaskID, r.Task, r.OldTask, r.OldStatus, r.NewStatus, r.EventID})
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	resp, err := srv.Spreadsheets.Values.Append(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A:A", s.tab), &sheets.ValueRange{Values: values}).
+		ValueInputOption("RAW").InsertDataOption("INSERT_ROWS").Do()
+	if err != nil {
+		return err
+	}
+
+	// Number the rows where they landed; until then readers take the same numbers from the row
+	first, err := rangeStartRow(resp.Updates.UpdatedRange)
+	if err != nil {
+		return err
+	}
+	seqs := make([][]interface{}, len(values))
+	for i := range seqs {
+		seqs[i] = []interface{}{first - 1 + i}
+	}
+	rng := fmt.Sprintf("'%s'!N%d:N%d", s.tab, first, first+len(values)-1)
+	_, err = srv.Spreadsheets.Values.Update(config.Get().SpreadsheetID, rng, &sheets.ValueRange{Values: seqs}).ValueInputOption("RAW").Do()
+	return err
+}
+
+// Helper: Row number where an A1 range such as "'audit'!A12:N14" starts
+func rangeStartRow(a1 string) (int, error) {
+	cells := a1[strings.LastIndex(a1, "!")+1:]
+	if i := strings.Index(cells, ":"); i != -1 {
+		cells = cells[:i]
+	}
+	row, err := strconv.Atoi(strings.TrimLeft(cells, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+	if err != nil || row < 1 {
+		return 0, fmt.Errorf("no start row in range %q", a1)
+	}
+	return row, nil
+}
+
+func (s *sheetAuditStore) query(f AuditFilter) (AuditPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	srv, err := s.service()
+	if err != nil {
+		return AuditPage{}, err
+	}
+	if err := s.refresh(srv); err != nil {
+		return AuditPage{}, err
+	}
+	return pageAudit(s.records, f), nil
+}
+
+// postgresAuditStore keeps the trail in the audit_log table
+type postgresAuditStore struct {
+	db *sql.DB
+}
+
+func (p *postgresAuditStore) describe() string {
+	return "the audit_log table"
+}
+
+func (p *postgresAuditStore) append(records []AuditRecord) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, r := range records {
+		at, err := time.Parse(time.RFC3339Nano, r.At)
+		if err != nil {
+			return err
+		}
+		// A batch retried after a lost commit is not recorded twice
+		if _, err := tx.Exec(`
+			INSERT INTO audit_log (event_id, at, actor, source, action, employee_name, sheet, task_date, task_id, task, old_task, old_status, new_status)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT (event_id) DO NOTHING`,
+			r.EventID, at, r.Actor, r.Source, r.Action, r.EmployeeName, r.Sheet, r.Date, r.TaskID, r.Task, r.OldTask, r.OldStatus, r.NewStatus); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *postgresAuditStore) query(f AuditFilter) (AuditPage, error) {
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	fold := func(column, v string) {
+		if v = strings.TrimSpace(v); v != "" {
+			where = append(where, fmt.Sprintf("lower(%s) = lower(%s)", column, arg(v)))
+		}
+	}
+	fold("employee_name", f.EmployeeName)
+	fold("sheet", f.Sheet)
+	fold("actor", f.Actor)
+	fold("source", f.Source)
+	fold("action", f.Action)
+	if f.TaskID != "" {
+		where = append(where, "task_id = "+arg(f.TaskID))
+	}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		where = append(where, "task_date <> ''")
+	}
+	if !f.From.IsZero() {
+		where = append(where, "task_date >= "+arg(f.From.String()))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "task_date <= "+arg(f.To.String()))
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "at >= "+arg(f.Since))
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "at < "+arg(f.Until))
+	}
+	if f.Before > 0 {
+		where = append(where, "seq < "+arg(f.Before))
+	}
+
+	query := `SELECT seq, event_id, at, actor, source, action, employee_name, sheet, task_date, task_id, task, old_task, old_status, new_status FROM audit_log`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY seq DESC"
+	if f.Limit > 0 {
+		query += " LIMIT " + arg(f.Limit+1) // One more tells whether there is a next page
+	}
+
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return AuditPage{}, err
+	}
+	defer rows.Close()
+
+	page := AuditPage{}
+	for rows.Next() {
+		var r AuditRecord
+		var at time.Time
+		if err := rows.Scan(&r.Seq, &r.EventID, &at, &r.Actor, &r.Source, &r.Action, &r.EmployeeName, &r.Sheet, &r.Date, &r.TaskID, &r.Task, &r.OldTask, &r.OldStatus, &r.NewStatus); err != nil {
+			return AuditPage{}, err
+		}
+		r.At = at.UTC().Format(time.RFC3339Nano)
+		page.Records = append(page.Records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return AuditPage{}, err
+	}
+	if f.Limit > 0 && len(page.Records) > f.Limit {
+		page.Records = page.Records[:f.Limit]
+		page.NextBefore = page.Records[f.Limit-1].Seq
+	}
+	return page, nil
+}
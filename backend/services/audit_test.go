@@ -0,0 +1,157 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func auditBatch(ids ...string) []AuditRecord {
+	var out []AuditRecord
+	for _, id := range ids {
+		out = append(out, AuditRecord{EventID: id, At: "2026-10-16T09:00:00Z", Source: EventSourceAPI, Action: EventTaskCreated,
+			EmployeeName: "Ann", Sheet: "DEV", Date: "2026-10-16", TaskID: "t" + id, Task: "task " + id, NewStatus: "todo"})
+	}
+	return out
+}
+
+func TestAuditStoresSkipRecordedEvents(t *testing.T) {
+	_, client := startFakeSheets(t)
+	stores := map[string]auditStore{
+		"file":  &fileAuditStore{path: t.TempDir() + "/audit.jsonl"},
+		"sheet": &sheetAuditStore{client: client, tab: "audit"},
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			if fs, ok := store.(*fileAuditStore); ok {
+				if err := fs.load(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			steps := []struct {
+				batch []AuditRecord
+				want  []string // Event IDs in the trail afterwards, newest first
+			}{
+				{auditBatch("e1", "e2"), []string{"e2", "e1"}},
+				{auditBatch("e1", "e2"), []string{"e2", "e1"}}, // A retried batch
+				{auditBatch("e2", "e3"), []string{"e3", "e2", "e1"}},
+			}
+			for i, step := range steps {
+				if err := store.append(step.batch); err != nil {
+					t.Fatalf("step %d: append: %v", i, err)
+				}
+				page, err := store.query(AuditFilter{})
+				if err != nil {
+					t.Fatalf("step %d: query: %v", i, err)
+				}
+				var got []string
+				for _, r := range page.Records {
+					got = append(got, r.EventID)
+				}
+				if len(got) != len(step.want) {
+					t.Fatalf("step %d: trail %v, want %v", i, got, step.want)
+				}
+				for j := range got {
+					if got[j] != step.want[j] || page.Records[j].Seq != int64(len(got)-j) {
+						t.Fatalf("step %d: trail %v (seq %d at %d), want %v", i, got, page.Records[j].Seq, j, step.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAuditPagesBySeq(t *testing.T) {
+	store := &fileAuditStore{}
+	if err := store.load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.append(auditBatch("e1", "e2", "e3", "e4", "e5")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		filter     AuditFilter
+		want       []int64
+		nextBefore int64
+	}{
+		{"first page", AuditFilter{Limit: 2}, []int64{5, 4}, 4},
+		{"next page", AuditFilter{Limit: 2, Before: 4}, []int64{3, 2}, 2},
+		{"last page", AuditFilter{Limit: 2, Before: 2}, []int64{1}, 0},
+		{"filtered", AuditFilter{TaskID: "te3"}, []int64{3}, 0},
+		{"no match", AuditFilter{EmployeeName: "Bob"}, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := store.query(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, r := range page.Records {
+				got = append(got, r.Seq)
+			}
+			if len(got) != len(tt.want) || page.NextBefore != tt.nextBefore {
+				t.Fatalf("seqs %v next %d, want %v next %d", got, page.NextBefore, tt.want, tt.nextBefore)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("seqs %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSheetAuditStoresShareSeqs(t *testing.T) {
+	_, client := startFakeSheets(t)
+	a := &sheetAuditStore{client: client, tab: "audit"}
+	b := &sheetAuditStore{client: client, tab: "audit"}
+
+	// Both have read the tab before the other appends
+	for _, s := range []*sheetAuditStore{a, b} {
+		if _, err := s.query(AuditFilter{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Appending at the same time
+	var wg sync.WaitGroup
+	for _, s := range []*sheetAuditStore{a, b} {
+		wg.Add(1)
+		go func(s *sheetAuditStore) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				if err := s.append(auditBatch(fmt.Sprintf("%p-%d", s, i))); err != nil {
+					t.Error(err)
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	for name, s := range map[string]*sheetAuditStore{"a": a, "b": b, "fresh": {client: client, tab: "audit"}} {
+		var got []string
+		seen := map[int64]bool{}
+		for f := (AuditFilter{Limit: 2}); ; {
+			page, err := s.query(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range page.Records {
+				if seen[r.Seq] {
+					t.Fatalf("%s: seq %d repeated", name, r.Seq)
+				}
+				seen[r.Seq] = true
+				got = append(got, r.EventID)
+			}
+			if page.NextBefore == 0 {
+				break
+			}
+			f.Before = page.NextBefore
+		}
+		if len(got) != 20 {
+			t.Errorf("%s: paged %d records, want 20", name, len(got))
+		}
+	}
+}
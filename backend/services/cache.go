@@ -0,0 +1,388 @@
+// services/cache.go
+package services
+
+import (
+	"go-backend/config"
+	"go-backend/models"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache statuses reported to clients
+const (
+	CacheHit         = "hit"         // Served from cache within the TTL
+	CacheRevalidated = "revalidated" // TTL expired but nothing in the spreadsheet changed since the fetch
+	CacheMiss        = "miss"        // Fetched from the backend
+)
+
+// CacheInfo says where a response came from and how old its data is
+type CacheInfo struct {
+	Status    string
+	FetchedAt time.Time
+}
+
+// Age is how long ago the data was read from the backend
+func (i CacheInfo) Age() time.Duration {
+	return time.Since(i.FetchedAt)
+}
+
+// Helper: Combine the info of several entries (worst status, oldest data)
+func (i CacheInfo) merge(other CacheInfo) CacheInfo {
+	rank := map[string]int{CacheHit: 1, CacheRevalidated: 2, CacheMiss: 3}
+	if rank[other.Status] > rank[i.Status] {
+		i.Status = other.Status
+	}
+	if i.FetchedAt.IsZero() || (!other.FetchedAt.IsZero() && other.FetchedAt.Before(i.FetchedAt)) {
+		i.FetchedAt = other.FetchedAt
+	}
+	return i
+}
+
+// sheetTaskReader is implemented by backends that can read a single role sheet,
+// so invalidating one sheet does not refetch the others
+type sheetTaskReader interface {
+	GetSheetLatestTasks(sheetTitle string) ([]models.EmployeeTasksResponse, error)
+}
+
+// revisionSource is implemented by backends that can cheaply tell whether anything changed
+type revisionSource interface {
+	Revision() (string, error)
+}
+
+type cacheEntry struct {
+	value       interface{}
+	fetchedAt   time.Time
+	validatedAt time.Time
+	revision    string
+}
+
+// CachedStore is a read-through cache in front of another Store.
+// Writes invalidate only the entries they affect, and only in this process: other instances
+// keep serving what they cached until their TTL runs out.
+//
+// Revalidation is a saving, not a freshness guarantee. The revision covers the whole
+// spreadsheet, so any write bumps it, including this backend's own writes to the logs,
+// audit and teams tabs; with a busy backend most expired entries are simply refetched.
+// It never keeps an entry past a change, but staleness is still bounded by the TTL only.
+type CachedStore struct {
+	Store
+	env        *Env
+	ttl        time.Duration
+	revalidate bool
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	gen     uint64 // Bumped by every invalidation; fetches that raced one are not stored
+
+	revMu     sync.Mutex
+	rev       string
+	revReadAt time.Time
+}
+
+// How long one revision lookup is shared between concurrent reads
+const revisionReuse = time.Second
+
+// NewCachedStore wraps store, built with env, with a cache configured by cfg
+func NewCachedStore(store Store, cfg config.CacheConfig, env *Env) *CachedStore {
+	return &CachedStore{
+		Store:      store,
+		env:        env,
+		ttl:        cfg.TTL,
+		revalidate: cfg.Revalidate,
+		entries:    map[string]*cacheEntry{},
+	}
+}
+
+// Cache keys
+func employeeKey(name string) string { return "employee:" + strings.ToLower(strings.TrimSpace(name)) }
+func sheetKey(title string) string   { return "sheet:" + strings.ToLower(strings.TrimSpace(title)) }
+
+const (
+	allTasksKey = "tasks:all"
+	metadataKey = "metadata"
+	logsKey     = "logs"
+)
+
+// Helper: Read key through the cache, calling fetch on a miss
+func (c *CachedStore) get(key string, fetch func() (interface{}, error)) (interface{}, CacheInfo, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry := c.entries[key]
+	c.mu.Unlock()
+
+	if entry != nil {
+		if now.Sub(entry.validatedAt) < c.ttl {
+			return entry.value, CacheInfo{Status: CacheHit, FetchedAt: entry.fetchedAt}, nil
+		}
+		if rev := c.currentRevision(); rev != "" && rev == entry.revision {
+			c.mu.Lock()
+			if c.entries[key] == entry {
+				entry.validatedAt = now
+			}
+			c.mu.Unlock()
+			return entry.value, CacheInfo{Status: CacheRevalidated, FetchedAt: entry.fetchedAt}, nil
+		}
+	}
+
+	// Take the revision before reading so a concurrent write leaves the entry stale, not wrong
+	rev := c.currentRevision()
+	c.mu.Lock()
+	gen := c.gen
+	c.mu.Unlock()
+
+	value, err := fetch()
+	if err != nil {
+		return nil, CacheInfo{}, err
+	}
+
+	c.mu.Lock()
+	if c.gen == gen && cacheable(value) {
+		c.entries[key] = &cacheEntry{value: value, fetchedAt: now, validatedAt: now, revision: rev}
+	}
+	c.mu.Unlock()
+	return value, CacheInfo{Status: CacheMiss, FetchedAt: now}, nil
+}
+
+// Helper: Partial results are served but not stored, so the next read retries the failed sheets
+func cacheable(v interface{}) bool {
+	switch resp := v.(type) {
+	case models.EmployeeTasksResponse:
+		return !resp.Partial
+	case models.TeamTasksResponse:
+		return !resp.Partial
+	}
+	return true
+}
+
+// Helper: Current backend revision, "" when revalidation is off or unsupported
+func (c *CachedStore) currentRevision() string {
+	if !c.revalidate {
+		return ""
+	}
+	src, ok := c.Store.(revisionSource)
+	if !ok {
+		return ""
+	}
+
+	c.revMu.Lock()
+	defer c.revMu.Unlock()
+	if time.Since(c.revReadAt) < revisionReuse {
+		return c.rev
+	}
+	rev, err := src.Revision()
+	if err != nil {
+		log.Printf("cache: unable to read spreadsheet revision: %v", err)
+		rev = ""
+	}
+	c.rev, c.revReadAt = rev, time.Now()
+	return rev
+}
+
+// Helper: Drop entries
+func (c *CachedStore) invalidate(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	c.gen++
+	c.dropRevision()
+}
+
+// Helper: Forget the shared revision so the next read asks the backend (called with c.mu held)
+func (c *CachedStore) dropRevision() {
+	c.revMu.Lock()
+	c.revReadAt = time.Time{}
+	c.revMu.Unlock()
+}
+
+// InvalidateTasks drops the cached tasks of one employee and one role sheet
+func (c *CachedStore) InvalidateTasks(role, employeeName string) {
+	if sheet, err := c.env.resolveRole(role); err == nil {
+		role = sheet
+	}
+	c.invalidate(employeeKey(employeeName), sheetKey(role), allTasksKey)
+}
+
+// InvalidateAll drops every entry
+func (c *CachedStore) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]*cacheEntry{}
+	c.gen++
+	c.dropRevision()
+}
+
+// GetLatestTasksWithInfo is GetLatestTasks plus where the data came from
+func (c *CachedStore) GetLatestTasksWithInfo(employeeName string) (models.EmployeeTasksResponse, CacheInfo, error) {
+	v, info, err := c.get(employeeKey(employeeName), func() (interface{}, error) {
+		return c.Store.GetLatestTasks(employeeName)
+	})
+	if err != nil {
+		return models.EmployeeTasksResponse{}, info, err
+	}
+	return v.(models.EmployeeTasksResponse), info, nil
+}
+
+// GetAllEmployeesLatestTasksWithInfo is GetAllEmployeesLatestTasks plus where the data came from.
+// Backends that can read a single sheet are cached per sheet, and a failing sheet is reported, not cached.
+func (c *CachedStore) GetAllEmployeesLatestTasksWithInfo() (models.TeamTasksResponse, CacheInfo, error) {
+	reader, ok := c.Store.(sheetTaskReader)
+	if !ok {
+		v, info, err := c.get(allTasksKey, func() (interface{}, error) {
+			return c.Store.GetAllEmployeesLatestTasks()
+		})
+		if err != nil {
+			return models.TeamTasksResponse{}, info, err
+		}
+		return v.(models.TeamTasksResponse), info, nil
+	}
+
+	var (
+		allEmployees []models.EmployeeTasksResponse
+		info         CacheInfo
+		mu           sync.Mutex
+		wg           sync.WaitGroup
+	)
+	titles := c.env.roleSheets()
+	statuses := make([]models.SheetStatus, len(titles))
+	for i, title := range titles {
+		wg.Add(1)
+		go func(i int, title string) {
+			defer wg.Done()
+
+			v, sheetInfo, err := c.get(sheetKey(title), func() (interface{}, error) {
+				return reader.GetSheetLatestTasks(title)
+			})
+			statuses[i] = sheetStatus(title, err)
+			if err != nil {
+				log.Printf("Failed to read sheet %s: %v", title, err)
+				return
+			}
+
+			mu.Lock()
+			allEmployees = append(allEmployees, v.([]models.EmployeeTasksResponse)...)
+			info = info.merge(sheetInfo)
+			mu.Unlock()
+		}(i, title)
+	}
+	wg.Wait()
+
+	if info.Status == "" {
+		info = CacheInfo{Status: CacheMiss, FetchedAt: time.Now()}
+	}
+	team, err := newTeamTasks(allEmployees, statuses)
+	return team, info, err
+}
+
+// GetLatestTasks reads through the cache
+func (c *CachedStore) GetLatestTasks(employeeName string) (models.EmployeeTasksResponse, error) {
+	resp, _, err := c.GetLatestTasksWithInfo(employeeName)
+	return resp, err
+}
+
+// GetAllEmployeesLatestTasks reads through the cache
+func (c *CachedStore) GetAllEmployeesLatestTasks() (models.TeamTasksResponse, error) {
+	resp, _, err := c.GetAllEmployeesLatestTasksWithInfo()
+	return resp, err
+}
+
+// AddTask writes through and invalidates the employee and the target sheet
+func (c *CachedStore) AddTask(req models.TaskRequest) error {
+	defer c.InvalidateTasks(req.Role, req.EmployeeName)
+	return c.Store.AddTask(req)
+}
+
+// UpdateTask writes through and invalidates the employee and the sheet holding the task
+func (c *CachedStore) UpdateTask(id string, update TaskUpdate, actor string) (TaskLocation, error) {
+	loc, err := c.Store.UpdateTask(id, update, actor)
+	if err == nil {
+		c.InvalidateTasks(loc.Sheet, loc.EmployeeName)
+	}
+	return loc, err
+}
+
+// DeleteTask writes through and invalidates the employee and the sheet holding the task
+func (c *CachedStore) DeleteTask(id string, actor string) (TaskLocation, error) {
+	loc, err := c.Store.DeleteTask(id, actor)
+	if err == nil {
+		c.InvalidateTasks(loc.Sheet, loc.EmployeeName)
+	}
+	return loc, err
+}
+
+// UpdateCellTask writes through and invalidates the employee and the target sheet
+func (c *CachedStore) UpdateCellTask(cell CellRef, line string, update TaskUpdate) (models.DayTasks, error) {
+	defer c.InvalidateTasks(cell.Role, cell.EmployeeName)
+	return c.Store.UpdateCellTask(cell, line, update)
+}
+
+// DeleteCellTask writes through and invalidates the employee and the target sheet
+func (c *CachedStore) DeleteCellTask(cell CellRef, line string) (models.DayTasks, error) {
+	defer c.InvalidateTasks(cell.Role, cell.EmployeeName)
+	return c.Store.DeleteCellTask(cell, line)
+}
+
+// ReorderCell writes through and invalidates the employee and the target sheet
+func (c *CachedStore) ReorderCell(cell CellRef, order []string) (models.DayTasks, error) {
+	defer c.InvalidateTasks(cell.Role, cell.EmployeeName)
+	return c.Store.ReorderCell(cell, order)
+}
+
+// CarryOver writes through and invalidates the employee and the sheet carried within
+func (c *CachedStore) CarryOver(req CarryOverRequest) (CarryOverResult, error) {
+	result, err := c.Store.CarryOver(req)
+	if err == nil {
+		c.InvalidateTasks(result.Sheet, result.EmployeeName)
+	}
+	return result, err
+}
+
+// GetAllEmployeesMetadataWithInfo is GetAllEmployeesMetadata plus where the data came from
+func (c *CachedStore) GetAllEmployeesMetadataWithInfo() ([]EmployeeMetadata, CacheInfo, error) {
+	v, info, err := c.get(metadataKey, func() (interface{}, error) {
+		return c.Store.GetAllEmployeesMetadata()
+	})
+	if err != nil {
+		return nil, info, err
+	}
+	return v.([]EmployeeMetadata), info, nil
+}
+
+// GetAllEmployeesMetadata reads through the cache
+func (c *CachedStore) GetAllEmployeesMetadata() ([]EmployeeMetadata, error) {
+	resp, _, err := c.GetAllEmployeesMetadataWithInfo()
+	return resp, err
+}
+
+// UpsertEmployeeMetadata writes through and invalidates the metadata list
+func (c *CachedStore) UpsertEmployeeMetadata(name, timezone string) error {
+	defer c.invalidate(metadataKey)
+	return c.Store.UpsertEmployeeMetadata(name, timezone)
+}
+
+// GetAllDailyLogsWithInfo is GetAllDailyLogs plus where the data came from
+func (c *CachedStore) GetAllDailyLogsWithInfo() ([]DailyLog, CacheInfo, error) {
+	v, info, err := c.get(logsKey, func() (interface{}, error) {
+		return c.Store.GetAllDailyLogs()
+	})
+	if err != nil {
+		return nil, info, err
+	}
+	return v.([]DailyLog), info, nil
+}
+
+// GetAllDailyLogs reads through the cache
+func (c *CachedStore) GetAllDailyLogs() ([]DailyLog, error) {
+	resp, _, err := c.GetAllDailyLogsWithInfo()
+	return resp, err
+}
+
+// UpsertDailyLog writes through and invalidates the daily log list
+func (c *CachedStore) UpsertDailyLog(name, date string) error {
+	defer c.invalidate(logsKey)
+	return c.Store.UpsertDailyLog(name, date)
+}
@@ -0,0 +1,378 @@
+// services/calendar.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CalendarDay describes one day for one employee
+type CalendarDay struct {
+	Date     models.Date `json:"date"`
+	Working  bool        `json:"working"`
+	Weekend  bool        `json:"weekend,omitempty"`
+	Holidays []string    `json:"holidays,omitempty"` // e.g. "Diwali (IN)"
+	Leave    bool        `json:"leave,omitempty"`
+}
+
+// EmployeeCalendar is what the calendar keeps per employee
+type EmployeeCalendar struct {
+	Employee    string        `json:"employee"`
+	HolidaySets []string      `json:"holiday_sets"` // Holiday files observed; empty observes every one
+	Leave       []models.Date `json:"leave"`        // Oldest first
+}
+
+// Calendar knows which days are working days for whom: weekends, holiday sets and per-employee leave
+type Calendar struct {
+	mu        sync.RWMutex
+	weekend   map[time.Weekday]bool
+	sets      []HolidaySet
+	employees map[string]*EmployeeCalendar // By lower-cased name
+	storeFile string                       // "" keeps leave in memory only
+}
+
+// IsWorkingDay reports whether d is a working day for employee ("" for the company calendar,
+// which observes every holiday set). Without a calendar Saturday and Sunday are the days off.
+func (e *Env) IsWorkingDay(employee string, d models.Date) bool {
+	cal := e.calendar()
+	if cal == nil {
+		return d.Weekday() != time.Saturday && d.Weekday() != time.Sunday
+	}
+	return cal.Day(employee, d).Working
+}
+
+// NewCalendar loads the weekend, holiday sets and stored leave named by cfg
+func NewCalendar(cfg config.CalendarConfig) (*Calendar, error) {
+	c := &Calendar{
+		weekend:   map[time.Weekday]bool{},
+		employees: map[string]*EmployeeCalendar{},
+		storeFile: cfg.StoreFile,
+	}
+	for _, name := range cfg.Weekend {
+		d, ok := config.ParseWeekday(name)
+		if !ok {
+			return nil, fmt.Errorf("calendar.weekend: %q is not a day name", name)
+		}
+		c.weekend[d] = true
+	}
+
+	sets, err := loadHolidaySets(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.sets = sets
+
+	if c.storeFile != "" {
+		if err := c.load(); err != nil {
+			return nil, fmt.Errorf("calendar.store_file: %v", err)
+		}
+	}
+	return c, nil
+}
+
+// Helper: calendar.holidays as the "config" set, then one set per holiday file
+func loadHolidaySets(cfg config.CalendarConfig) ([]HolidaySet, error) {
+	var sets []HolidaySet
+	if len(cfg.Holidays) > 0 {
+		set := HolidaySet{Name: "config", Source: "config"}
+		for _, day := range cfg.Holidays {
+			d, err := models.ParseDate(day)
+			if err != nil {
+				return nil, fmt.Errorf("calendar.holidays: %v", err)
+			}
+			set.Holidays = append(set.Holidays, Holiday{Date: d, Name: "Holiday"})
+		}
+		sets = append(sets, set)
+	}
+	for _, sc := range cfg.HolidaySets {
+		days, err := loadHolidayFile(sc.File)
+		if err != nil {
+			return nil, fmt.Errorf("holiday set %s: %v", sc.Name, err)
+		}
+		if days == nil {
+			days = []Holiday{}
+		}
+		sets = append(sets, HolidaySet{Name: sc.Name, Source: sc.File, Holidays: days})
+	}
+	return sets, nil
+}
+
+// Reload re-reads the holiday files of the active configuration; on error the loaded sets are kept
+func (c *Calendar) Reload() ([]HolidaySet, error) {
+	sets, err := loadHolidaySets(config.Get().Calendar)
+	if err != nil {
+		return nil, newError(ErrValidation, "holidays_invalid", "%v", err)
+	}
+	c.mu.Lock()
+	c.sets = sets
+	c.mu.Unlock()
+	log.Printf("Calendar: loaded %d holiday sets", len(sets))
+	return c.HolidaySets(), nil
+}
+
+// HolidaySets returns the loaded holiday sets
+func (c *Calendar) HolidaySets() []HolidaySet {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	sets := make([]HolidaySet, len(c.sets))
+	copy(sets, c.sets)
+	return sets
+}
+
+// Day describes d for employee
+func (c *Calendar) Day(employee string, d models.Date) CalendarDay {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	day := CalendarDay{Date: d, Weekend: c.weekend[d.Weekday()]}
+	emp := c.employees[calendarKey(employee)]
+	for _, set := range c.sets {
+		// calendar.holidays apply to everyone, files only to the employees observing them
+		if set.Source != "config" && emp != nil && len(emp.HolidaySets) > 0 && !containsFold(emp.HolidaySets, set.Name) {
+			continue
+		}
+		for _, h := range set.Holidays {
+			if h.Date == d {
+				day.Holidays = append(day.Holidays, fmt.Sprintf("%s (%s)", h.Name, set.Name))
+			}
+		}
+	}
+	if emp != nil {
+		for _, l := range emp.Leave {
+			if l == d {
+				day.Leave = true
+				break
+			}
+		}
+	}
+	day.Working = !day.Weekend && len(day.Holidays) == 0 && !day.Leave
+	return day
+}
+
+// Days describes every day from from to to (inclusive) for employee
+func (c *Calendar) Days(employee string, from, to models.Date) []CalendarDay {
+	days := []CalendarDay{}
+	for d := from; !d.After(to); d = d.AddDays(1) {
+		days = append(days, c.Day(employee, d))
+	}
+	return days
+}
+
+// Employee returns what the calendar keeps for an employee (empty when nothing is kept)
+func (c *Calendar) Employee(name string) EmployeeCalendar {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if emp := c.employees[calendarKey(name)]; emp != nil {
+		return emp.clone()
+	}
+	return EmployeeCalendar{Employee: strings.TrimSpace(name), HolidaySets: []string{}, Leave: []models.Date{}}
+}
+
+// Employees returns every employee with leave or holiday sets, by name
+func (c *Calendar) Employees() []EmployeeCalendar {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := []EmployeeCalendar{}
+	for _, emp := range c.employees {
+		out = append(out, emp.clone())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Employee < out[j].Employee })
+	return out
+}
+
+// AddLeave marks the days from from to to (inclusive) as leave for an employee
+func (c *Calendar) AddLeave(name string, from, to models.Date) (EmployeeCalendar, error) {
+	if strings.TrimSpace(name) == "" {
+		return EmployeeCalendar{}, newError(ErrValidation, "validation_failed", "employee name is required")
+	}
+	if to.IsZero() {
+		to = from
+	}
+	if from.IsZero() || to.Before(from) {
+		return EmployeeCalendar{}, newError(ErrValidation, "validation_failed", "leave needs a from date on or before its to date")
+	}
+	if from.DaysUntil(to) > 366 {
+		return EmployeeCalendar{}, newError(ErrValidation, "validation_failed", "leave can span at most a year at a time")
+	}
+
+	return c.update(name, func(emp *EmployeeCalendar) error {
+		have := map[models.Date]bool{}
+		for _, d := range emp.Leave {
+			have[d] = true
+		}
+		for d := from; !d.After(to); d = d.AddDays(1) {
+			if !have[d] {
+				emp.Leave = append(emp.Leave, d)
+			}
+		}
+		sort.Slice(emp.Leave, func(i, j int) bool { return emp.Leave[i].Before(emp.Leave[j]) })
+		return nil
+	})
+}
+
+// RemoveLeave takes one day of leave back
+func (c *Calendar) RemoveLeave(name string, d models.Date) (EmployeeCalendar, error) {
+	return c.update(name, func(emp *EmployeeCalendar) error {
+		for i, l := range emp.Leave {
+			if l == d {
+				emp.Leave = append(emp.Leave[:i], emp.Leave[i+1:]...)
+				return nil
+			}
+		}
+		return newError(ErrNotFound, "leave_not_found", "%s has no leave on %s", emp.Employee, d)
+	})
+}
+
+// SetHolidaySets chooses which holiday sets an employee observes; none observes every set
+func (c *Calendar) SetHolidaySets(name string, sets []string) (EmployeeCalendar, error) {
+	known := map[string]string{}
+	for _, set := range c.HolidaySets() {
+		known[strings.ToLower(set.Name)] = set.Name
+	}
+	var chosen []string
+	for _, s := range sets {
+		set, ok := known[strings.ToLower(strings.TrimSpace(s))]
+		if !ok {
+			return EmployeeCalendar{}, newError(ErrValidation, "unknown_holiday_set", "holiday set '%s' is not loaded", s)
+		}
+		if !containsFold(chosen, set) {
+			chosen = append(chosen, set)
+		}
+	}
+
+	return c.update(name, func(emp *EmployeeCalendar) error {
+		emp.HolidaySets = chosen
+		return nil
+	})
+}
+
+// Helper: Apply fn to an employee's record under the lock and persist the result
+func (c *Calendar) update(name string, fn func(emp *EmployeeCalendar) error) (EmployeeCalendar, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := calendarKey(name)
+	emp, existed := c.employees[key]
+	if !existed {
+		emp = &EmployeeCalendar{Employee: strings.TrimSpace(name)}
+	}
+	before := emp.clone()
+	if err := fn(emp); err != nil {
+		return EmployeeCalendar{}, err
+	}
+	c.employees[key] = emp
+
+	if err := c.save(); err != nil {
+		// Keep memory and file in step
+		if existed {
+			*emp = before
+		} else {
+			delete(c.employees, key)
+		}
+		return EmployeeCalendar{}, fmt.Errorf("failed to save calendar: %v", err)
+	}
+	return emp.clone(), nil
+}
+
+// Helper: Read the store file; a missing file is an empty calendar
+func (c *Calendar) load() error {
+	data, err := os.ReadFile(c.storeFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var stored []EmployeeCalendar
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	for i := range stored {
+		c.employees[calendarKey(stored[i].Employee)] = &stored[i]
+	}
+	return nil
+}
+
+// Helper: Write the store file (caller holds mu); the rename keeps it whole if we crash midway
+func (c *Calendar) save() error {
+	if c.storeFile == "" {
+		return nil
+	}
+	stored := []EmployeeCalendar{}
+	for _, emp := range c.employees {
+		stored = append(stored, emp.clone())
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].Employee < stored[j].Employee })
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.storeFile), ".calendar-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.storeFile)
+}
+
+// Helper: Deep copy, with empty lists rather than nil for JSON
+func (e *EmployeeCalendar) clone() EmployeeCalendar {
+	return EmployeeCalendar{
+		Employee:    e.Employee,
+		HolidaySets: append([]string{}, e.HolidaySets...),
+		Leave:       append([]models.Date{}, e.Leave...),
+	}
+}
+
+// Helper: Calendar lookup key of an employee name
+func calendarKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Helper: Case-insensitive membership
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// latestDays is how many working days of history the team view shows per employee;
+// entries on the employee's days off are shown on top of them
+const latestDays = 7
+
+// Helper: Whether a day counts toward latestDays (columns that are not dates do)
+func (e *Env) countsTowardLatest(employee string, d models.Date) bool {
+	return d.IsZero() || e.IsWorkingDay(employee, d)
+}
+
+// Helper: How many entries of hist count toward latestDays
+func (e *Env) latestCount(employee string, hist []models.DayTasks) int {
+	n := 0
+	for _, day := range hist {
+		if e.countsTowardLatest(employee, day.Date) {
+			n++
+		}
+	}
+	return n
+}
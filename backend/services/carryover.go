@@ -0,0 +1,197 @@
+// services/carryover.go
+package services
+
+import (
+	"fmt"
+	"go-backend/models"
+	"strings"
+	"time"
+)
+
+// CarryOverRequest asks for an employee's unfinished tasks to be copied into a day
+type CarryOverRequest struct {
+	EmployeeName string      `json:"employee_name"`
+	Role         string      `json:"role"` // Sheet to carry within; the employee's sheet when empty
+	Date         models.Date `json:"date"` // Day to carry into; the employee's today when unset
+	Actor        string      `json:"-"`    // Who is writing: the bearer token owner or the X-Actor header
+}
+
+// CarryOverResult is what one carry-over copied into an employee's day
+type CarryOverResult struct {
+	EmployeeName string            `json:"employee_name"`
+	Sheet        string            `json:"sheet"`
+	From         models.Date       `json:"from"` // Last populated day before To; null when there is none
+	To           models.Date       `json:"to"`
+	Carried      []models.TaskItem `json:"carried"`
+	Skipped      []models.TaskItem `json:"skipped"` // Unfinished tasks To already holds
+}
+
+// CarryOverReport summarizes one carry-over pass over every employee
+type CarryOverReport struct {
+	StartedAt  string            `json:"started_at"`
+	Duration   string            `json:"duration"`
+	Results    []CarryOverResult `json:"results"` // Employees something was carried for
+	Errors     []string          `json:"errors,omitempty"`
+	FatalError string            `json:"fatal_error,omitempty"`
+}
+
+// Helper: Carry an employee's unfinished tasks over, reading their history first
+func (e *Env) carryOver(s taskCells, req CarryOverRequest) (CarryOverResult, error) {
+	if strings.TrimSpace(req.EmployeeName) == "" {
+		return CarryOverResult{}, newError(ErrValidation, "validation_failed", "employee_name is required")
+	}
+	emp, err := s.GetLatestTasks(req.EmployeeName)
+	if err != nil {
+		return CarryOverResult{}, err
+	}
+	return e.carryOverFrom(s, req, emp)
+}
+
+// Helper: Copy the todo and pending lines of the last populated day in emp's history into the target day.
+// Carried lines keep their task ID, status and the day they were first carried from; lines the target
+// day already holds (same ID or text) are skipped.
+func (e *Env) carryOverFrom(s taskCells, req CarryOverRequest, emp models.EmployeeTasksResponse) (CarryOverResult, error) {
+	if req.Role == "" {
+		req.Role = emp.SheetName
+	}
+	loc, err := e.locateCell(s, CellRef{EmployeeName: req.EmployeeName, Role: req.Role, Date: req.Date, Actor: req.Actor})
+	if err != nil {
+		return CarryOverResult{}, err
+	}
+	result := CarryOverResult{
+		EmployeeName: req.EmployeeName,
+		Sheet:        loc.Sheet,
+		To:           loc.Date,
+		Carried:      []models.TaskItem{},
+		Skipped:      []models.TaskItem{},
+	}
+
+	source, ok := lastPopulatedDay(emp.History, loc.Date)
+	if !ok {
+		return result, nil
+	}
+	result.From = source.Date
+
+	var unfinished []models.TaskItem
+	for _, item := range source.Tasks {
+		if item.Status == "todo" || item.Status == "pending" {
+			if item.CarriedFrom == nil {
+				from := source.Date
+				item.CarriedFrom = &from
+			}
+			unfinished = append(unfinished, item)
+		}
+	}
+	if len(unfinished) == 0 {
+		return result, nil
+	}
+
+	if err := s.ensureCell(loc); err != nil {
+		return CarryOverResult{}, err
+	}
+	err = e.editCellPublishing(s, loc, req.Actor, func(items []models.TaskItem) ([]models.TaskItem, error) {
+		result.Carried, result.Skipped = []models.TaskItem{}, []models.TaskItem{}
+		for _, item := range unfinished {
+			if holdsTask(items, item) {
+				result.Skipped = append(result.Skipped, item)
+				continue
+			}
+			items = append(items, item)
+			result.Carried = append(result.Carried, item)
+		}
+		return items, nil
+	})
+	if err != nil {
+		return CarryOverResult{}, err
+	}
+	return result, nil
+}
+
+// Helper: The latest dated day before d that has any task lines
+func lastPopulatedDay(history []models.DayTasks, d models.Date) (models.DayTasks, bool) {
+	var last models.DayTasks
+	found := false
+	for _, day := range history {
+		if day.Date.IsZero() || !day.Date.Before(d) || len(day.Tasks) == 0 {
+			continue
+		}
+		if !found || last.Date.Before(day.Date) {
+			last, found = day, true
+		}
+	}
+	return last, found
+}
+
+// Helper: Whether a cell already holds a task, by ID or by text
+func holdsTask(items []models.TaskItem, task models.TaskItem) bool {
+	for _, item := range items {
+		if item.ID == task.ID || strings.EqualFold(item.Task, task.Task) {
+			return true
+		}
+	}
+	return false
+}
+
+// CarryOver copies every employee's unfinished tasks into their today; the scheduler runs it as a job.
+// A pass carries into each employee's working days only, and into a day at most once,
+// so lines removed after a carry-over stay removed.
+type CarryOver struct {
+	store    Store
+	env      *Env
+	onChange func(role, employeeName string) // Called after tasks were carried into a cell
+}
+
+// NewCarryOver returns a CarryOver writing through store, on the working days of env's calendar
+func NewCarryOver(store Store, env *Env) *CarryOver {
+	return &CarryOver{store: store, env: env}
+}
+
+// SetOnChange registers fn to be called whenever tasks are carried into an employee's day
+func (c *CarryOver) SetOnChange(fn func(role, employeeName string)) {
+	c.onChange = fn
+}
+
+// RunOnce carries every employee's unfinished tasks into their today, skipping days off and days
+// already carried into. carried maps each employee (lower-cased) to the last day carried into
+// ("2006-01-02") and is updated; the scheduler keeps it between runs.
+func (c *CarryOver) RunOnce(carried map[string]string) (report CarryOverReport, err error) {
+	started := time.Now()
+	report = CarryOverReport{StartedAt: started.Format(time.RFC3339), Results: []CarryOverResult{}}
+	defer func() {
+		report.Duration = time.Since(started).String()
+	}()
+
+	cells, ok := c.store.(taskCells)
+	if !ok {
+		err = fmt.Errorf("carry-over is not supported by this storage backend")
+		report.FatalError = err.Error()
+		return report, err
+	}
+	team, err := c.store.GetAllEmployeesLatestTasks()
+	if err != nil {
+		report.FatalError = err.Error()
+		return report, err
+	}
+
+	for _, emp := range team.Employees {
+		key := strings.ToLower(strings.TrimSpace(emp.EmployeeName))
+		today := c.env.EmployeeToday(c.store, emp.EmployeeName)
+		if carried[key] == today.String() || !c.env.IsWorkingDay(emp.EmployeeName, today) {
+			continue
+		}
+
+		result, err := c.env.carryOverFrom(cells, CarryOverRequest{EmployeeName: emp.EmployeeName, Role: emp.SheetName, Date: today}, emp)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", emp.EmployeeName, err))
+			continue
+		}
+		carried[key] = today.String()
+		if len(result.Carried) > 0 {
+			report.Results = append(report.Results, result)
+			if c.onChange != nil {
+				c.onChange(result.Sheet, result.EmployeeName)
+			}
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"go-backend/models"
+	"strings"
+	"testing"
+)
+
+// failingCells is a MemoryStore whose reads of one employee's cells fail, as a Sheets read would
+type failingCells struct {
+	*MemoryStore
+	employee string
+}
+
+var errReadFailed = errors.New("read failed")
+
+func (f *failingCells) editCell(loc TaskLocation, fn func(items []models.TaskItem) ([]models.TaskItem, error)) error {
+	if strings.EqualFold(loc.EmployeeName, f.employee) {
+		return errReadFailed
+	}
+	return f.MemoryStore.editCell(loc, fn)
+}
+
+func TestRunOnceLeavesFailedEmployeeUnmarked(t *testing.T) {
+	env := everyDayWorking(t)
+	store := &failingCells{MemoryStore: NewMemoryStore(env), employee: "Ann"}
+	today := env.EmployeeToday(store, "Ann")
+	for _, name := range []string{"Ann", "Bob"} {
+		req := models.TaskRequest{EmployeeName: name, Date: today.AddDays(-1), Tasks: []models.TaskItem{{Task: "write docs", Status: "todo"}}}
+		if err := store.AddTask(req); err != nil {
+			t.Fatalf("seeding %s: %v", name, err)
+		}
+	}
+	carry := NewCarryOver(store, env)
+	carried := map[string]string{}
+
+	report, err := carry.RunOnce(carried)
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if _, ok := carried["ann"]; ok {
+		t.Errorf("ann marked carried after a failed read: %v", carried)
+	}
+	if carried["bob"] != today.String() {
+		t.Errorf("carried[bob] = %q, want %q", carried["bob"], today)
+	}
+	if len(report.Errors) != 1 || !strings.HasPrefix(report.Errors[0], "Ann: ") {
+		t.Errorf("Errors = %q, want one error for Ann", report.Errors)
+	}
+
+	// The next pass retries Ann only
+	store.employee = ""
+	report, err = carry.RunOnce(carried)
+	if err != nil {
+		t.Fatalf("second RunOnce: %v", err)
+	}
+	if carried["ann"] != today.String() {
+		t.Errorf("carried[ann] = %q after the retry, want %q", carried["ann"], today)
+	}
+	if len(report.Results) != 1 || report.Results[0].EmployeeName != "Ann" || len(report.Results[0].Carried) != 1 {
+		t.Errorf("Results = %+v, want one task carried for Ann", report.Results)
+	}
+}
+
+func TestCarryOverFrom(t *testing.T) {
+	env := everyDayWorking(t)
+	line := func(id, task, status string) models.TaskItem {
+		return models.TaskItem{ID: id, Task: task, Status: status}
+	}
+
+	tests := []struct {
+		name        string
+		history     map[int][]models.TaskItem // Days relative to today
+		today       []models.TaskItem         // Already in today's cell
+		wantFrom    int                       // Relative to today; 0 when nothing is carried from
+		wantCarried []string
+		wantSkipped []string
+	}{
+		{
+			name:    "nothing before today",
+			history: map[int][]models.TaskItem{0: {line("t1", "a", "todo")}},
+		},
+		{
+			name:        "unfinished lines of the last populated day",
+			history:     map[int][]models.TaskItem{-3: {line("t1", "old", "todo")}, -2: {line("t2", "a", "todo"), line("t3", "b", "complete"), line("t4", "c", "pending")}},
+			wantFrom:    -2,
+			wantCarried: []string{"a", "c"},
+		},
+		{
+			name:     "last populated day all done",
+			history:  map[int][]models.TaskItem{-2: {line("t1", "a", "todo")}, -1: {line("t2", "b", "complete")}},
+			wantFrom: -1,
+		},
+		{
+			name:        "empty days in between",
+			history:     map[int][]models.TaskItem{-4: {line("t1", "a", "todo")}, -1: nil},
+			wantFrom:    -4,
+			wantCarried: []string{"a"},
+		},
+		{
+			name:        "already in today by text",
+			history:     map[int][]models.TaskItem{-1: {line("t1", "Write docs", "todo"), line("t2", "b", "todo")}},
+			today:       []models.TaskItem{{Task: "write docs", Status: "complete"}},
+			wantFrom:    -1,
+			wantCarried: []string{"b"},
+			wantSkipped: []string{"Write docs"},
+		},
+		{
+			name:        "future days ignored",
+			history:     map[int][]models.TaskItem{-1: {line("t1", "a", "todo")}, 1: {line("t2", "b", "todo")}},
+			wantFrom:    -1,
+			wantCarried: []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore(env)
+			today := env.EmployeeToday(store, "Ann")
+			if err := store.AddTask(models.TaskRequest{EmployeeName: "Ann", Date: today, Tasks: tt.today}); err != nil {
+				t.Fatal(err)
+			}
+			emp := models.EmployeeTasksResponse{EmployeeName: "Ann", SheetName: "DEV"}
+			for offset, items := range tt.history {
+				d := today.AddDays(offset)
+				emp.History = append(emp.History, newDayTasks(d, d.Header(), items))
+			}
+
+			result, err := env.carryOverFrom(store, CarryOverRequest{EmployeeName: "Ann"}, emp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			texts := func(items []models.TaskItem) []string {
+				var out []string
+				for _, item := range items {
+					out = append(out, item.Task)
+				}
+				return out
+			}
+			if got, want := strings.Join(texts(result.Carried), ","), strings.Join(tt.wantCarried, ","); got != want {
+				t.Errorf("carried %q, want %q", got, want)
+			}
+			if got, want := strings.Join(texts(result.Skipped), ","), strings.Join(tt.wantSkipped, ","); got != want {
+				t.Errorf("skipped %q, want %q", got, want)
+			}
+			if result.To != today {
+				t.Errorf("to %s, want %s", result.To, today)
+			}
+			if tt.wantFrom != 0 && result.From != today.AddDays(tt.wantFrom) {
+				t.Errorf("from %s, want %s", result.From, today.AddDays(tt.wantFrom))
+			}
+			for _, item := range result.Carried {
+				if item.CarriedFrom == nil || *item.CarriedFrom != result.From || item.ID == "" {
+					t.Errorf("carried %+v, want its ID kept and carried_from %s", item, result.From)
+				}
+			}
+		})
+	}
+}
+
+func TestCarryOverKeepsFirstCarriedFromAndSkipsByID(t *testing.T) {
+	env := everyDayWorking(t)
+	store := NewMemoryStore(env)
+	today := env.EmployeeToday(store, "Ann")
+	if err := store.AddTask(models.TaskRequest{EmployeeName: "Ann", Date: today, Tasks: []models.TaskItem{{Task: "renamed today", Status: "todo"}}}); err != nil {
+		t.Fatal(err)
+	}
+	emp, err := store.GetLatestTasks("Ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameID := emp.History[0].Tasks[0].ID
+	first := today.AddDays(-5)
+	yesterday := today.AddDays(-1)
+	emp.History = append(emp.History, newDayTasks(yesterday, yesterday.Header(), []models.TaskItem{
+		{ID: sameID, Task: "old name", Status: "todo"},
+		{ID: "tx", Task: "long runner", Status: "pending", CarriedFrom: &first},
+	}))
+
+	result, err := env.carryOverFrom(store, CarryOverRequest{EmployeeName: "Ann"}, emp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].ID != sameID {
+		t.Errorf("skipped %+v, want the line with the ID today holds", result.Skipped)
+	}
+	if len(result.Carried) != 1 || result.Carried[0].ID != "tx" || *result.Carried[0].CarriedFrom != first ||
+		result.Carried[0].Status != "pending" {
+		t.Errorf("carried %+v, want tx still pending and carried from %s", result.Carried, first)
+	}
+}
@@ -15,27 +15,31 @@ type EmployeeMetadata struct {
 	EmployeeName string  `json:"employee_name"`
 	CreatedAt    string  `json:"created_at"`
 	UpdatedAt    *string `json:"updated_at"`
+	Timezone     string  `json:"timezone"` // IANA zone, e.g. "Asia/Kolkata"; "" uses the server's
 }
 
 type DailyLog struct {
 	EmployeeName string `json:"employee_name"`
 	TaskDate     string `json:"task_date"`
-	CreatedAt    string `json:"created_at"`
+	CreatedAt    string `json:"created_at"` // In the employee's zone
 	UpdatedAt    string `json:"updated_at"`
+	Timezone     string `json:"timezone"`
+	CreatedAtUTC string `json:"created_at_utc"`
+	UpdatedAtUTC string `json:"updated_at_utc"`
 }
 
 // Mutex to handle concurrency for our "Sheet DB"
 var dbMutex sync.Mutex
 
 // GetAllEmployeesMetadata reads from "database" sheet
-func GetAllEmployeesMetadata() ([]EmployeeMetadata, error) {
-	srv, err := config.GetSheetsService()
+func (s *SheetsStore) GetAllEmployeesMetadata() ([]EmployeeMetadata, error) {
+	srv, err := s.client.Service()
 	if err != nil {
 		return nil, err
 	}
 
 	// Read all data skipping header
-	resp, err := srv.Spreadsheets.Values.Get(config.SpreadsheetID, fmt.Sprintf("'%s'!A2:E", config.SheetDBEmployees)).Do()
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A2:E", config.SheetDBEmployees)).Do()
 	if err != nil {
 		return nil, err
 	}
@@ -44,7 +48,7 @@ func GetAllEmployeesMetadata() ([]EmployeeMetadata, error) {
 	for i, row := range resp.Values {
 		// Expecting: Name, Created, Updated (Simplified schema)
 		// Old schema was: Name(A), ID(B), Project(C), Created(D), Updated(E)
-		// New schema will be: Name(A), Created(B), Updated(C)
+		// New schema will be: Name(A), Created(B), Updated(C), Timezone(D)
 		
 		if len(row) < 1 {
 			continue
@@ -61,52 +65,66 @@ func GetAllEmployeesMetadata() ([]EmployeeMetadata, error) {
 			upd := fmt.Sprintf("%v", row[2])
 			emp.UpdatedAt = &upd
 		}
+		if len(row) > 3 {
+			emp.Timezone = fmt.Sprintf("%v", row[3])
+		}
 		employees = append(employees, emp)
 	}
 	return employees, nil
 }
 
 // GetAllDailyLogs reads from "database_logs" sheet
-func GetAllDailyLogs() ([]DailyLog, error) {
-	srv, err := config.GetSheetsService()
+func (s *SheetsStore) GetAllDailyLogs() ([]DailyLog, error) {
+	srv, err := s.client.Service()
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := srv.Spreadsheets.Values.Get(config.SpreadsheetID, fmt.Sprintf("'%s'!A2:D", config.SheetDBLogs)).Do()
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A2:G", config.SheetDBLogs)).Do()
 	if err != nil {
 		return nil, err
 	}
 
 	logs := []DailyLog{}
 	for _, row := range resp.Values {
-		// Expecting: Name, Date, Created, Updated
+		// Expecting: Name, Date, Created, Updated, then Timezone, Created UTC, Updated UTC (rows since time zones)
 		if len(row) < 4 {
 			continue
 		}
-		logs = append(logs, DailyLog{
+		l := DailyLog{
 			EmployeeName: fmt.Sprintf("%v", row[0]),
 			TaskDate:     fmt.Sprintf("%v", row[1]),
 			CreatedAt:    fmt.Sprintf("%v", row[2]),
 			UpdatedAt:    fmt.Sprintf("%v", row[3]),
-		})
+		}
+		if len(row) > 6 {
+			l.Timezone = fmt.Sprintf("%v", row[4])
+			l.CreatedAtUTC = fmt.Sprintf("%v", row[5])
+			l.UpdatedAtUTC = fmt.Sprintf("%v", row[6])
+		}
+		logs = append(logs, l)
 	}
 	return logs, nil
 }
 
 // UpsertEmployeeMetadata updates or inserts employee info in "database" sheet
-func UpsertEmployeeMetadata(name string) error {
+func (s *SheetsStore) UpsertEmployeeMetadata(name, timezone string) error {
+	if err := ValidateTimezone(timezone); err != nil {
+		return err
+	}
+	defer s.env.forgetEmployeeZones()
+
 	dbMutex.Lock()
 	defer dbMutex.Unlock()
 
-	srv, err := config.GetSheetsService()
+	srv, err := s.client.Service()
 	if err != nil {
 		return err
 	}
 
 	// 1. Read existing data to check for duplicates
-	readRange := fmt.Sprintf("'%s'!A:A", config.SheetDBEmployees)
-	resp, err := srv.Spreadsheets.Values.Get(config.SpreadsheetID, readRange).Do()
+	readRange := fmt.Sprintf("'%s'!A:D", config.SheetDBEmployees)
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, readRange).Do()
 	if err != nil {
 		return err
 	}
@@ -126,38 +144,47 @@ func UpsertEmployeeMetadata(name string) error {
 
 	if rowIndex != -1 {
 		// UPDATE existing row
-		// New Schema: Name(A), Created(B), Updated(C)
-		// We only need to update Updated(C). Name and Created persist.
-		
-		tsRange := fmt.Sprintf("'%s'!C%d", config.SheetDBEmployees, rowIndex+1)
-		vrTs := &sheets.ValueRange{Values: [][]interface{}{{now}}}
-		_, err = srv.Spreadsheets.Values.Update(config.SpreadsheetID, tsRange, vrTs).ValueInputOption("RAW").Do()
+		// New Schema: Name(A), Created(B), Updated(C), Timezone(D)
+		// We only need to update Updated(C) and Timezone(D). Name and Created persist.
+		if timezone == "" && len(resp.Values[rowIndex]) > 3 {
+			timezone = fmt.Sprintf("%v", resp.Values[rowIndex][3])
+		}
+
+		tsRange := fmt.Sprintf("'%s'!C%d:D%d", config.SheetDBEmployees, rowIndex+1, rowIndex+1)
+		vrTs := &sheets.ValueRange{Values: [][]interface{}{{now, timezone}}}
+		_, err = srv.Spreadsheets.Values.Update(config.Get().SpreadsheetID, tsRange, vrTs).ValueInputOption("RAW").Do()
 		return err
 
 	} else {
 		// INSERT new row
-		// Name, Created, Updated
+		// Name, Created, Updated, Timezone
 		vr := &sheets.ValueRange{
-			Values: [][]interface{}{{cleanName, now, now}},
+			Values: [][]interface{}{{cleanName, now, now, timezone}},
 		}
-		_, err = srv.Spreadsheets.Values.Append(config.SpreadsheetID, fmt.Sprintf("'%s'!A:A", config.SheetDBEmployees), vr).ValueInputOption("RAW").Do()
-		return err
+		_, err = srv.Spreadsheets.Values.Append(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A:A", config.SheetDBEmployees), vr).ValueInputOption("RAW").Do()
+		if err != nil {
+			return err
+		}
+		s.env.publishEmployeeCreated(cleanName)
+		return nil
 	}
 }
 
 // UpsertDailyLog updates or inserts log in "database_logs" sheet
-func UpsertDailyLog(name, date string) error {
+func (s *SheetsStore) UpsertDailyLog(name, date string) error {
+	now, nowUTC, zone := s.env.logTimestamps(s, name)
+
 	dbMutex.Lock()
 	defer dbMutex.Unlock()
 
-	srv, err := config.GetSheetsService()
+	srv, err := s.client.Service()
 	if err != nil {
 		return err
 	}
 
-	// 1. Read Name(A) and Date(B) columns
-	readRange := fmt.Sprintf("'%s'!A:B", config.SheetDBLogs)
-	resp, err := srv.Spreadsheets.Values.Get(config.SpreadsheetID, readRange).Do()
+	// 1. Read Name(A) and Date(B) columns, and Timezone(E) and Created UTC(F) to write back
+	readRange := fmt.Sprintf("'%s'!A:G", config.SheetDBLogs)
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, readRange).Do()
 	if err != nil {
 		return err
 	}
@@ -179,43 +206,47 @@ func UpsertDailyLog(name, date string) error {
 		}
 	}
 
-	now := time.Now().Format(time.RFC3339)
-
 	if rowIndex != -1 {
 		// UPDATE existing row
-		// Cols: Name(A), Date(B), Created(C), Updated(D)
-		// Only update Updated(D). created_at MUST persist.
-		
-		updateRange := fmt.Sprintf("'%s'!D%d", config.SheetDBLogs, rowIndex+1)
+		// Cols: Name(A), Date(B), Created(C), Updated(D), Timezone(E), Created UTC(F), Updated UTC(G)
+		// Only update Updated(D) and Updated UTC(G). created_at MUST persist.
+		row := resp.Values[rowIndex]
+		createdUTC := ""
+		if len(row) > 5 {
+			zone = fmt.Sprintf("%v", row[4])
+			createdUTC = fmt.Sprintf("%v", row[5])
+		}
+
+		updateRange := fmt.Sprintf("'%s'!D%d:G%d", config.SheetDBLogs, rowIndex+1, rowIndex+1)
 		vr := &sheets.ValueRange{
-			Values: [][]interface{}{{now}},
+			Values: [][]interface{}{{now, zone, createdUTC, nowUTC}},
 		}
-		_, err = srv.Spreadsheets.Values.Update(config.SpreadsheetID, updateRange, vr).ValueInputOption("RAW").Do()
+		_, err = srv.Spreadsheets.Values.Update(config.Get().SpreadsheetID, updateRange, vr).ValueInputOption("RAW").Do()
 		return err
 
 	} else {
 		// INSERT new row
-		// Name, Date, Created, Updated
+		// Name, Date, Created, Updated, Timezone, Created UTC, Updated UTC
 		vr := &sheets.ValueRange{
-			Values: [][]interface{}{{cleanName, cleanDate, now, now}},
+			Values: [][]interface{}{{cleanName, cleanDate, now, now, zone, nowUTC, nowUTC}},
 		}
-		_, err = srv.Spreadsheets.Values.Append(config.SpreadsheetID, fmt.Sprintf("'%s'!A:A", config.SheetDBLogs), vr).ValueInputOption("RAW").Do()
+		_, err = srv.Spreadsheets.Values.Append(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A:A", config.SheetDBLogs), vr).ValueInputOption("RAW").Do()
 		return err
 	}
 }
 
 // UpdateTimestamp updates only the updated_at field for an employee in "database"
-func UpdateTimestamp(name string) error {
+func (s *SheetsStore) UpdateTimestamp(name string) error {
 	dbMutex.Lock()
 	defer dbMutex.Unlock()
 
-	srv, err := config.GetSheetsService()
+	srv, err := s.client.Service()
 	if err != nil {
 		return err
 	}
 
 	// Read Names
-	resp, err := srv.Spreadsheets.Values.Get(config.SpreadsheetID, fmt.Sprintf("'%s'!A:A", config.SheetDBEmployees)).Do()
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A:A", config.SheetDBEmployees)).Do()
 	if err != nil { return err }
 
 	cleanName := strings.TrimSpace(name)
@@ -233,7 +264,7 @@ func UpdateTimestamp(name string) error {
 		// Updated At is now Column C (index 3) in new schema
 		tsRange := fmt.Sprintf("'%s'!C%d", config.SheetDBEmployees, rowIndex+1)
 		vr := &sheets.ValueRange{Values: [][]interface{}{{now}}}
-		_, err = srv.Spreadsheets.Values.Update(config.SpreadsheetID, tsRange, vr).ValueInputOption("RAW").Do()
+		_, err = srv.Spreadsheets.Values.Update(config.Get().SpreadsheetID, tsRange, vr).ValueInputOption("RAW").Do()
 		return err
 	}
 	
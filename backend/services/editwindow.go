@@ -0,0 +1,224 @@
+// services/editwindow.go
+package services
+
+import (
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"log"
+	"strings"
+	"time"
+)
+
+// EditRequest asks the edit-window policy whether a write may happen
+type EditRequest struct {
+	Sheet    string      // Role sheet written to; empty means the default role
+	Employee string      // Whose cell is written
+	Date     models.Date // Day written; zero means the employee's today
+	Actor    string      // Who is writing (bearer token owner or X-Actor header), empty when unknown
+	Timezone string      // Employee's IANA zone; empty uses the rule's zone
+}
+
+// Edit-window decision reasons
+const (
+	EditWithinWindow    = "within_window"
+	EditGracePeriod     = "grace_period"
+	EditManagerOverride = "manager_override"
+	EditBeforeWindow    = "before_window"
+	EditFutureDate      = "future_date"
+)
+
+// EditDecision is the policy's verdict and the facts it was based on
+type EditDecision struct {
+	Allowed     bool        `json:"allowed"`
+	Reason      string      `json:"reason"` // One of the Edit* reasons above
+	Message     string      `json:"message,omitempty"`
+	Rule        string      `json:"rule"` // Sheet whose rule applied, or "default"
+	Date        models.Date `json:"date"`
+	Today       models.Date `json:"today"`  // In Timezone
+	Oldest      models.Date `json:"oldest"` // Earliest day this actor may write right now
+	Timezone    string      `json:"timezone"`
+	DaysBack    int         `json:"days_back"`
+	WorkingDays bool        `json:"working_days"`
+	Grace       string      `json:"grace,omitempty"`
+	Actor       string      `json:"actor,omitempty"`
+	Manager     bool        `json:"manager"`
+}
+
+// EvaluateEdit decides whether req falls inside the edit window of its sheet, as of now
+func (e *Env) EvaluateEdit(req EditRequest) EditDecision {
+	return e.evaluateEdit(req, time.Now())
+}
+
+// Helper: The rule for a role and the name it is reported under: the team's own setting,
+// then edit_window.sheets for its sheet, then the default rule
+func (e *Env) editRule(role string) (config.EditWindowRule, string) {
+	if rule, team, ok := e.teamEditRule(role); ok {
+		return rule, team
+	}
+	sheet := role
+	if resolved, err := e.resolveRole(role); err == nil {
+		sheet = resolved
+	}
+
+	cfg := config.Get().EditWindow
+	for title, rule := range cfg.Sheets {
+		if strings.EqualFold(strings.TrimSpace(title), strings.TrimSpace(sheet)) {
+			return rule, title
+		}
+	}
+	return cfg.EditWindowRule, "default"
+}
+
+// Helper: Zone "today" is computed in: the employee's, then the rule's, then the server's
+func editLocation(employeeZone, ruleZone string) *time.Location {
+	for _, name := range []string{employeeZone, ruleZone} {
+		if name == "" {
+			continue
+		}
+		loc, err := time.LoadLocation(name)
+		if err == nil {
+			return loc
+		}
+		log.Printf("Edit window: ignoring unknown time zone %q: %v", name, err)
+	}
+	return time.Local
+}
+
+// IsManager reports whether actor is listed in edit_window.managers. Callers must have
+// authenticated the actor first: the handlers turn away manager names sent without a token.
+func IsManager(actor string) bool {
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		return false
+	}
+	for _, m := range config.Get().EditWindow.Managers {
+		if strings.EqualFold(strings.TrimSpace(m), actor) {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper: First day of a window reaching daysBack days (or working days) before today
+func (e *Env) windowStart(employee string, today models.Date, daysBack int, workingDays bool) models.Date {
+	if !workingDays {
+		return today.AddDays(-daysBack)
+	}
+	d := today
+	// Bounded so a calendar of nothing but holidays cannot loop forever
+	for counted, steps := 0, 0; counted < daysBack && steps < 3660; steps++ {
+		d = d.AddDays(-1)
+		if e.IsWorkingDay(employee, d) {
+			counted++
+		}
+	}
+	return d
+}
+
+// editWindow is one way a write can be allowed: on or after oldest, for reason
+type editWindow struct {
+	oldest models.Date
+	reason string
+}
+
+func (e *Env) evaluateEdit(req EditRequest, now time.Time) EditDecision {
+	sheet := req.Sheet
+	if sheet == "" {
+		sheet = config.Get().DefaultRole
+	}
+	rule, ruleName := e.editRule(sheet)
+	loc := editLocation(req.Timezone, rule.Timezone)
+
+	local := now.In(loc)
+	today := models.DateOf(local)
+	d := EditDecision{
+		Rule:        ruleName,
+		Date:        req.Date,
+		Today:       today,
+		Timezone:    loc.String(),
+		DaysBack:    rule.DaysBack,
+		WorkingDays: rule.WorkingDays,
+		Actor:       req.Actor,
+		Manager:     IsManager(req.Actor),
+	}
+	if rule.Grace > 0 {
+		d.Grace = rule.Grace.String()
+	}
+	if d.Date.IsZero() {
+		d.Date = today
+	}
+
+	// Candidate windows, narrowest first; the first one containing the date allows it
+	d.Oldest = e.windowStart(req.Employee, today, rule.DaysBack, rule.WorkingDays)
+	windows := []editWindow{{d.Oldest, EditWithinWindow}}
+
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if rule.Grace > 0 && local.Sub(midnight) < rule.Grace {
+		oldest := e.windowStart(req.Employee, today.AddDays(-1), rule.DaysBack, rule.WorkingDays)
+		windows = append(windows, editWindow{oldest, EditGracePeriod})
+		if oldest.Before(d.Oldest) {
+			d.Oldest = oldest
+		}
+	}
+	if d.Manager && rule.ManagerDaysBack > rule.DaysBack {
+		oldest := e.windowStart(req.Employee, today, rule.ManagerDaysBack, rule.WorkingDays)
+		windows = append(windows, editWindow{oldest, EditManagerOverride})
+		if oldest.Before(d.Oldest) {
+			d.Oldest = oldest
+		}
+	}
+
+	if d.Date.After(today) {
+		d.Reason = EditFutureDate
+		d.Message = fmt.Sprintf("restriction: %s is after today (%s, %s)", d.Date.Header(), today.Header(), d.Timezone)
+		return d
+	}
+	for _, w := range windows {
+		if !d.Date.Before(w.oldest) {
+			d.Allowed = true
+			d.Reason = w.reason
+			return d
+		}
+	}
+
+	d.Reason = EditBeforeWindow
+	switch {
+	case d.Oldest == today:
+		d.Message = fmt.Sprintf("restriction: can only edit Today's (%s) tasks", today.Header())
+	case d.Oldest == today.AddDays(-1):
+		d.Message = fmt.Sprintf("restriction: can only edit Today's (%s) or Yesterday's (%s) tasks", today.Header(), d.Oldest.Header())
+	default:
+		d.Message = fmt.Sprintf("restriction: can only edit tasks from %s back to %s", today.Header(), d.Oldest.Header())
+	}
+	return d
+}
+
+// Helper: Resolve the day a write targets (the employee's today when unset) and enforce the edit window
+func (e *Env) resolveTargetDate(meta MetadataStore, req models.TaskRequest) (models.Date, error) {
+	d := e.EvaluateEdit(EditRequest{
+		Sheet:    req.Role,
+		Employee: req.EmployeeName,
+		Date:     req.Date,
+		Actor:    req.Actor,
+		Timezone: e.EmployeeTimezone(meta, req.EmployeeName),
+	})
+	if !d.Allowed {
+		return models.Date{}, &Error{Kind: ErrForbidden, Code: "edit_window_closed", Detail: d.Message, Explanation: d}
+	}
+	return d.Date, nil
+}
+
+// Helper: Earliest day anyone may still write on a sheet, counting grace periods and manager overrides
+func (e *Env) earliestEditable(sheet string) models.Date {
+	rule, _ := e.editRule(sheet)
+	loc := editLocation("", rule.Timezone)
+	daysBack := max(rule.DaysBack, rule.ManagerDaysBack)
+
+	// Employees in other zones may still be a day behind, and a grace period adds another
+	today := models.DateOf(time.Now().In(loc)).AddDays(-1)
+	if rule.Grace > 0 {
+		today = today.AddDays(-1)
+	}
+	return e.windowStart("", today, daysBack, rule.WorkingDays)
+}
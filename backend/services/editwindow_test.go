@@ -0,0 +1,83 @@
+package services
+
+import (
+	"go-backend/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvaluateEdit(t *testing.T) {
+	cfg := config.Default()
+	cfg.EditWindow = config.EditWindowConfig{
+		EditWindowRule: config.EditWindowRule{DaysBack: 1, Timezone: "UTC", Grace: 2 * time.Hour, ManagerDaysBack: 5},
+		Sheets: map[string]config.EditWindowRule{
+			"managers": {Timezone: "UTC"},
+			"Ops":      {DaysBack: 1, WorkingDays: true, Timezone: "UTC"},
+		},
+		Managers: []string{"Boss"},
+	}
+	prev := config.Get()
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(prev) })
+
+	monday := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		req      EditRequest
+		now      time.Time
+		allowed  bool
+		reason   string
+		rule     string
+		oldest   string
+		today    string // Only checked when set
+		contains string // In the message, only checked when set
+	}{
+		{name: "today", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-19")}, now: monday,
+			allowed: true, reason: EditWithinWindow, rule: "default", oldest: "2026-10-18"},
+		{name: "today by default", req: EditRequest{}, now: monday,
+			allowed: true, reason: EditWithinWindow, rule: "default", oldest: "2026-10-18"},
+		{name: "yesterday", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-18")}, now: monday,
+			allowed: true, reason: EditWithinWindow, rule: "default", oldest: "2026-10-18"},
+		{name: "two days back", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-17")}, now: monday,
+			reason: EditBeforeWindow, rule: "default", oldest: "2026-10-18", contains: "Yesterday's (Sun 18-Oct-2026)"},
+		{name: "tomorrow", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-20")}, now: monday,
+			reason: EditFutureDate, rule: "default", oldest: "2026-10-18"},
+		{name: "grace after midnight", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-17")}, now: monday.Add(-9 * time.Hour),
+			allowed: true, reason: EditGracePeriod, rule: "default", oldest: "2026-10-17"},
+		{name: "grace over", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-17")}, now: monday.Add(-7 * time.Hour),
+			reason: EditBeforeWindow, rule: "default", oldest: "2026-10-18"},
+		{name: "manager override", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-14"), Actor: "boss"}, now: monday,
+			allowed: true, reason: EditManagerOverride, rule: "default", oldest: "2026-10-14"},
+		{name: "past the manager window", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-13"), Actor: "boss"}, now: monday,
+			reason: EditBeforeWindow, rule: "default", oldest: "2026-10-14", contains: "from Mon 19-Oct-2026 back to Wed 14-Oct-2026"},
+		{name: "not a manager", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-14"), Actor: "dev"}, now: monday,
+			reason: EditBeforeWindow, rule: "default", oldest: "2026-10-18"},
+		{name: "sheet rule, today only", req: EditRequest{Sheet: "Managers", Date: date(t, "2026-10-18")}, now: monday,
+			reason: EditBeforeWindow, rule: "managers", oldest: "2026-10-19", contains: "can only edit Today's"},
+		{name: "working days skip the weekend", req: EditRequest{Sheet: "ops", Date: date(t, "2026-10-16")}, now: monday,
+			allowed: true, reason: EditWithinWindow, rule: "Ops", oldest: "2026-10-16"},
+		{name: "working days, before the window", req: EditRequest{Sheet: "ops", Date: date(t, "2026-10-15")}, now: monday,
+			reason: EditBeforeWindow, rule: "Ops", oldest: "2026-10-16"},
+		{name: "employee zone ahead", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-20"), Timezone: "Pacific/Auckland"}, now: monday.Add(2 * time.Hour),
+			allowed: true, reason: EditWithinWindow, rule: "default", oldest: "2026-10-18", today: "2026-10-20"}, // 01:00 there, still in grace
+		{name: "unknown employee zone uses the rule's", req: EditRequest{Sheet: "DEV", Date: date(t, "2026-10-20"), Timezone: "Mars/Olympus"}, now: monday.Add(2 * time.Hour),
+			reason: EditFutureDate, rule: "default", oldest: "2026-10-18", today: "2026-10-19"},
+	}
+	var env *Env // Saturday and Sunday off
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := env.evaluateEdit(tt.req, tt.now)
+			if d.Allowed != tt.allowed || d.Reason != tt.reason || d.Rule != tt.rule || d.Oldest.String() != tt.oldest {
+				t.Errorf("got allowed %v, %s, rule %s, oldest %s; want %v, %s, rule %s, oldest %s",
+					d.Allowed, d.Reason, d.Rule, d.Oldest, tt.allowed, tt.reason, tt.rule, tt.oldest)
+			}
+			if tt.today != "" && d.Today.String() != tt.today {
+				t.Errorf("today %s, want %s", d.Today, tt.today)
+			}
+			if !strings.Contains(d.Message, tt.contains) {
+				t.Errorf("message %q, want it to contain %q", d.Message, tt.contains)
+			}
+		})
+	}
+}
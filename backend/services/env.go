@@ -0,0 +1,40 @@
+// services/env.go
+package services
+
+// Env is the shared state the stores and jobs consult: the calendar days are reasoned about
+// with, the team registry roles resolve through and the bus task and employee events go to.
+// main builds one and hands it to the constructors; tests build their own.
+// A nil Env, like a nil field, treats Saturday and Sunday as the only days off, takes roles as
+// sheet titles from config.RoleSheets and drops events; it also reads employee time zones
+// on every use instead of caching them.
+type Env struct {
+	Calendar *Calendar
+	Teams    *TeamRegistry
+	Events   *EventBus
+
+	zones zoneCache
+}
+
+// Helper: The calendar, nil without one
+func (e *Env) calendar() *Calendar {
+	if e == nil {
+		return nil
+	}
+	return e.Calendar
+}
+
+// Helper: The team registry, nil without one
+func (e *Env) teams() *TeamRegistry {
+	if e == nil {
+		return nil
+	}
+	return e.Teams
+}
+
+// Helper: The event bus, nil without one
+func (e *Env) events() *EventBus {
+	if e == nil {
+		return nil
+	}
+	return e.Events
+}
@@ -0,0 +1,92 @@
+// services/errors.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-backend/config"
+	"net"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+)
+
+// Error kinds returned by the services; handlers map them to HTTP statuses.
+// Test with errors.Is(err, services.ErrNotFound).
+var (
+	ErrNotFound    = errors.New("not found")            // 404
+	ErrForbidden   = errors.New("forbidden")            // 403, e.g. outside the edit window
+	ErrValidation  = errors.New("validation failed")    // 422
+	ErrConflict    = errors.New("conflict")             // 409
+	ErrUnavailable = errors.New("upstream unavailable") // 503, Google or the database is down or throttling
+)
+
+// Error is a service error with a kind and a machine-readable code
+type Error struct {
+	Kind   error  // One of the Err* kinds above
+	Code   string // e.g. "employee_not_found"
+	Detail string // Human-readable message
+	Err    error  // Underlying cause, if any
+
+	// Structured details for the client, e.g. the EditDecision behind an edit_window_closed
+	Explanation interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// Is matches the error's kind
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Helper: Build a typed error
+func newError(kind error, code, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Code: code, Detail: fmt.Sprintf(format, args...)}
+}
+
+// Helper: "unknown role" for a request naming a sheet that does not exist
+func errUnknownRole(role string) error {
+	return newError(ErrValidation, "unknown_role", "sheet '%s' not found", role)
+}
+
+// Helper: "task not found" for a task ID that no live cell holds
+func errTaskNotFound(id string) error {
+	return newError(ErrNotFound, "task_not_found", "task '%s' not found", id)
+}
+
+// AsError returns err as a typed service error. Errors from Google (429, 5xx),
+// the open circuit breaker and network failures become ErrUnavailable;
+// anything else is returned as nil.
+func AsError(err error) *Error {
+	var se *Error
+	if errors.As(err, &se) {
+		return se
+	}
+
+	if errors.Is(err, config.ErrCircuitOpen) {
+		return &Error{Kind: ErrUnavailable, Code: "circuit_open", Detail: err.Error(), Err: err}
+	}
+
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		switch {
+		case gerr.Code == http.StatusTooManyRequests:
+			return &Error{Kind: ErrUnavailable, Code: "upstream_rate_limited", Detail: err.Error(), Err: err}
+		case gerr.Code >= 500:
+			return &Error{Kind: ErrUnavailable, Code: "upstream_unavailable", Detail: err.Error(), Err: err}
+		}
+	}
+
+	var nerr net.Error
+	if errors.As(err, &nerr) {
+		return &Error{Kind: ErrUnavailable, Code: "upstream_unreachable", Detail: err.Error(), Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+// services/events.go
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go-backend/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	EventTaskCreated       = "task.created"
+	EventTaskStatusChanged = "task.status_changed"
+	EventTaskRenamed       = "task.renamed"
+	EventTaskDeleted       = "task.deleted"
+	EventEmployeeCreated   = "employee.created"
+)
+
+// Where a change came from
+const (
+	EventSourceAPI   = "api"   // A write through this backend
+	EventSourceSheet = "sheet" // An edit made directly in the spreadsheet
+)
+
+// EventTypes lists every event type, e.g. for validating webhook subscriptions
+var EventTypes = []string{EventTaskCreated, EventTaskStatusChanged, EventTaskRenamed, EventTaskDeleted, EventEmployeeCreated}
+
+// Event is something that happened to a task or an employee
+type Event struct {
+	ID           string           `json:"id"`
+	Type         string           `json:"type"`
+	OccurredAt   string           `json:"occurred_at"`
+	Actor        string           `json:"actor,omitempty"` // Bearer token owner or X-Actor of the write, when known
+	Source       string           `json:"source"`          // "api" or "sheet"
+	EmployeeName string           `json:"employee_name"`
+	Sheet        string           `json:"sheet,omitempty"`
+	Date         *models.Date     `json:"date,omitempty"`
+	Task         *models.TaskItem `json:"task,omitempty"`       // As it now is; as it was for task.deleted
+	OldStatus    string           `json:"old_status,omitempty"` // task.status_changed
+	OldTask      string           `json:"old_task,omitempty"`   // task.renamed
+}
+
+// CellChange is one change to an employee/day cell: the cell as it now reads and the task
+// events of the change (none when the lines were only reordered)
+type CellChange struct {
+	EmployeeName string            `json:"employee_name"`
+	Sheet        string            `json:"sheet"`
+	Date         *models.Date      `json:"date"`            // null when the column header is not a date
+	Label        string            `json:"label,omitempty"` // The column header, when it is not a date
+	Actor        string            `json:"actor,omitempty"`
+	Source       string            `json:"source"`
+	Tasks        []models.TaskItem `json:"tasks"`
+	Changes      []Event           `json:"changes"`
+}
+
+// EventBus hands events and cell changes to their subscribers, synchronously and in order.
+// Subscribers must not block or write through the store.
+type EventBus struct {
+	mu    sync.RWMutex
+	subs  []func(Event)
+	cells []func(CellChange)
+}
+
+// NewEventBus returns a bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers fn to receive every event published from now on
+func (b *EventBus) Subscribe(fn func(Event)) {
+	b.mu.Lock()
+	b.subs = append(b.subs, fn)
+	b.mu.Unlock()
+}
+
+// SubscribeCells registers fn to receive every cell change published from now on
+func (b *EventBus) SubscribeCells(fn func(CellChange)) {
+	b.mu.Lock()
+	b.cells = append(b.cells, fn)
+	b.mu.Unlock()
+}
+
+// PublishCell hands a cell change to the cell subscribers, then its events to the event subscribers
+func (b *EventBus) PublishCell(change CellChange) {
+	b.mu.RLock()
+	for _, fn := range b.cells {
+		fn(change)
+	}
+	b.mu.RUnlock()
+	b.Publish(change.Changes...)
+}
+
+// Publish hands events to every subscriber
+func (b *EventBus) Publish(events ...Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, ev := range events {
+		for _, fn := range b.subs {
+			fn(ev)
+		}
+	}
+}
+
+// Helper: Stamp events with their ID and time
+func stamp(events []Event) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for i := range events {
+		events[i].ID = newEventID()
+		events[i].OccurredAt = now
+	}
+}
+
+// Helper: Random event ID, "e" and 20 hex digits
+func newEventID() string {
+	return randomID("e", 10)
+}
+
+// Helper: prefix and n random bytes in hex
+func randomID(prefix string, n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return prefix + hex.EncodeToString(b)
+}
+
+// Helper: Publish a change to a cell with its task events, found by diffing its lines on task ID.
+// Nothing is published when the lines are unchanged.
+func (e *Env) publishCellChange(loc TaskLocation, actor, source string, before, after []models.TaskItem) {
+	eventBus := e.events()
+	if eventBus == nil || sameLines(before, after) {
+		return
+	}
+	var date *models.Date
+	label := strings.TrimSpace(loc.Label)
+	if !loc.Date.IsZero() {
+		d := loc.Date
+		date, label = &d, ""
+	}
+	event := func(typ string, item models.TaskItem) Event {
+		return Event{Type: typ, Actor: actor, Source: source, EmployeeName: loc.EmployeeName, Sheet: loc.Sheet, Date: date, Task: &item}
+	}
+
+	old := make(map[string]models.TaskItem, len(before))
+	for _, item := range before {
+		old[item.ID] = item
+	}
+	var events []Event
+	kept := map[string]bool{}
+	for _, item := range after {
+		kept[item.ID] = true
+		prev, ok := old[item.ID]
+		if !ok {
+			events = append(events, event(EventTaskCreated, item))
+			continue
+		}
+		if prev.Task != item.Task {
+			ev := event(EventTaskRenamed, item)
+			ev.OldTask = prev.Task
+			events = append(events, ev)
+		}
+		if !strings.EqualFold(prev.Status, item.Status) {
+			ev := event(EventTaskStatusChanged, item)
+			ev.OldStatus = prev.Status
+			events = append(events, ev)
+		}
+	}
+	for _, item := range before {
+		if !kept[item.ID] {
+			events = append(events, event(EventTaskDeleted, item))
+		}
+	}
+	stamp(events)
+
+	if events == nil {
+		events = []Event{}
+	}
+	tasks := append([]models.TaskItem{}, after...)
+	eventBus.PublishCell(CellChange{
+		EmployeeName: loc.EmployeeName,
+		Sheet:        loc.Sheet,
+		Date:         date,
+		Label:        label,
+		Actor:        actor,
+		Source:       source,
+		Tasks:        tasks,
+		Changes:      events,
+	})
+}
+
+// Helper: Whether two versions of a cell have the same lines in the same order
+func sameLines(a, b []models.TaskItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || a[i].Task != b[i].Task || !strings.EqualFold(a[i].Status, b[i].Status) {
+			return false
+		}
+	}
+	return true
+}
+
+// Helper: Publish employee.created
+func (e *Env) publishEmployeeCreated(name string) {
+	eventBus := e.events()
+	if eventBus == nil {
+		return
+	}
+	events := []Event{{Type: EventEmployeeCreated, Source: EventSourceAPI, EmployeeName: strings.TrimSpace(name)}}
+	stamp(events)
+	eventBus.Publish(events...)
+}
+
+// Helper: editCell that publishes the events of the edit once it is written
+func (e *Env) editCellPublishing(s taskCells, loc TaskLocation, actor string, fn func(items []models.TaskItem) ([]models.TaskItem, error)) error {
+	var before, after []models.TaskItem
+	err := s.editCell(loc, func(items []models.TaskItem) ([]models.TaskItem, error) {
+		before = append([]models.TaskItem(nil), items...)
+		out, err := fn(items)
+		after = out
+		return out, err
+	})
+	if err != nil {
+		return err
+	}
+	e.publishCellChange(loc, actor, EventSourceAPI, before, after)
+	return nil
+}
@@ -0,0 +1,175 @@
+// services/feed.go
+package services
+
+import (
+	"go-backend/config"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Feed message types
+const (
+	FeedCellChanged     = "cell.changed"     // Data is a CellChange
+	FeedEmployeeCreated = "employee.created" // Data is an Event
+)
+
+// Messages a subscriber may fall behind by before it is dropped
+const feedSubscriberBuffer = 64
+
+// FeedMessage is one message of the live feed. IDs are "<epoch>-<seq>": the epoch changes
+// on every start, the sequence number grows by one per message.
+type FeedMessage struct {
+	ID   string      `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+
+	seq          uint64
+	employeeName string
+	sheet        string
+}
+
+// FeedFilter narrows a subscription to one employee and/or one sheet; empty fields match all
+type FeedFilter struct {
+	EmployeeName string
+	Sheet        string
+}
+
+// Helper: Whether a message passes the filter
+func (f FeedFilter) match(m FeedMessage) bool {
+	if f.EmployeeName != "" && !strings.EqualFold(f.EmployeeName, m.employeeName) {
+		return false
+	}
+	if f.Sheet != "" && m.sheet != "" && !strings.EqualFold(f.Sheet, m.sheet) {
+		return false
+	}
+	return true
+}
+
+// FeedSubscription receives live messages on C until it is unsubscribed, or until it falls
+// too far behind, in which case C is closed and the client should resume from its last ID
+type FeedSubscription struct {
+	C      <-chan FeedMessage
+	LastID string // Newest message when it started, "" before the first; where a reset client resumes
+	ch     chan FeedMessage
+	filter FeedFilter
+}
+
+// Feed keeps the most recent cell changes and employee events for the dashboard's live
+// stream and fans them out to subscribers. Clients resume from the last ID they saw.
+type Feed struct {
+	mu     sync.Mutex
+	epoch  string
+	seq    uint64
+	buffer []FeedMessage // Ring of the last cfg.Buffer messages
+	next   int
+	subs   map[*FeedSubscription]bool
+}
+
+// NewFeed returns an empty feed keeping the last cfg.Buffer messages for resuming clients
+func NewFeed(cfg config.EventsConfig) *Feed {
+	return &Feed{
+		epoch:  strconv.FormatInt(time.Now().UnixMilli(), 36),
+		buffer: make([]FeedMessage, 0, cfg.Buffer),
+		subs:   map[*FeedSubscription]bool{},
+	}
+}
+
+// HandleCell adds a cell change to the feed; subscribe it with EventBus.SubscribeCells
+func (f *Feed) HandleCell(c CellChange) {
+	f.add(FeedMessage{Type: FeedCellChanged, Data: c, employeeName: c.EmployeeName, sheet: c.Sheet})
+}
+
+// HandleEvent adds employee.created to the feed; task events reach it as cell changes
+func (f *Feed) HandleEvent(ev Event) {
+	if ev.Type != EventEmployeeCreated {
+		return
+	}
+	f.add(FeedMessage{Type: FeedEmployeeCreated, Data: ev, employeeName: ev.EmployeeName, sheet: ev.Sheet})
+}
+
+// Helper: Number, keep and fan out a message
+func (f *Feed) add(m FeedMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	m.seq = f.seq
+	m.ID = f.id(f.seq)
+	if len(f.buffer) < cap(f.buffer) {
+		f.buffer = append(f.buffer, m)
+	} else if cap(f.buffer) > 0 {
+		f.buffer[f.next] = m
+		f.next = (f.next + 1) % cap(f.buffer)
+	}
+
+	for sub := range f.subs {
+		if !sub.filter.match(m) {
+			continue
+		}
+		select {
+		case sub.ch <- m:
+		default:
+			// Too slow; it resumes from its last ID after reconnecting
+			delete(f.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe starts a subscription. With lastID it also returns the kept messages after
+// that ID; reset is true when they cannot be replayed (the ID is from before a restart or
+// older than the buffer), in which case the client should reload instead.
+func (f *Feed) Subscribe(lastID string, filter FeedFilter) (sub *FeedSubscription, backlog []FeedMessage, reset bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan FeedMessage, feedSubscriberBuffer)
+	sub = &FeedSubscription{C: ch, ch: ch, filter: filter}
+	if f.seq > 0 {
+		sub.LastID = f.id(f.seq)
+	}
+	f.subs[sub] = true
+
+	if lastID == "" {
+		return sub, nil, false
+	}
+	epoch, n, _ := strings.Cut(lastID, "-")
+	seq, err := strconv.ParseUint(n, 10, 64)
+	if err != nil || epoch != f.epoch || seq > f.seq {
+		return sub, nil, true
+	}
+	kept := f.kept()
+	if seq < f.seq && (len(kept) == 0 || kept[0].seq > seq+1) {
+		return sub, nil, true
+	}
+	for _, m := range kept {
+		if m.seq > seq && filter.match(m) {
+			backlog = append(backlog, m)
+		}
+	}
+	return sub, backlog, false
+}
+
+// Unsubscribe ends a subscription and closes its channel
+func (f *Feed) Unsubscribe(sub *FeedSubscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs[sub] {
+		delete(f.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Helper: ID of message seq
+func (f *Feed) id(seq uint64) string {
+	return f.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// Helper: Kept messages, oldest first. Callers hold f.mu.
+func (f *Feed) kept() []FeedMessage {
+	out := make([]FeedMessage, 0, len(f.buffer))
+	out = append(out, f.buffer[f.next:]...)
+	return append(out, f.buffer[:f.next]...)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"go-backend/config"
+	"strings"
+	"testing"
+)
+
+func TestFeedSubscribeResumes(t *testing.T) {
+	feed := NewFeed(config.EventsConfig{Buffer: 3})
+	for _, name := range []string{"Ann", "Ann", "Bob", "Ann", "Ann"} {
+		feed.HandleCell(CellChange{EmployeeName: name, Sheet: "DEV"})
+	}
+	id := func(seq string) string { return feed.epoch + "-" + seq }
+
+	tests := []struct {
+		name        string
+		lastID      string
+		filter      FeedFilter
+		wantBacklog []string // Sequence numbers replayed
+		wantReset   bool
+	}{
+		{name: "fresh client", lastID: ""},
+		{name: "up to date", lastID: id("5")},
+		{name: "a few behind", lastID: id("3"), wantBacklog: []string{"4", "5"}},
+		{name: "oldest kept is next", lastID: id("2"), wantBacklog: []string{"3", "4", "5"}},
+		{name: "filtered", lastID: id("2"), filter: FeedFilter{EmployeeName: "ann"}, wantBacklog: []string{"4", "5"}},
+		{name: "other sheet", lastID: id("2"), filter: FeedFilter{Sheet: "QA"}},
+		{name: "older than the buffer", lastID: id("1"), wantReset: true},
+		{name: "before the first message", lastID: id("0"), wantReset: true},
+		{name: "ahead of the feed", lastID: id("9"), wantReset: true},
+		{name: "from before a restart", lastID: "0-4", wantReset: true},
+		{name: "not an ID", lastID: "yesterday", wantReset: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, backlog, reset := feed.Subscribe(tt.lastID, tt.filter)
+			defer feed.Unsubscribe(sub)
+			if reset != tt.wantReset {
+				t.Errorf("reset = %v, want %v", reset, tt.wantReset)
+			}
+			var got []string
+			for _, m := range backlog {
+				_, seq, _ := strings.Cut(m.ID, "-")
+				got = append(got, seq)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantBacklog, ",") {
+				t.Errorf("backlog %v, want %v", got, tt.wantBacklog)
+			}
+			if sub.LastID != id("5") {
+				t.Errorf("LastID = %q, want %q", sub.LastID, id("5"))
+			}
+		})
+	}
+}
+
+func TestFeedFansOutAndDropsSlowSubscribers(t *testing.T) {
+	feed := NewFeed(config.EventsConfig{Buffer: 10})
+	sub, _, _ := feed.Subscribe("", FeedFilter{})
+	if sub.LastID != "" {
+		t.Errorf("LastID = %q on an empty feed, want none", sub.LastID)
+	}
+	bob, _, _ := feed.Subscribe("", FeedFilter{EmployeeName: "Bob"})
+	defer feed.Unsubscribe(bob)
+
+	feed.HandleCell(CellChange{EmployeeName: "Ann", Sheet: "DEV"})
+	feed.HandleEvent(Event{Type: EventTaskCreated, EmployeeName: "Bob"}) // Reaches the feed as a cell change instead
+	feed.HandleEvent(Event{Type: EventEmployeeCreated, EmployeeName: "Bob", Sheet: "QA"})
+	if m := <-sub.C; m.Type != FeedCellChanged {
+		t.Errorf("first message %s, want %s", m.Type, FeedCellChanged)
+	}
+	if m := <-sub.C; m.Type != FeedEmployeeCreated {
+		t.Errorf("second message %s, want %s", m.Type, FeedEmployeeCreated)
+	}
+	if m := <-bob.C; m.Type != FeedEmployeeCreated {
+		t.Errorf("Bob's first message %s, want only his own", m.Type)
+	}
+
+	// sub stops reading and falls behind
+	for i := 0; i <= feedSubscriberBuffer; i++ {
+		feed.HandleCell(CellChange{EmployeeName: "Ann", Sheet: "DEV"})
+	}
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n != feedSubscriberBuffer {
+		t.Errorf("%d messages before the channel closed, want %d", n, feedSubscriberBuffer)
+	}
+}
@@ -0,0 +1,99 @@
+// services/headers.go
+package services
+
+import (
+	"fmt"
+	"go-backend/models"
+	"strings"
+)
+
+// dateHeaders is a decoded role sheet header row: labels[0] is the name column,
+// the rest are day columns from oldest to newest
+type dateHeaders struct {
+	labels []string
+	dates  []models.Date // Zero for the name column and headers that are not dates
+}
+
+// Helper: Decode a header row as read from the Sheets API
+func decodeHeaderRow(row []interface{}) dateHeaders {
+	labels := make([]string, len(row))
+	for i, h := range row {
+		labels[i] = fmt.Sprintf("%v", h)
+	}
+	return decodeHeaders(labels, models.Today())
+}
+
+// Helper: Decode header labels into calendar days.
+// Headers with a year ("Fri 16-Oct-2026", "2026-10-16") are taken as-is. Legacy "Mon 02-Jan"
+// headers get their year from the legacy headers alone: walking right to left, each one is the
+// latest matching day not after the legacy column to its right, starting from tomorrow (clock
+// skew). Headers with a year are left out of the chain: a back-dated one may sit to their right.
+func decodeHeaders(labels []string, today models.Date) dateHeaders {
+	dates := make([]models.Date, len(labels))
+	limit := today.AddDays(1)
+	for i := len(labels) - 1; i >= 1; i-- {
+		label := strings.TrimSpace(labels[i])
+		if label == "" {
+			continue
+		}
+		if d, err := models.ParseDate(label); err == nil {
+			dates[i] = d
+			continue
+		}
+		if d, err := models.LegacyHeaderIn(label, limit); err == nil {
+			dates[i] = d
+			limit = d
+		}
+	}
+	return dateHeaders{labels: labels, dates: dates}
+}
+
+// Helper: Day and header text of column i ("Unknown" past the end of the header row)
+func (h dateHeaders) column(i int) (models.Date, string) {
+	if i >= len(h.labels) {
+		return models.Date{}, "Unknown"
+	}
+	return h.dates[i], h.labels[i]
+}
+
+// Helper: Index at which a new column for day d keeps the dated columns in order:
+// before the first column of a later day, else after the last column
+func (h dateHeaders) insertAt(d models.Date) int {
+	for i := 1; i < len(h.dates); i++ {
+		if !h.dates[i].IsZero() && d.Before(h.dates[i]) {
+			return i
+		}
+	}
+	return max(len(h.labels), 1)
+}
+
+// Helper: Index of the newest column for day d, -1 when there is none
+func (h dateHeaders) find(d models.Date) int {
+	for i := len(h.dates) - 1; i >= 1; i-- {
+		if h.dates[i] == d {
+			return i
+		}
+	}
+	return -1
+}
+
+// Helper: Index of the newest column for a day key (see dayKey), -1 when there is none
+func (h dateHeaders) findKey(key string) int {
+	if d, err := models.ParseDate(key); err == nil {
+		return h.find(d)
+	}
+	for i := len(h.labels) - 1; i >= 1; i-- {
+		if h.dates[i].IsZero() && strings.EqualFold(strings.TrimSpace(h.labels[i]), strings.TrimSpace(key)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Helper: Identity of a day column across backends: the ISO date, or the raw header when it is not a date
+func dayKey(d models.Date, label string) string {
+	if d.IsZero() {
+		return strings.TrimSpace(label)
+	}
+	return d.String()
+}
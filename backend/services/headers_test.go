@@ -0,0 +1,90 @@
+package services
+
+import (
+	"go-backend/models"
+	"testing"
+)
+
+func TestDecodeHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		today  string
+		labels []string
+		want   []string // ISO day of each column after the name, "" when it is not a date
+	}{
+		{"full headers", "2026-10-16", []string{"Name", "Thu 15-Oct-2026", "2026-10-16"}, []string{"2026-10-15", "2026-10-16"}},
+		{"legacy across new year", "2026-01-02", []string{"Name", "Wed 31-Dec", "Thu 01-Jan", "Fri 02-Jan"},
+			[]string{"2025-12-31", "2026-01-01", "2026-01-02"}},
+		{"legacy tomorrow allowed", "2026-10-16", []string{"Name", "Sat 17-Oct"}, []string{"2026-10-17"}},
+		{"legacy before a full header", "2026-10-16", []string{"Name", "Wed 14-Oct", "Thu 15-Oct-2026"}, []string{"2026-10-14", "2026-10-15"}},
+		{"back-dated full header after legacy", "2026-10-16", []string{"Name", "Mon 12-Oct", "Tue 13-Oct", "Fri 09-Oct-2026"},
+			[]string{"2026-10-12", "2026-10-13", "2026-10-09"}},
+		{"mixed, in order", "2026-10-16", []string{"Name", "Thu 08-Oct", "Fri 09-Oct-2026", "Mon 12-Oct", "2026-10-13"},
+			[]string{"2026-10-08", "2026-10-09", "2026-10-12", "2026-10-13"}},
+		{"legacy weekday picks the year", "2026-10-16", []string{"Name", "Mon 16-Oct"}, []string{"2023-10-16"}},
+		{"not dates", "2026-10-16", []string{"Name", "Notes", "", "Fri 16-Oct"}, []string{"", "", "2026-10-16"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := decodeHeaders(tt.labels, date(t, tt.today))
+			for i, want := range tt.want {
+				got, label := h.column(i + 1)
+				if label != tt.labels[i+1] {
+					t.Errorf("column %d label %q, want %q", i+1, label, tt.labels[i+1])
+				}
+				if (want == "" && !got.IsZero()) || (want != "" && got.String() != want) {
+					t.Errorf("column %d (%q) = %v, want %q", i+1, tt.labels[i+1], got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDateHeadersInsertAt(t *testing.T) {
+	h := decodeHeaders([]string{"Name", "Mon 12-Oct", "Notes", "Wed 14-Oct-2026", "Fri 16-Oct"}, date(t, "2026-10-16"))
+	tests := []struct {
+		day  string
+		want int
+	}{
+		{"2026-10-09", 1},
+		{"2026-10-13", 3}, // Past the undated column
+		{"2026-10-15", 4},
+		{"2026-10-19", 5},
+	}
+	for _, tt := range tests {
+		if got := h.insertAt(date(t, tt.day)); got != tt.want {
+			t.Errorf("insertAt(%s) = %d, want %d", tt.day, got, tt.want)
+		}
+	}
+	if got := decodeHeaders(nil, date(t, "2026-10-16")).insertAt(date(t, "2026-10-16")); got != 1 {
+		t.Errorf("insertAt on an empty header row = %d, want 1", got)
+	}
+}
+
+func TestDateHeadersFindKey(t *testing.T) {
+	h := decodeHeaders([]string{"Name", "Thu 15-Oct-2026", "Notes", "Thu 15-Oct", "2026-10-16"}, date(t, "2026-10-16"))
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"2026-10-15", 3}, // The newest column of a repeated day
+		{"2026-10-16", 4},
+		{"2026-10-14", -1},
+		{"notes", 2},
+		{"Name", -1}, // Never the name column
+	}
+	for _, tt := range tests {
+		if got := h.findKey(tt.key); got != tt.want {
+			t.Errorf("findKey(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+	if _, label := h.column(9); label != "Unknown" {
+		t.Errorf("column past the end = %q, want Unknown", label)
+	}
+	if k := dayKey(date(t, "2026-10-15"), "Thu 15-Oct-2026"); k != "2026-10-15" {
+		t.Errorf("dayKey of a date = %q", k)
+	}
+	if k := dayKey(models.Date{}, " Notes "); k != "Notes" {
+		t.Errorf("dayKey of a label = %q", k)
+	}
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"go-backend/config"
+	"go-backend/fakesheets"
+	"go-backend/models"
+	"net/http/httptest"
+	"testing"
+)
+
+// Helper: A fake Sheets API with the default role tabs, and the configuration and client pointing at it
+func startFakeSheets(t *testing.T) (*fakesheets.Server, *config.SheetsClient) {
+	t.Helper()
+	fake := fakesheets.NewServer()
+	hs := httptest.NewServer(fake)
+	t.Cleanup(hs.Close)
+
+	cfg := config.Default()
+	cfg.Credentials.Endpoint = hs.URL + "/"
+	cfg.SheetsAPI.ReadsPerMinute, cfg.SheetsAPI.WritesPerMinute = 60000, 60000 // The fake has no quota
+	for _, tab := range fake.AddSpreadsheet(cfg.SpreadsheetID, cfg.RoleSheets...) {
+		if err := fake.SetValue(cfg.SpreadsheetID, tab, 0, 0, "Employee Name"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prev := config.Get()
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(prev) })
+
+	client, err := config.NewSheetsClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, client
+}
+
+// Helper: An Env on whose calendar every day is a working day, whatever today is
+func everyDayWorking(t *testing.T) *Env {
+	t.Helper()
+	cal, err := NewCalendar(config.CalendarConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Env{Calendar: cal}
+}
+
+// Helper: A date from "YYYY-MM-DD"
+func date(t *testing.T, s string) models.Date {
+	t.Helper()
+	d, err := models.ParseDate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
@@ -0,0 +1,181 @@
+// services/holidays.go
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"go-backend/models"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Holiday is one day off of a holiday set
+type Holiday struct {
+	Date models.Date `json:"date"`
+	Name string      `json:"name"`
+}
+
+// HolidaySet is a named list of holidays and where it came from
+type HolidaySet struct {
+	Name     string    `json:"name"`
+	Source   string    `json:"source"` // File path, or "config" for calendar.holidays
+	Holidays []Holiday `json:"holidays"`
+}
+
+// Helper: Read a holiday file, by extension
+func loadHolidayFile(path string) ([]Holiday, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var days []Holiday
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ics":
+		var recurring []string
+		days, recurring, err = parseICS(f)
+		for _, event := range recurring {
+			log.Printf("Calendar: %s: recurring event %s is only read on its first day; list each year's date instead", path, event)
+		}
+	case ".csv":
+		days, err = parseHolidayCSV(f)
+	default:
+		return nil, fmt.Errorf("%s: holiday files must be .ics or .csv", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	return days, nil
+}
+
+// Helper: Holidays of an iCalendar file: every day each VEVENT covers.
+// All-day events end the day before DTEND. Recurrence rules are not expanded: events with
+// one are read for their first occurrence and returned in recurring ("line 12 'Diwali'").
+func parseICS(r io.Reader) (days []Holiday, recurring []string, err error) {
+	// Unfold continuation lines (RFC 5545 3.1)
+	var lines []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var inEvent, repeats bool
+	var start, end models.Date
+	var summary string
+	var begin int // Line of the event's BEGIN
+	for n, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(name, ";") // Drop parameters, e.g. DTSTART;VALUE=DATE
+		switch strings.ToUpper(name) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent, repeats, start, end, summary, begin = true, false, models.Date{}, models.Date{}, "", n+1
+			}
+		case "DTSTART", "DTEND":
+			if !inEvent {
+				continue
+			}
+			d, err := parseICSDate(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+			if strings.EqualFold(name, "DTSTART") {
+				start = d
+			} else {
+				end = d
+			}
+		case "SUMMARY":
+			if inEvent {
+				summary = unescapeICS(value)
+			}
+		case "RRULE", "RDATE":
+			repeats = repeats || inEvent
+		case "END":
+			if !strings.EqualFold(value, "VEVENT") || !inEvent {
+				continue
+			}
+			inEvent = false
+			if start.IsZero() {
+				continue
+			}
+			if repeats {
+				recurring = append(recurring, fmt.Sprintf("line %d '%s'", begin, summary))
+			}
+			if !end.After(start) {
+				end = start.AddDays(1)
+			}
+			for d := start; d.Before(end); d = d.AddDays(1) {
+				days = append(days, Holiday{Date: d, Name: summary})
+			}
+		}
+	}
+	return days, recurring, nil
+}
+
+// Helper: The day of an iCalendar DATE or DATE-TIME value ("20261225", "20261225T000000Z")
+func parseICSDate(v string) (models.Date, error) {
+	v = strings.TrimSpace(v)
+	if len(v) < 8 {
+		return models.Date{}, fmt.Errorf("invalid date %q", v)
+	}
+	return models.ParseDate(v[0:4] + "-" + v[4:6] + "-" + v[6:8])
+}
+
+// Helper: Undo iCalendar text escaping
+func unescapeICS(v string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(v)
+}
+
+// Helper: Holidays of a "date,name" CSV file; a header row and # comments are skipped
+func parseHolidayCSV(r io.Reader) ([]Holiday, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+
+	var days []Holiday
+	for n := 1; ; n++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) == 0 || strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		d, err := models.ParseDate(rec[0])
+		if err != nil {
+			if n == 1 {
+				continue // Header
+			}
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		h := Holiday{Date: d}
+		if len(rec) > 1 {
+			h.Name = strings.TrimSpace(rec[1])
+		}
+		days = append(days, h)
+	}
+	return days, nil
+}
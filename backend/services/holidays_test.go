@@ -0,0 +1,30 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseICS(t *testing.T) {
+	const ics = "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20261020\r\nDTEND;VALUE=DATE:20261022\r\nSUMMARY:Diwali\\, day 1\r\n  and 2\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20261225\r\nRRULE:FREQ=YEARLY\r\nSUMMARY:Christmas\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART:20261102T090000Z\r\nSUMMARY:Offsite\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	days, recurring, err := parseICS(strings.NewReader(ics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2026-10-20 Diwali, day 1 and 2", "2026-10-21 Diwali, day 1 and 2", "2026-12-25 Christmas", "2026-11-02 Offsite"}
+	if len(days) != len(want) {
+		t.Fatalf("got %+v, want %v", days, want)
+	}
+	for i, h := range days {
+		if got := h.Date.String() + " " + h.Name; got != want[i] {
+			t.Errorf("day %d = %q, want %q", i, got, want[i])
+		}
+	}
+	if len(recurring) != 1 || recurring[0] != "line 7 'Christmas'" {
+		t.Errorf("recurring = %q, want the Christmas event", recurring)
+	}
+}
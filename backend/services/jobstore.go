@@ -0,0 +1,810 @@
+// services/jobstore.go
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-backend/config"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// jobRecord is the persisted state of one job
+type jobRecord struct {
+	NextRunAt  *time.Time        `json:"next_run_at"` // nil: runs only on request
+	LeaseOwner string            `json:"lease_owner"`
+	LeaseUntil *time.Time        `json:"lease_until"`
+	State      map[string]string `json:"state"`
+	Runs       []JobRun          `json:"runs"` // Newest first (file store only)
+}
+
+// Helper: Whether another instance than owner holds the lease at now
+func (r *jobRecord) leasedTo(owner string, now time.Time) bool {
+	return r.LeaseOwner != "" && r.LeaseOwner != owner && r.LeaseUntil != nil && now.Before(*r.LeaseUntil)
+}
+
+// jobStore persists job schedules, leases, state and run history. The lease is what keeps
+// a job to one instance at a time when several share the store.
+type jobStore interface {
+	// register records a job; a sooner next run replaces the recorded one (nil: runs only on request)
+	register(job string, next *time.Time) error
+
+	// acquire leases a due job (any job with force) to owner until the given time and returns its state;
+	// ok is false when it is not due or another instance holds the lease
+	acquire(job, owner string, now, until time.Time, force bool) (state map[string]string, ok bool, err error)
+
+	// renew extends a lease owner holds
+	renew(job, owner string, until time.Time) error
+
+	// finish records a run, saves the job's state and releases the lease; a non-nil next reschedules the job.
+	// When run.Instance no longer holds the lease, the state and schedule are left to the instance that
+	// took over and the run is recorded as lost.
+	finish(run JobRun, state map[string]string, next *time.Time, keep int) (lost bool, err error)
+
+	// updateState read-modifies-writes a record's state outside any run; fn returning false skips the write
+	updateState(job string, fn func(state map[string]string) bool) error
+
+	records() (map[string]jobRecord, error)
+	runs(job string, limit int) ([]JobRun, error)
+	describe() string
+}
+
+// fileJobStore keeps job state in a JSON file, or only in memory when path is empty.
+// Instances sharing the file take turns through an exclusive lock on "<path>.lock".
+type fileJobStore struct {
+	path string
+	mu   sync.Mutex
+	mem  map[string]*jobRecord // The state when path is empty
+}
+
+func (f *fileJobStore) describe() string {
+	if f.path == "" {
+		return "memory"
+	}
+	return f.path
+}
+
+// Helper: Read-modify-write the records under the file lock; fn returning false skips the write
+func (f *fileJobStore) update(fn func(recs map[string]*jobRecord) (bool, error)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.path == "" {
+		if f.mem == nil {
+			f.mem = map[string]*jobRecord{}
+		}
+		_, err := fn(f.mem)
+		return err
+	}
+
+	lock, err := os.OpenFile(f.path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	unlock, err := lockFile(lock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	recs := map[string]*jobRecord{}
+	data, err := os.ReadFile(f.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &recs); err != nil {
+			return err
+		}
+	}
+	changed, err := fn(recs)
+	if err != nil || !changed {
+		return err
+	}
+
+	data, err = json.MarshalIndent(recs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".jobs-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// Helper: The record of a job, created when missing
+func recordOf(recs map[string]*jobRecord, job string) *jobRecord {
+	rec := recs[job]
+	if rec == nil {
+		rec = &jobRecord{State: map[string]string{}}
+		recs[job] = rec
+	}
+	return rec
+}
+
+func (f *fileJobStore) register(job string, next *time.Time) error {
+	return f.update(func(recs map[string]*jobRecord) (bool, error) {
+		rec := recordOf(recs, job)
+		if next == nil {
+			rec.NextRunAt = nil
+		} else if rec.NextRunAt == nil || next.Before(*rec.NextRunAt) {
+			rec.NextRunAt = next
+		}
+		return true, nil
+	})
+}
+
+func (f *fileJobStore) acquire(job, owner string, now, until time.Time, force bool) (map[string]string, bool, error) {
+	var state map[string]string
+	ok := false
+	err := f.update(func(recs map[string]*jobRecord) (bool, error) {
+		rec := recordOf(recs, job)
+		due := force || (rec.NextRunAt != nil && !now.Before(*rec.NextRunAt))
+		if !due || rec.leasedTo(owner, now) {
+			return false, nil
+		}
+		rec.LeaseOwner, rec.LeaseUntil = owner, &until
+		state, ok = copyState(rec.State), true
+		return true, nil
+	})
+	return state, ok, err
+}
+
+func (f *fileJobStore) renew(job, owner string, until time.Time) error {
+	return f.update(func(recs map[string]*jobRecord) (bool, error) {
+		rec := recordOf(recs, job)
+		if rec.LeaseOwner != owner {
+			return false, nil
+		}
+		rec.LeaseUntil = &until
+		return true, nil
+	})
+}
+
+func (f *fileJobStore) finish(run JobRun, state map[string]string, next *time.Time, keep int) (bool, error) {
+	lost := false
+	err := f.update(func(recs map[string]*jobRecord) (bool, error) {
+		rec := recordOf(recs, run.Job)
+		if rec.LeaseOwner == run.Instance {
+			rec.LeaseOwner, rec.LeaseUntil = "", nil
+			if next != nil {
+				rec.NextRunAt = next
+			}
+			rec.State = copyState(state)
+		} else {
+			lost = true
+			markLost(&run, rec.LeaseOwner)
+		}
+		rec.Runs = append([]JobRun{run}, rec.Runs...)
+		if len(rec.Runs) > keep {
+			rec.Runs = rec.Runs[:keep]
+		}
+		return true, nil
+	})
+	return lost, err
+}
+
+// Helper: Record a run whose lease ran out and passed to owner ("" when no one took it over)
+func markLost(run *JobRun, owner string) {
+	run.Status = JobStatusLost
+	if owner == "" {
+		run.Error = "lease expired before the run finished"
+	} else {
+		run.Error = fmt.Sprintf("lease expired before the run finished and passed to %s", owner)
+	}
+}
+
+func (f *fileJobStore) updateState(job string, fn func(state map[string]string) bool) error {
+	return f.update(func(recs map[string]*jobRecord) (bool, error) {
+		rec := recordOf(recs, job)
+		if rec.State == nil {
+			rec.State = map[string]string{}
+		}
+		return fn(rec.State), nil
+	})
+}
+
+func (f *fileJobStore) records() (map[string]jobRecord, error) {
+	out := map[string]jobRecord{}
+	err := f.update(func(recs map[string]*jobRecord) (bool, error) {
+		for name, rec := range recs {
+			out[name] = *rec
+		}
+		return false, nil
+	})
+	return out, err
+}
+
+func (f *fileJobStore) runs(job string, limit int) ([]JobRun, error) {
+	var out []JobRun
+	err := f.update(func(recs map[string]*jobRecord) (bool, error) {
+		if rec := recs[job]; rec != nil {
+			out = append(out, rec.Runs...)
+		}
+		return false, nil
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, err
+}
+
+// Helper: Copy a job's state so runs never share the stored map
+func copyState(state map[string]string) map[string]string {
+	out := make(map[string]string, len(state))
+	for k, v := range state {
+		out[k] = v
+	}
+	return out
+}
+
+// postgresJobStore keeps job state in the jobs and job_runs tables; leases are taken with a
+// conditional UPDATE, so any number of instances can share the database
+type postgresJobStore struct {
+	db *sql.DB
+}
+
+func (p *postgresJobStore) describe() string {
+	return "postgres"
+}
+
+func (p *postgresJobStore) register(job string, next *time.Time) error {
+	_, err := p.db.Exec(`
+		INSERT INTO jobs (name, next_run_at) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET
+			next_run_at = CASE
+				WHEN EXCLUDED.next_run_at IS NULL THEN NULL
+				ELSE LEAST(COALESCE(jobs.next_run_at, EXCLUDED.next_run_at), EXCLUDED.next_run_at)
+			END,
+			updated_at = now()`, job, next)
+	return err
+}
+
+func (p *postgresJobStore) acquire(job, owner string, now, until time.Time, force bool) (map[string]string, bool, error) {
+	var raw string
+	err := p.db.QueryRow(`
+		UPDATE jobs SET lease_owner = $2, lease_until = $3, updated_at = now()
+		WHERE name = $1
+			AND ($5 OR (next_run_at IS NOT NULL AND next_run_at <= $4))
+			AND (lease_owner = '' OR lease_owner = $2 OR lease_until IS NULL OR lease_until <= $4)
+		RETURNING state`, job, owner, until, now, force).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	state := map[string]string{}
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return nil, false, err
+	}
+	return state, true, nil
+}
+
+func (p *postgresJobStore) renew(job, owner string, until time.Time) error {
+	_, err := p.db.Exec(`UPDATE jobs SET lease_until = $3, updated_at = now() WHERE name = $1 AND lease_owner = $2`, job, owner, until)
+	return err
+}
+
+func (p *postgresJobStore) finish(run JobRun, state map[string]string, next *time.Time, keep int) (bool, error) {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return false, err
+	}
+	started, err := time.Parse(time.RFC3339Nano, run.StartedAt)
+	if err != nil {
+		return false, err
+	}
+	finished, err := time.Parse(time.RFC3339Nano, run.FinishedAt)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE jobs SET
+			lease_owner = '',
+			lease_until = NULL,
+			next_run_at = COALESCE($3, next_run_at),
+			state = $4,
+			updated_at = now()
+		WHERE name = $1 AND lease_owner = $2`, run.Job, run.Instance, next, string(raw))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	lost := n == 0
+	if lost {
+		var owner string
+		if err := tx.QueryRow(`SELECT lease_owner FROM jobs WHERE name = $1`, run.Job).Scan(&owner); err != nil && err != sql.ErrNoRows {
+			return false, err
+		}
+		markLost(&run, owner)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO job_runs (job, instance, trigger, started_at, finished_at, status, error, result)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		run.Job, run.Instance, run.Trigger, started, finished, run.Status, run.Error, string(run.Result)); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM job_runs WHERE job = $1 AND id NOT IN (
+			SELECT id FROM job_runs WHERE job = $1 ORDER BY id DESC LIMIT $2)`, run.Job, keep); err != nil {
+		return false, err
+	}
+	return lost, tx.Commit()
+}
+
+func (p *postgresJobStore) updateState(job string, fn func(state map[string]string) bool) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO jobs (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, job); err != nil {
+		return err
+	}
+	var raw string
+	if err := tx.QueryRow(`SELECT state FROM jobs WHERE name = $1 FOR UPDATE`, job).Scan(&raw); err != nil {
+		return err
+	}
+	state := map[string]string{}
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return err
+	}
+	if !fn(state) {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE jobs SET state = $2, updated_at = now() WHERE name = $1`, job, string(data)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p *postgresJobStore) records() (map[string]jobRecord, error) {
+	rows, err := p.db.Query(`SELECT name, next_run_at, lease_owner, lease_until FROM jobs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]jobRecord{}
+	for rows.Next() {
+		var name string
+		var next, until sql.NullTime
+		var rec jobRecord
+		if err := rows.Scan(&name, &next, &rec.LeaseOwner, &until); err != nil {
+			return nil, err
+		}
+		if next.Valid {
+			rec.NextRunAt = &next.Time
+		}
+		if until.Valid {
+			rec.LeaseUntil = &until.Time
+		}
+		out[name] = rec
+	}
+	return out, rows.Err()
+}
+
+func (p *postgresJobStore) runs(job string, limit int) ([]JobRun, error) {
+	rows, err := p.db.Query(`
+		SELECT instance, trigger, started_at, finished_at, status, error, result
+		FROM job_runs WHERE job = $1 ORDER BY id DESC LIMIT $2`, job, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []JobRun
+	for rows.Next() {
+		run := JobRun{Job: job}
+		var started, finished time.Time
+		var result string
+		if err := rows.Scan(&run.Instance, &run.Trigger, &started, &finished, &run.Status, &run.Error, &result); err != nil {
+			return nil, err
+		}
+		run.StartedAt = started.UTC().Format(time.RFC3339Nano)
+		run.FinishedAt = finished.UTC().Format(time.RFC3339Nano)
+		run.Duration = finished.Sub(started).String()
+		if result != "" {
+			run.Result = json.RawMessage(result)
+		}
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+// sheetJobStore keeps job state on a tab of the spreadsheet, for the sheets backend:
+// Job | Part | Next Run At | Lease Owner | Lease Until | Runs | State.
+// A job's state is JSON split over rows of the same job with Part 1, 2, ... when it outgrows
+// one cell. The Sheets API has no conditional write, so a lease is claimed by writing it,
+// waiting for settle and reading it back: of two instances claiming at once, the last
+// write wins and the other sees it and backs off.
+type sheetJobStore struct {
+	client  *config.SheetsClient
+	tab     string
+	settle  time.Duration
+	mu      sync.Mutex
+	ensured bool
+}
+
+// Wait between claiming a lease on the jobs tab and reading it back
+const jobLeaseSettle = 3 * time.Second
+
+// Characters per cell, under the Sheets limit of 50000
+const jobCellLimit = 45000
+
+var jobTabHeaders = []interface{}{"Job", "Part", "Next Run At", "Lease Owner", "Lease Until", "Runs", "State"}
+
+// sheetJobRow is a job as read from the jobs tab
+type sheetJobRow struct {
+	rec   *jobRecord
+	parts []int // Sheet row number of each part (1-based), 0 when missing
+}
+
+func (s *sheetJobStore) describe() string {
+	return fmt.Sprintf("the '%s' tab", s.tab)
+}
+
+// Helper: The Sheets service, with the jobs tab created on first use
+func (s *sheetJobStore) service() (*sheets.Service, error) {
+	srv, err := s.client.Service()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ensured {
+		if err := config.EnsureSheet(srv, s.tab, jobTabHeaders); err != nil {
+			return nil, err
+		}
+		s.ensured = true
+	}
+	return srv, nil
+}
+
+// Helper: Every job on the tab, by name
+func (s *sheetJobStore) read(srv *sheets.Service) (map[string]*sheetJobRow, error) {
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A2:G", s.tab)).Do()
+	if err != nil {
+		return nil, err
+	}
+	jobs := map[string]*sheetJobRow{}
+	chunks := map[string][]string{}
+	for i, row := range resp.Values {
+		cell := func(c int) string {
+			if c < len(row) {
+				return fmt.Sprintf("%v", row[c])
+			}
+			return ""
+		}
+		name := cell(0)
+		part, err := strconv.Atoi(cell(1))
+		if name == "" || err != nil || part < 0 {
+			continue
+		}
+		job := jobs[name]
+		if job == nil {
+			job = &sheetJobRow{rec: &jobRecord{State: map[string]string{}}}
+			jobs[name] = job
+		}
+		for len(job.parts) <= part {
+			job.parts = append(job.parts, 0)
+			chunks[name] = append(chunks[name], "")
+		}
+		if job.parts[part] != 0 {
+			continue // A duplicate row of two instances adding the job at once; the first one counts
+		}
+		job.parts[part] = i + 2
+		chunks[name][part] = cell(6)
+		if part == 0 {
+			job.rec.NextRunAt = parseJobTime(cell(2))
+			job.rec.LeaseOwner = cell(3)
+			job.rec.LeaseUntil = parseJobTime(cell(4))
+			if runs := cell(5); runs != "" {
+				if err := json.Unmarshal([]byte(runs), &job.rec.Runs); err != nil {
+					return nil, fmt.Errorf("job %s: runs: %v", name, err)
+				}
+			}
+		}
+	}
+	for name, job := range jobs {
+		if state := strings.Join(chunks[name], ""); state != "" {
+			if err := json.Unmarshal([]byte(state), &job.rec.State); err != nil {
+				return nil, fmt.Errorf("job %s: state: %v", name, err)
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// Helper: Write cells of part 0 of a job's rows from column first on, adding the row when missing
+func (s *sheetJobStore) writeRow(srv *sheets.Service, jobs map[string]*sheetJobRow, name, first string, values []interface{}) error {
+	if job := jobs[name]; job != nil && len(job.parts) > 0 && job.parts[0] != 0 {
+		rng := fmt.Sprintf("'%s'!%s%d", s.tab, first, job.parts[0])
+		_, err := srv.Spreadsheets.Values.Update(config.Get().SpreadsheetID, rng, &sheets.ValueRange{Values: [][]interface{}{values}}).
+			ValueInputOption("RAW").Do()
+		return err
+	}
+	row := []interface{}{name, "0", "", "", "", "", ""}
+	copy(row[int(first[0]-'A'):], values)
+	_, err := srv.Spreadsheets.Values.Append(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A:A", s.tab), &sheets.ValueRange{Values: [][]interface{}{row}}).
+		ValueInputOption("RAW").InsertDataOption("INSERT_ROWS").Do()
+	if err == nil {
+		// Later writes in this call find the row by reading again
+		delete(jobs, name)
+	}
+	return err
+}
+
+// Helper: Write a job's state over its part rows, adding rows as it grows and blanking those it no longer needs
+func (s *sheetJobStore) writeState(srv *sheets.Service, job *sheetJobRow, name string, state map[string]string) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	var chunks []string
+	for text := string(data); text != ""; {
+		n := min(len(text), jobCellLimit)
+		for n < len(text) && !utf8.RuneStart(text[n]) {
+			n--
+		}
+		chunks = append(chunks, text[:n])
+		text = text[n:]
+	}
+	for part := 0; part < max(len(chunks), len(job.parts)); part++ {
+		chunk := ""
+		if part < len(chunks) {
+			chunk = chunks[part]
+		}
+		if part < len(job.parts) && job.parts[part] != 0 {
+			rng := fmt.Sprintf("'%s'!G%d", s.tab, job.parts[part])
+			if _, err := srv.Spreadsheets.Values.Update(config.Get().SpreadsheetID, rng, &sheets.ValueRange{Values: [][]interface{}{{chunk}}}).
+				ValueInputOption("RAW").Do(); err != nil {
+				return err
+			}
+			continue
+		}
+		row := []interface{}{name, strconv.Itoa(part), "", "", "", "", chunk}
+		if _, err := srv.Spreadsheets.Values.Append(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A:A", s.tab), &sheets.ValueRange{Values: [][]interface{}{row}}).
+			ValueInputOption("RAW").InsertDataOption("INSERT_ROWS").Do(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Helper: A time as kept on the jobs tab, "" for nil
+func formatJobTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// Helper: Parse a time from the jobs tab; nil when empty or unreadable
+func parseJobTime(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// Helper: Runs as JSON for one cell, dropping results and then the oldest runs until it fits
+func encodeJobRuns(runs []JobRun) (string, error) {
+	for {
+		data, err := json.Marshal(runs)
+		if err != nil || len(data) <= jobCellLimit {
+			return string(data), err
+		}
+		stripped := false
+		for i := range runs {
+			if runs[i].Result != nil {
+				runs[i].Result, stripped = nil, true
+			}
+		}
+		if !stripped {
+			if len(runs) <= 1 {
+				return "[]", nil
+			}
+			runs = runs[:len(runs)-1]
+		}
+	}
+}
+
+// Helper: The record of a job, empty when the tab has none
+func (job *sheetJobRow) record() *jobRecord {
+	if job == nil {
+		return &jobRecord{State: map[string]string{}}
+	}
+	return job.rec
+}
+
+func (s *sheetJobStore) register(job string, next *time.Time) error {
+	srv, err := s.service()
+	if err != nil {
+		return err
+	}
+	jobs, err := s.read(srv)
+	if err != nil {
+		return err
+	}
+	rec := jobs[job].record()
+	if next != nil && rec.NextRunAt != nil && !next.Before(*rec.NextRunAt) {
+		next = rec.NextRunAt
+	}
+	return s.writeRow(srv, jobs, job, "C", []interface{}{formatJobTime(next)})
+}
+
+func (s *sheetJobStore) acquire(job, owner string, now, until time.Time, force bool) (map[string]string, bool, error) {
+	srv, err := s.service()
+	if err != nil {
+		return nil, false, err
+	}
+	jobs, err := s.read(srv)
+	if err != nil {
+		return nil, false, err
+	}
+	rec := jobs[job].record()
+	due := force || (rec.NextRunAt != nil && !now.Before(*rec.NextRunAt))
+	if !due || rec.leasedTo(owner, now) {
+		return nil, false, nil
+	}
+	if err := s.writeRow(srv, jobs, job, "D", []interface{}{owner, formatJobTime(&until)}); err != nil {
+		return nil, false, err
+	}
+
+	// Read the claim back once any competing claim has landed
+	time.Sleep(s.settle)
+	jobs, err = s.read(srv)
+	if err != nil {
+		return nil, false, err
+	}
+	rec = jobs[job].record()
+	if rec.LeaseOwner != owner {
+		return nil, false, nil
+	}
+	return copyState(rec.State), true, nil
+}
+
+func (s *sheetJobStore) renew(job, owner string, until time.Time) error {
+	srv, err := s.service()
+	if err != nil {
+		return err
+	}
+	jobs, err := s.read(srv)
+	if err != nil {
+		return err
+	}
+	if jobs[job].record().LeaseOwner != owner {
+		return nil
+	}
+	return s.writeRow(srv, jobs, job, "E", []interface{}{formatJobTime(&until)})
+}
+
+func (s *sheetJobStore) finish(run JobRun, state map[string]string, next *time.Time, keep int) (bool, error) {
+	srv, err := s.service()
+	if err != nil {
+		return false, err
+	}
+	jobs, err := s.read(srv)
+	if err != nil {
+		return false, err
+	}
+	rec := jobs[run.Job].record()
+	lost := rec.LeaseOwner != run.Instance
+	if lost {
+		markLost(&run, rec.LeaseOwner)
+	}
+	runs := append([]JobRun{run}, rec.Runs...)
+	if len(runs) > keep {
+		runs = runs[:keep]
+	}
+	encoded, err := encodeJobRuns(runs)
+	if err != nil {
+		return lost, err
+	}
+	if lost {
+		// The state and schedule belong to the instance that took over
+		return true, s.writeRow(srv, jobs, run.Job, "F", []interface{}{encoded})
+	}
+
+	// Holding the lease, the job has its row
+	if next == nil {
+		next = rec.NextRunAt
+	}
+	if err := s.writeState(srv, jobs[run.Job], run.Job, state); err != nil {
+		return false, err
+	}
+	return false, s.writeRow(srv, jobs, run.Job, "C", []interface{}{formatJobTime(next), "", "", encoded})
+}
+
+func (s *sheetJobStore) updateState(job string, fn func(state map[string]string) bool) error {
+	srv, err := s.service()
+	if err != nil {
+		return err
+	}
+	jobs, err := s.read(srv)
+	if err != nil {
+		return err
+	}
+	if jobs[job] == nil {
+		if err := s.writeRow(srv, jobs, job, "C", nil); err != nil {
+			return err
+		}
+		if jobs, err = s.read(srv); err != nil {
+			return err
+		}
+	}
+	state := copyState(jobs[job].record().State)
+	if !fn(state) {
+		return nil
+	}
+	return s.writeState(srv, jobs[job], job, state)
+}
+
+func (s *sheetJobStore) records() (map[string]jobRecord, error) {
+	srv, err := s.service()
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := s.read(srv)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]jobRecord{}
+	for name, job := range jobs {
+		out[name] = *job.rec
+	}
+	return out, nil
+}
+
+func (s *sheetJobStore) runs(job string, limit int) ([]JobRun, error) {
+	srv, err := s.service()
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := s.read(srv)
+	if err != nil {
+		return nil, err
+	}
+	out := jobs[job].record().Runs
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,24 @@
+// services/jobstore_lock_unix.go
+//go:build unix
+
+package services
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Helper: Hold an exclusive lock on f until unlock is called. A POSIX record lock rather
+// than flock, which not every Unix has.
+func lockFile(f *os.File) (unlock func(), err error) {
+	lk := unix.Flock_t{Type: unix.F_WRLCK, Whence: io.SeekStart}
+	if err := unix.FcntlFlock(f.Fd(), unix.F_SETLKW, &lk); err != nil {
+		return nil, err
+	}
+	return func() {
+		lk.Type = unix.F_UNLCK
+		unix.FcntlFlock(f.Fd(), unix.F_SETLK, &lk)
+	}, nil
+}
@@ -0,0 +1,20 @@
+// services/jobstore_lock_windows.go
+//go:build windows
+
+package services
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// Helper: Hold an exclusive lock on f until unlock is called
+func lockFile(f *os.File) (unlock func(), err error) {
+	h := windows.Handle(f.Fd())
+	ol := new(windows.Overlapped)
+	if err := windows.LockFileEx(h, windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol); err != nil {
+		return nil, err
+	}
+	return func() { windows.UnlockFileEx(h, 0, 1, 0, ol) }, nil
+}
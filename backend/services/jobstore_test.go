@@ -0,0 +1,120 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJobStoresLeaseContention(t *testing.T) {
+	_, client := startFakeSheets(t)
+	stores := map[string]jobStore{
+		"file":  &fileJobStore{path: t.TempDir() + "/jobs.json"},
+		"sheet": &sheetJobStore{client: client, tab: "jobs", settle: 50 * time.Millisecond},
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+			if err := store.register("carry-over", &now); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.updateState("carry-over", func(st map[string]string) bool { st["day"] = "2026-10-15"; return true }); err != nil {
+				t.Fatal(err)
+			}
+
+			state, ok, err := store.acquire("carry-over", "a", now, now.Add(time.Minute), false)
+			if err != nil || !ok || state["day"] != "2026-10-15" {
+				t.Fatalf("a acquire = %v, %v, %v", state, ok, err)
+			}
+			if _, ok, err := store.acquire("carry-over", "b", now.Add(30*time.Second), now.Add(2*time.Minute), true); err != nil || ok {
+				t.Fatalf("b acquired a leased job: %v, %v", ok, err)
+			}
+
+			// A renewal by anyone but the owner is ignored
+			if err := store.renew("carry-over", "b", now.Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok, _ := store.acquire("carry-over", "b", now.Add(2*time.Minute), now.Add(3*time.Minute), false); !ok {
+				t.Fatal("b could not take over the expired lease")
+			}
+
+			// a finishes late: its run is lost and b's schedule and state stay
+			late := now.Add(24 * time.Hour)
+			lost, err := store.finish(JobRun{Job: "carry-over", Instance: "a", Status: JobStatusOK}, map[string]string{"day": "stale"}, &late, 10)
+			if err != nil || !lost {
+				t.Fatalf("a finish = %v, %v; want lost", lost, err)
+			}
+			recs, _ := store.records()
+			if rec := recs["carry-over"]; rec.LeaseOwner != "b" || !rec.NextRunAt.Equal(now) || rec.State["day"] != "2026-10-15" {
+				t.Fatalf("after a lost run: owner %q, next %v, state %v", rec.LeaseOwner, rec.NextRunAt, rec.State)
+			}
+
+			next := now.Add(time.Hour)
+			if lost, err := store.finish(JobRun{Job: "carry-over", Instance: "b", Status: JobStatusOK}, map[string]string{"day": "2026-10-16"}, &next, 10); err != nil || lost {
+				t.Fatalf("b finish = %v, %v", lost, err)
+			}
+			recs, _ = store.records()
+			if rec := recs["carry-over"]; rec.LeaseOwner != "" || !rec.NextRunAt.Equal(next) || rec.State["day"] != "2026-10-16" {
+				t.Fatalf("after b's run: owner %q, next %v, state %v", rec.LeaseOwner, rec.NextRunAt, rec.State)
+			}
+			runs, _ := store.runs("carry-over", 10)
+			if len(runs) != 2 || runs[0].Instance != "b" || runs[0].Status != JobStatusOK || runs[1].Instance != "a" || runs[1].Status != JobStatusLost {
+				t.Fatalf("runs = %+v", runs)
+			}
+		})
+	}
+}
+
+func TestSheetJobStoreOneClaimWins(t *testing.T) {
+	_, client := startFakeSheets(t)
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	setup := &sheetJobStore{client: client, tab: "jobs"}
+	if err := setup.register("archive-rollover", &now); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	won := make([]bool, 4)
+	for i := range won {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store := &sheetJobStore{client: client, tab: "jobs", settle: 200 * time.Millisecond}
+			_, ok, err := store.acquire("archive-rollover", string(rune('a'+i)), now, now.Add(time.Minute), false)
+			if err != nil {
+				t.Error(err)
+			}
+			won[i] = ok
+		}(i)
+	}
+	wg.Wait()
+	winners := 0
+	for _, ok := range won {
+		if ok {
+			winners++
+		}
+	}
+	if winners != 1 {
+		t.Fatalf("%d instances acquired the job, want 1", winners)
+	}
+}
+
+func TestSheetJobStoreSplitsLargeState(t *testing.T) {
+	_, client := startFakeSheets(t)
+	store := &sheetJobStore{client: client, tab: "jobs"}
+	big := strings.Repeat("é", 2*jobCellLimit)
+	for _, size := range []int{len(big), 10} {
+		want := big[:size]
+		if err := store.updateState("sheet-watch", func(st map[string]string) bool { st["snapshot"] = want; return true }); err != nil {
+			t.Fatal(err)
+		}
+		recs, err := store.records()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := recs["sheet-watch"].State["snapshot"]; got != want {
+			t.Fatalf("snapshot of %d bytes read back as %d bytes", len(want), len(got))
+		}
+	}
+}
@@ -0,0 +1,408 @@
+// services/memory.go
+package services
+
+import (
+	"fmt"
+	"go-backend/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memRow mirrors one employee row of a role sheet: column index -> task lines
+type memRow struct {
+	name  string
+	cells map[int][]models.TaskItem
+}
+
+// memSheet mirrors one role sheet: headers[0] is the name column, the rest are date headers
+type memSheet struct {
+	title   string
+	headers []string
+	rows    []*memRow
+}
+
+// MemoryStore is a process-local Store with the same behaviour as the Sheets backend
+type MemoryStore struct {
+	env       *Env
+	mu        sync.RWMutex
+	sheets    []*memSheet
+	employees []EmployeeMetadata
+	logs      []DailyLog
+}
+
+// NewMemoryStore returns an empty MemoryStore with the role sheets of env
+func NewMemoryStore(env *Env) *MemoryStore {
+	m := &MemoryStore{env: env}
+	for _, title := range env.roleSheets() {
+		m.sheets = append(m.sheets, &memSheet{title: title, headers: []string{"Name"}})
+	}
+	return m
+}
+
+// EnsureRoleSheet adds an empty role sheet for a new team
+func (m *MemoryStore) EnsureRoleSheet(title string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.findSheet(title) == nil {
+		m.sheets = append(m.sheets, &memSheet{title: title, headers: []string{"Name"}})
+	}
+	return nil
+}
+
+// Helper: Find sheet by title (case-insensitive)
+func (m *MemoryStore) findSheet(title string) *memSheet {
+	for _, sheet := range m.sheets {
+		if strings.EqualFold(sheet.title, title) {
+			return sheet
+		}
+	}
+	return nil
+}
+
+// Helper: Find employee row by name
+func (sh *memSheet) findRow(employeeName string) *memRow {
+	for _, row := range sh.rows {
+		if namesMatch(row.name, employeeName) {
+			return row
+		}
+	}
+	return nil
+}
+
+// Helper: Build history (newest column first), at most limit days (counted on e's calendar) when limit > 0
+func (sh *memSheet) history(e *Env, row *memRow, limit int) []models.DayTasks {
+	headers := decodeHeaders(sh.headers, models.Today())
+	var hist []models.DayTasks
+	counted := 0
+	for cIdx := len(sh.headers) - 1; cIdx >= 1; cIdx-- {
+		if limit > 0 && counted >= limit {
+			break
+		}
+		items := row.cells[cIdx]
+		if len(items) == 0 {
+			continue
+		}
+		date, label := headers.column(cIdx)
+		hist = append(hist, newDayTasks(date, label, items))
+		if e.countsTowardLatest(row.name, date) {
+			counted++
+		}
+	}
+	return hist
+}
+
+// GetLatestTasks returns an employee's full history across the role sheets
+func (m *MemoryStore) GetLatestTasks(employeeName string) (models.EmployeeTasksResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var allHistory []models.DayTasks
+	var foundName string
+	var foundSheet string
+	var statuses []models.SheetStatus
+
+	for _, title := range m.env.roleSheets() {
+		sheet := m.findSheet(title)
+		if sheet == nil {
+			statuses = append(statuses, sheetStatus(title, errSheetMissing))
+			continue
+		}
+		statuses = append(statuses, sheetStatus(sheet.title, nil))
+		row := sheet.findRow(employeeName)
+		if row == nil {
+			continue
+		}
+		hist := sheet.history(m.env, row, 0)
+		if len(hist) == 0 {
+			continue
+		}
+		allHistory = append(allHistory, hist...)
+		if foundName == "" {
+			foundName = row.name
+			foundSheet = sheet.title
+		}
+	}
+
+	return m.env.withSheetStatuses(models.EmployeeTasksResponse{
+		EmployeeName: foundName,
+		SheetName:    foundSheet,
+		History:      allHistory,
+	}, len(allHistory) > 0, employeeName, statuses)
+}
+
+// GetAllEmployeesLatestTasks returns the last 7 populated days of every employee
+func (m *MemoryStore) GetAllEmployeesLatestTasks() (models.TeamTasksResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var allEmployees []models.EmployeeTasksResponse
+	var statuses []models.SheetStatus
+	for _, title := range m.env.roleSheets() {
+		sheet := m.findSheet(title)
+		if sheet == nil {
+			statuses = append(statuses, sheetStatus(title, errSheetMissing))
+			continue
+		}
+		statuses = append(statuses, sheetStatus(sheet.title, nil))
+		for _, row := range sheet.rows {
+			if row.name == "" {
+				continue
+			}
+			allEmployees = append(allEmployees, models.EmployeeTasksResponse{
+				EmployeeName: row.name,
+				SheetName:    sheet.title,
+				History:      sheet.history(m.env, row, latestDays),
+			})
+		}
+	}
+
+	return newTeamTasks(allEmployees, statuses)
+}
+
+// AddTask updates or creates tasks
+func (m *MemoryStore) AddTask(req models.TaskRequest) error {
+	items, err := validateTaskItems(req.Tasks)
+	if err != nil {
+		return err
+	}
+	req.Tasks = items
+
+	// 1-2. Determine Target Date and Validate Allowed Edit Window
+	targetDate, err := m.env.resolveTargetDate(m, req)
+	if err != nil {
+		return err
+	}
+
+	// Published once the lock is released
+	var before, after []models.TaskItem
+	var loc TaskLocation
+	defer func() {
+		if after != nil {
+			m.env.publishCellChange(loc, req.Actor, EventSourceAPI, before, after)
+		}
+	}()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 3. Determine Target Sheet
+	role, err := m.env.resolveRole(req.Role)
+	if err != nil {
+		return err
+	}
+	sheet := m.findSheet(role)
+	if sheet == nil {
+		if req.Role == "" {
+			return fmt.Errorf("default sheet '%s' not found", role)
+		}
+		return errUnknownRole(req.Role)
+	}
+
+	// 4. Find or Create Employee Row
+	row := sheet.findRow(req.EmployeeName)
+	if row == nil {
+		row = &memRow{name: req.EmployeeName, cells: map[int][]models.TaskItem{}}
+		sheet.rows = append(sheet.rows, row)
+	}
+
+	// 5. Find or Create Date Column
+	targetColIndex := decodeHeaders(sheet.headers, models.Today()).find(targetDate)
+	if targetColIndex == -1 {
+		sheet.headers = append(sheet.headers, targetDate.Header())
+		targetColIndex = len(sheet.headers) - 1
+	}
+
+	// 6. Merge Tasks into the Cell
+	before = append([]models.TaskItem(nil), row.cells[targetColIndex]...)
+	row.cells[targetColIndex] = mergeTasks(row.cells[targetColIndex], req.Tasks)
+	after, loc = row.cells[targetColIndex], TaskLocation{EmployeeName: req.EmployeeName, Sheet: sheet.title, Date: targetDate}
+
+	return nil
+}
+
+// FindTask locates a task by ID in the role sheets
+func (m *MemoryStore) FindTask(id string) (TaskLocation, error) {
+	return m.findTask(id)
+}
+
+// UpdateTask renames a task and/or changes its status
+func (m *MemoryStore) UpdateTask(id string, update TaskUpdate, actor string) (TaskLocation, error) {
+	return m.env.updateTask(m, id, update, actor)
+}
+
+// DeleteTask removes a task from its cell
+func (m *MemoryStore) DeleteTask(id string, actor string) (TaskLocation, error) {
+	return m.env.deleteTask(m, id, actor)
+}
+
+// UpdateCellTask renames and/or recolors one line of an employee/day cell
+func (m *MemoryStore) UpdateCellTask(cell CellRef, line string, update TaskUpdate) (models.DayTasks, error) {
+	return m.env.updateCellTask(m, cell, line, update)
+}
+
+// DeleteCellTask removes one line of an employee/day cell
+func (m *MemoryStore) DeleteCellTask(cell CellRef, line string) (models.DayTasks, error) {
+	return m.env.deleteCellTask(m, cell, line)
+}
+
+// ReorderCell puts the lines of an employee/day cell in a new order
+func (m *MemoryStore) ReorderCell(cell CellRef, order []string) (models.DayTasks, error) {
+	return m.env.reorderCell(m, cell, order)
+}
+
+// CarryOver copies an employee's unfinished tasks into a day, keeping their IDs and statuses
+func (m *MemoryStore) CarryOver(req CarryOverRequest) (CarryOverResult, error) {
+	return m.env.carryOver(m, req)
+}
+
+// Helper: Scan the role sheets for the cell holding a task (the latest one for a carried-over task)
+func (m *MemoryStore) findTask(id string) (TaskLocation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found []TaskLocation
+	for _, title := range m.env.roleSheets() {
+		sheet := m.findSheet(title)
+		if sheet == nil {
+			continue
+		}
+		headers := decodeHeaders(sheet.headers, models.Today())
+		for _, row := range sheet.rows {
+			for cIdx, items := range row.cells {
+				if i := taskIndex(items, id); i != -1 {
+					date, label := headers.column(cIdx)
+					found = append(found, TaskLocation{TaskItem: items[i], EmployeeName: row.name, Sheet: sheet.title, Date: date, Label: label, Position: i})
+				}
+			}
+		}
+	}
+	return latestLocation(id, found)
+}
+
+// Helper: Replace the lines of one existing cell
+func (m *MemoryStore) editCell(loc TaskLocation, fn func(items []models.TaskItem) ([]models.TaskItem, error)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sheet := m.findSheet(loc.Sheet)
+	if sheet == nil {
+		return errUnknownRole(loc.Sheet)
+	}
+	row := sheet.findRow(loc.EmployeeName)
+	col := decodeHeaders(sheet.headers, models.Today()).findKey(dayKey(loc.Date, loc.Label))
+	if row == nil || col == -1 {
+		return newError(ErrNotFound, "cell_not_found", "%s has no cell for %s in %s", loc.EmployeeName, loc.Label, sheet.title)
+	}
+
+	// fn works on a copy so a failed edit leaves the cell alone
+	items, err := fn(append([]models.TaskItem(nil), row.cells[col]...))
+	if err != nil {
+		return err
+	}
+	row.cells[col] = items
+	return nil
+}
+
+// Helper: Create the employee row and day column of a cell when missing
+func (m *MemoryStore) ensureCell(loc TaskLocation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sheet := m.findSheet(loc.Sheet)
+	if sheet == nil {
+		return errUnknownRole(loc.Sheet)
+	}
+	if sheet.findRow(loc.EmployeeName) == nil {
+		sheet.rows = append(sheet.rows, &memRow{name: loc.EmployeeName, cells: map[int][]models.TaskItem{}})
+	}
+	if decodeHeaders(sheet.headers, models.Today()).find(loc.Date) == -1 {
+		sheet.headers = append(sheet.headers, loc.Date.Header())
+	}
+	return nil
+}
+
+// GetAllEmployeesMetadata lists the employee records
+func (m *MemoryStore) GetAllEmployeesMetadata() ([]EmployeeMetadata, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	employees := make([]EmployeeMetadata, len(m.employees))
+	copy(employees, m.employees)
+	return employees, nil
+}
+
+// GetAllDailyLogs lists the daily log records
+func (m *MemoryStore) GetAllDailyLogs() ([]DailyLog, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	logs := make([]DailyLog, len(m.logs))
+	copy(logs, m.logs)
+	return logs, nil
+}
+
+// UpsertEmployeeMetadata updates or inserts employee info
+func (m *MemoryStore) UpsertEmployeeMetadata(name, timezone string) error {
+	if err := ValidateTimezone(timezone); err != nil {
+		return err
+	}
+	defer m.env.forgetEmployeeZones()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cleanName := strings.TrimSpace(name)
+	now := time.Now().Format(time.RFC3339)
+
+	for i, emp := range m.employees {
+		if strings.EqualFold(emp.EmployeeName, cleanName) {
+			m.employees[i].UpdatedAt = &now
+			if timezone != "" {
+				m.employees[i].Timezone = timezone
+			}
+			return nil
+		}
+	}
+
+	m.employees = append(m.employees, EmployeeMetadata{
+		ID:           fmt.Sprintf("%d", len(m.employees)+2), // Row number, as in the sheet
+		EmployeeName: cleanName,
+		CreatedAt:    now,
+		UpdatedAt:    &now,
+		Timezone:     timezone,
+	})
+	m.env.publishEmployeeCreated(cleanName)
+	return nil
+}
+
+// UpsertDailyLog updates or inserts a log for an employee and date
+func (m *MemoryStore) UpsertDailyLog(name, date string) error {
+	// Before locking: the zone lookup reads the employee records
+	now, nowUTC, zone := m.env.logTimestamps(m, name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cleanName := strings.TrimSpace(name)
+	cleanDate := strings.TrimSpace(date)
+
+	for i, l := range m.logs {
+		if strings.EqualFold(l.EmployeeName, cleanName) && strings.EqualFold(l.TaskDate, cleanDate) {
+			m.logs[i].UpdatedAt = now
+			m.logs[i].UpdatedAtUTC = nowUTC
+			return nil
+		}
+	}
+
+	m.logs = append(m.logs, DailyLog{
+		EmployeeName: cleanName,
+		TaskDate:     cleanDate,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Timezone:     zone,
+		CreatedAtUTC: nowUTC,
+		UpdatedAtUTC: nowUTC,
+	})
+	return nil
+}
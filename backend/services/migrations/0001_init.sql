@@ -0,0 +1,64 @@
+-- Roles map to the role sheets (DEV, Managers)
+CREATE TABLE roles (
+    id         SERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX roles_name_key ON roles (lower(name));
+
+INSERT INTO roles (name) VALUES ('DEV'), ('Managers');
+
+-- Employees replace the "database" tab
+CREATE TABLE employees (
+    id         SERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX employees_name_key ON employees (lower(name));
+
+-- Role membership replaces the employee rows of a role sheet
+CREATE TABLE role_members (
+    id          SERIAL PRIMARY KEY,
+    role_id     INTEGER NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+    UNIQUE (role_id, employee_id)
+);
+
+-- Role days replace the date header columns of a role sheet
+CREATE TABLE role_days (
+    id       SERIAL PRIMARY KEY,
+    role_id  INTEGER NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    label    TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    UNIQUE (role_id, position)
+);
+CREATE UNIQUE INDEX role_days_label_key ON role_days (role_id, lower(label));
+
+CREATE TABLE task_statuses (
+    name TEXT PRIMARY KEY
+);
+
+INSERT INTO task_statuses (name) VALUES ('todo'), ('pending'), ('complete');
+
+-- Day tasks replace the lines of a rich-text cell
+CREATE TABLE day_tasks (
+    id          BIGSERIAL PRIMARY KEY,
+    role_day_id INTEGER NOT NULL REFERENCES role_days (id) ON DELETE CASCADE,
+    employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+    position    INTEGER NOT NULL,
+    task        TEXT NOT NULL,
+    status      TEXT NOT NULL REFERENCES task_statuses (name),
+    UNIQUE (role_day_id, employee_id, position)
+);
+CREATE INDEX day_tasks_employee_idx ON day_tasks (employee_id);
+
+-- Daily logs replace the "database_logs" tab
+CREATE TABLE daily_logs (
+    id          SERIAL PRIMARY KEY,
+    employee_id INTEGER NOT NULL REFERENCES employees (id) ON DELETE CASCADE,
+    task_date   TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX daily_logs_employee_date_key ON daily_logs (employee_id, lower(task_date));
@@ -0,0 +1,15 @@
+-- Per-cell state of the Sheets <-> SQL mirror
+CREATE TABLE sync_cells (
+    id             SERIAL PRIMARY KEY,
+    role_name      TEXT NOT NULL,
+    employee_name  TEXT NOT NULL,
+    day_label      TEXT NOT NULL,
+    status         TEXT NOT NULL,
+    synced_hash    TEXT NOT NULL DEFAULT '',
+    sheet_hash     TEXT NOT NULL DEFAULT '',
+    db_hash        TEXT NOT NULL DEFAULT '',
+    last_error     TEXT NOT NULL DEFAULT '',
+    last_synced_at TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX sync_cells_key ON sync_cells (lower(role_name), lower(employee_name), lower(day_label));
@@ -0,0 +1,3 @@
+-- Employee time zones; daily log timestamps are rendered in the zone recorded with them
+ALTER TABLE employees ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
+ALTER TABLE daily_logs ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
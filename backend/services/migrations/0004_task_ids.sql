@@ -0,0 +1,6 @@
+-- Stable task IDs; a day is rewritten on every change, so the serial id does not survive edits
+ALTER TABLE day_tasks ADD COLUMN uid TEXT;
+UPDATE day_tasks SET uid = 't' || lpad(to_hex(id), 10, '0');
+ALTER TABLE day_tasks ALTER COLUMN uid SET NOT NULL;
+-- Not unique: IDs pulled from the sheet are only unique per employee and day
+CREATE INDEX day_tasks_uid_idx ON day_tasks (uid);
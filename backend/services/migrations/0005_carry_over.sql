@@ -0,0 +1,2 @@
+-- Day an unfinished task was first carried over from ("2006-01-02"), '' for tasks written that day
+ALTER TABLE day_tasks ADD COLUMN carried_from TEXT NOT NULL DEFAULT '';
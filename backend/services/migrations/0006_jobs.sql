@@ -0,0 +1,23 @@
+-- Background jobs: the next scheduled run, the lease of the instance running it and job state
+CREATE TABLE jobs (
+    name        TEXT PRIMARY KEY,
+    next_run_at TIMESTAMPTZ,             -- NULL: runs only on request
+    lease_owner TEXT NOT NULL DEFAULT '',
+    lease_until TIMESTAMPTZ,
+    state       TEXT NOT NULL DEFAULT '{}', -- JSON object kept between runs
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Run history, trimmed to jobs.history runs per job
+CREATE TABLE job_runs (
+    id          BIGSERIAL PRIMARY KEY,
+    job         TEXT NOT NULL,
+    instance    TEXT NOT NULL,
+    trigger     TEXT NOT NULL,
+    started_at  TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    status      TEXT NOT NULL,
+    error       TEXT NOT NULL DEFAULT '',
+    result      TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX job_runs_job_idx ON job_runs (job, id DESC);
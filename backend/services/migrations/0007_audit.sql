@@ -0,0 +1,19 @@
+-- Append-only audit trail: one row per task change, whether made through the API or in the sheet
+CREATE TABLE audit_log (
+    seq           BIGSERIAL PRIMARY KEY,
+    event_id      TEXT NOT NULL UNIQUE,     -- The change's event ID; a retried write is not recorded twice
+    at            TIMESTAMPTZ NOT NULL,
+    actor         TEXT NOT NULL DEFAULT '', -- Empty for edits made in the sheet
+    source        TEXT NOT NULL,            -- 'api' or 'sheet'
+    action        TEXT NOT NULL,            -- Event type, e.g. 'task.status_changed'
+    employee_name TEXT NOT NULL,
+    sheet         TEXT NOT NULL DEFAULT '',
+    task_date     TEXT NOT NULL DEFAULT '', -- "2006-01-02"; empty when the column header is not a date
+    task_id       TEXT NOT NULL DEFAULT '',
+    task          TEXT NOT NULL DEFAULT '',
+    old_task      TEXT NOT NULL DEFAULT '',
+    old_status    TEXT NOT NULL DEFAULT '',
+    new_status    TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX audit_log_employee_idx ON audit_log (lower(employee_name), seq DESC);
+CREATE INDEX audit_log_task_idx ON audit_log (task_id, seq DESC);
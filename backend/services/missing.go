@@ -0,0 +1,134 @@
+// services/missing.go
+package services
+
+import (
+	"go-backend/models"
+	"strings"
+)
+
+// MissingUpdate is an employee with no task lines in their column for a working day
+type MissingUpdate struct {
+	EmployeeName string       `json:"employee_name"`
+	Sheet        string       `json:"sheet"`
+	Date         models.Date  `json:"date"`
+	LastUpdate   *models.Date `json:"last_update"`              // Latest day with task lines; null when there is none
+	LastLoggedAt string       `json:"last_logged_at,omitempty"` // Latest database_logs write, in the employee's zone
+}
+
+// MissingSheet is the missing-update check of one role sheet
+type MissingSheet struct {
+	models.SheetStatus
+	Checked int             `json:"checked"` // Employees on a working day
+	Missing []MissingUpdate `json:"missing"`
+}
+
+// MissingReport lists, per role sheet, who has not written their update for a day
+type MissingReport struct {
+	Date    *models.Date   `json:"date"` // null: each employee's today
+	Sheets  []MissingSheet `json:"sheets"`
+	Partial bool           `json:"partial"` // Some sheets failed; they are listed with ok false
+}
+
+// MissingUpdates finds the employees with an empty column on a working day: date, or each employee's
+// today when nil. role limits the check to one role sheet ("" checks all). Days before the team view's
+// history cannot be checked.
+func (e *Env) MissingUpdates(store Store, date *models.Date, role string) (MissingReport, error) {
+	sheet := ""
+	if role != "" {
+		s, err := e.resolveRole(role)
+		if err != nil {
+			return MissingReport{}, err
+		}
+		sheet = s
+	}
+	if date != nil {
+		today := models.Today()
+		if today.Before(*date) {
+			return MissingReport{}, newError(ErrValidation, "validation_failed", "date must not be in the future")
+		}
+		if date.Before(e.windowStart("", today, latestDays-1, true)) {
+			return MissingReport{}, newError(ErrValidation, "validation_failed", "date must be within the last %d working days", latestDays)
+		}
+	}
+
+	team, err := store.GetAllEmployeesLatestTasks()
+	if err != nil {
+		return MissingReport{}, err
+	}
+	logged, err := lastLogged(store)
+	if err != nil {
+		return MissingReport{}, err
+	}
+
+	report := MissingReport{Date: date, Sheets: []MissingSheet{}, Partial: team.Partial}
+	bySheet := map[string]int{}
+	for _, st := range team.Sheets {
+		if sheet != "" && !strings.EqualFold(st.Sheet, sheet) {
+			continue
+		}
+		bySheet[strings.ToLower(st.Sheet)] = len(report.Sheets)
+		report.Sheets = append(report.Sheets, MissingSheet{SheetStatus: st, Missing: []MissingUpdate{}})
+	}
+
+	for _, emp := range team.Employees {
+		i, ok := bySheet[strings.ToLower(emp.SheetName)]
+		if !ok {
+			continue
+		}
+		d := e.EmployeeToday(store, emp.EmployeeName)
+		if date != nil {
+			d = *date
+		}
+		if !e.IsWorkingDay(emp.EmployeeName, d) {
+			continue
+		}
+
+		report.Sheets[i].Checked++
+		if hasUpdate(emp.History, d) {
+			continue
+		}
+		missing := MissingUpdate{
+			EmployeeName: emp.EmployeeName,
+			Sheet:        emp.SheetName,
+			Date:         d,
+			LastLoggedAt: logged[strings.ToLower(emp.EmployeeName)],
+		}
+		if last, ok := lastPopulatedDay(emp.History, d.AddDays(1)); ok {
+			missing.LastUpdate = &last.Date
+		}
+		report.Sheets[i].Missing = append(report.Sheets[i].Missing, missing)
+	}
+	return report, nil
+}
+
+// Helper: Whether a history has task lines on d
+func hasUpdate(history []models.DayTasks, d models.Date) bool {
+	for _, day := range history {
+		if day.Date == d && len(day.Tasks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper: Latest database_logs write per employee (lower-cased name), in their zone
+func lastLogged(store LogStore) (map[string]string, error) {
+	logs, err := store.GetAllDailyLogs()
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	latestUTC := map[string]string{}
+	for _, l := range logs {
+		key := strings.ToLower(strings.TrimSpace(l.EmployeeName))
+		at, atUTC := l.UpdatedAt, l.UpdatedAtUTC
+		if at == "" {
+			at, atUTC = l.CreatedAt, l.CreatedAtUTC
+		}
+		// UTC timestamps compare as strings; rows written before zones were recorded have none
+		if atUTC >= latestUTC[key] || out[key] == "" {
+			out[key], latestUTC[key] = at, atUTC
+		}
+	}
+	return out, nil
+}
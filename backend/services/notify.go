@@ -0,0 +1,199 @@
+// services/notify.go
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// How long a notifier waits for the mail server or webhook
+const notifyTimeout = 10 * time.Second
+
+// Notifier sends reminders through one channel
+type Notifier interface {
+	Name() string
+	Type() string
+	Target() string // Where reminders go, without credentials
+	Notify(reminders []MissingUpdate) error
+}
+
+// NewNotifiers builds the notifiers named in the configuration
+func NewNotifiers(cfgs []config.NotifierConfig) ([]Notifier, error) {
+	client := &http.Client{Timeout: notifyTimeout}
+	var out []Notifier
+	for _, c := range cfgs {
+		switch strings.ToLower(c.Type) {
+		case "smtp":
+			out = append(out, &smtpNotifier{cfg: c})
+		case "webhook":
+			out = append(out, &webhookNotifier{cfg: c, client: client})
+		case "slack":
+			out = append(out, &slackNotifier{webhookNotifier{cfg: c, client: client}})
+		default:
+			return nil, fmt.Errorf("notifier %s: unknown type %q", c.Name, c.Type)
+		}
+	}
+	return out, nil
+}
+
+// Helper: One line per reminder, grouped by day, e.g. "2026-10-16: Alice (DEV), Bob (Managers)"
+func reminderLines(reminders []MissingUpdate) []string {
+	byDate := map[models.Date][]string{}
+	var dates []models.Date
+	for _, r := range reminders {
+		if _, ok := byDate[r.Date]; !ok {
+			dates = append(dates, r.Date)
+		}
+		byDate[r.Date] = append(byDate[r.Date], fmt.Sprintf("%s (%s)", r.EmployeeName, r.Sheet))
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	lines := make([]string, 0, len(dates))
+	for _, d := range dates {
+		lines = append(lines, fmt.Sprintf("%s: %s", d, strings.Join(byDate[d], ", ")))
+	}
+	return lines
+}
+
+// smtpNotifier mails a digest to cfg.To and a personal reminder to each employee in cfg.Emails
+type smtpNotifier struct {
+	cfg config.NotifierConfig
+}
+
+func (n *smtpNotifier) Name() string   { return n.cfg.Name }
+func (n *smtpNotifier) Type() string   { return "smtp" }
+func (n *smtpNotifier) Target() string { return n.cfg.Addr }
+
+func (n *smtpNotifier) Notify(reminders []MissingUpdate) error {
+	if len(n.cfg.To) > 0 {
+		body := "No stand-up update yet:\r\n\r\n" + strings.Join(reminderLines(reminders), "\r\n") + "\r\n"
+		if err := n.send(n.cfg.To, "Missing stand-up updates", body); err != nil {
+			return err
+		}
+	}
+	for _, r := range reminders {
+		addr := lookupFold(n.cfg.Emails, r.EmployeeName)
+		if addr == "" {
+			continue
+		}
+		body := fmt.Sprintf("Hi %s,\r\n\r\nYour %s column has no update for %s yet.\r\n", r.EmployeeName, r.Sheet, r.Date.Header())
+		if err := n.send([]string{addr}, fmt.Sprintf("Stand-up reminder for %s", r.Date), body); err != nil {
+			return fmt.Errorf("%s: %v", r.EmployeeName, err)
+		}
+	}
+	return nil
+}
+
+// Helper: Send one plain-text mail; credentials are only offered when a username is set
+func (n *smtpNotifier) send(to []string, subject, body string) error {
+	var auth smtp.Auth
+	if n.cfg.Username != "" {
+		host, _, err := net.SplitHostPort(n.cfg.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(body)
+	return smtp.SendMail(n.cfg.Addr, auth, n.cfg.From, to, msg.Bytes())
+}
+
+// Helper: Value of a map keyed by employee name, ignoring case
+func lookupFold(m map[string]string, name string) string {
+	for k, v := range m {
+		if strings.EqualFold(strings.TrimSpace(k), strings.TrimSpace(name)) {
+			return v
+		}
+	}
+	return ""
+}
+
+// webhookNotifier posts the reminders as JSON: {"event": "standup.missing", "sent_at": ..., "missing": [...]}
+type webhookNotifier struct {
+	cfg    config.NotifierConfig
+	client *http.Client
+}
+
+func (n *webhookNotifier) Name() string   { return n.cfg.Name }
+func (n *webhookNotifier) Type() string   { return "webhook" }
+func (n *webhookNotifier) Target() string { return redactURL(n.cfg.URL) }
+
+func (n *webhookNotifier) Notify(reminders []MissingUpdate) error {
+	return n.post(struct {
+		Event   string          `json:"event"`
+		SentAt  string          `json:"sent_at"`
+		Missing []MissingUpdate `json:"missing"`
+	}{"standup.missing", time.Now().UTC().Format(time.RFC3339), reminders})
+}
+
+// Helper: POST a JSON payload with the configured headers; anything but 2xx fails
+func (n *webhookNotifier) post(payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, n.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s answered %d: %s", redactURL(n.cfg.URL), resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// slackNotifier posts to a Slack-compatible incoming webhook: {"text": "..."}
+type slackNotifier struct {
+	webhookNotifier
+}
+
+func (n *slackNotifier) Type() string { return "slack" }
+
+func (n *slackNotifier) Notify(reminders []MissingUpdate) error {
+	lines := reminderLines(reminders)
+	for i, l := range lines {
+		lines[i] = "• " + l
+	}
+	return n.post(map[string]string{"text": "No stand-up update yet:\n" + strings.Join(lines, "\n")})
+}
+
+// Helper: A webhook URL without its path and query, which often carry the secret
+func redactURL(raw string) string {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		return raw
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	if _, h, ok := strings.Cut(host, "@"); ok {
+		host = h
+	}
+	return scheme + "://" + host + "/..."
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"encoding/json"
+	"go-backend/config"
+	"go-backend/fakenotify"
+	"go-backend/models"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Helper: A fake mail server and webhook receiver, shut down with the test
+func startFakeNotify(t *testing.T) (srv *fakenotify.Server, smtpAddr, httpURL string) {
+	t.Helper()
+	srv = fakenotify.NewServer()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.ServeSMTP(ln)
+	hs := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		ln.Close()
+		hs.Close()
+	})
+	return srv, ln.Addr().String(), hs.URL
+}
+
+func sampleReminders(t *testing.T) []MissingUpdate {
+	t.Helper()
+	d, err := models.ParseDate("2026-10-16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []MissingUpdate{
+		{EmployeeName: "Ann", Sheet: "DEV", Date: d},
+		{EmployeeName: "Bob", Sheet: "Managers", Date: d},
+	}
+}
+
+func TestNotifiersSendReminders(t *testing.T) {
+	srv, smtpAddr, httpURL := startFakeNotify(t)
+
+	tests := []struct {
+		name  string
+		cfg   config.NotifierConfig
+		check func(t *testing.T, got []fakenotify.Message)
+	}{
+		{
+			name: "smtp",
+			cfg: config.NotifierConfig{Name: "mail", Type: "smtp", Addr: smtpAddr, From: "standup@example.com",
+				To: []string{"lead@example.com"}, Emails: map[string]string{"ann": "ann@example.com"}},
+			check: func(t *testing.T, got []fakenotify.Message) {
+				if len(got) != 2 {
+					t.Fatalf("got %d mails, want the digest and one personal reminder", len(got))
+				}
+				digest, personal := got[0], got[1]
+				if digest.To[0] != "lead@example.com" || !strings.Contains(digest.Body, "2026-10-16: Ann (DEV), Bob (Managers)") {
+					t.Errorf("digest to %v:\n%s", digest.To, digest.Body)
+				}
+				if personal.To[0] != "ann@example.com" || !strings.Contains(personal.Body, "Hi Ann,") ||
+					!strings.Contains(personal.Body, "Subject: Stand-up reminder for 2026-10-16") {
+					t.Errorf("personal reminder to %v:\n%s", personal.To, personal.Body)
+				}
+			},
+		},
+		{
+			name: "webhook",
+			cfg:  config.NotifierConfig{Name: "hook", Type: "webhook", URL: httpURL + "/hook", Headers: map[string]string{"X-Token": "s3cret"}},
+			check: func(t *testing.T, got []fakenotify.Message) {
+				if len(got) != 1 || got[0].Path != "/hook" || got[0].Headers["X-Token"] != "s3cret" {
+					t.Fatalf("got %+v, want one POST to /hook with the configured header", got)
+				}
+				var body struct {
+					Event   string          `json:"event"`
+					Missing []MissingUpdate `json:"missing"`
+				}
+				if err := json.Unmarshal([]byte(got[0].Body), &body); err != nil {
+					t.Fatal(err)
+				}
+				if body.Event != "standup.missing" || len(body.Missing) != 2 || body.Missing[1].EmployeeName != "Bob" {
+					t.Errorf("payload %s", got[0].Body)
+				}
+			},
+		},
+		{
+			name: "slack",
+			cfg:  config.NotifierConfig{Name: "chat", Type: "slack", URL: httpURL + "/slack"},
+			check: func(t *testing.T, got []fakenotify.Message) {
+				var body map[string]string
+				if len(got) != 1 || json.Unmarshal([]byte(got[0].Body), &body) != nil {
+					t.Fatalf("got %+v, want one JSON POST", got)
+				}
+				if want := "No stand-up update yet:\n• 2026-10-16: Ann (DEV), Bob (Managers)"; body["text"] != want {
+					t.Errorf("text = %q, want %q", body["text"], want)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifiers, err := NewNotifiers([]config.NotifierConfig{tt.cfg})
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := len(srv.Messages())
+			if err := notifiers[0].Notify(sampleReminders(t)); err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			tt.check(t, srv.Messages()[before:])
+		})
+	}
+}
+
+func TestWebhookNotifierFailsOnErrorStatus(t *testing.T) {
+	srv, _, httpURL := startFakeNotify(t)
+	srv.SetStatus(http.StatusInternalServerError)
+
+	notifiers, err := NewNotifiers([]config.NotifierConfig{{Name: "hook", Type: "webhook", URL: httpURL + "/secret-path"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = notifiers[0].Notify(sampleReminders(t))
+	if err == nil || !strings.Contains(err.Error(), "answered 500") {
+		t.Fatalf("Notify = %v, want the 500 reported", err)
+	}
+	if strings.Contains(err.Error(), "secret-path") {
+		t.Errorf("error %q shows the webhook path", err)
+	}
+}
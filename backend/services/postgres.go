@@ -0,0 +1,958 @@
+// services/postgres.go
+package services
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Arbitrary key for pg_advisory_lock so only one instance migrates at a time
+const migrationLockID = 7456001
+
+// PostgresStore is the PostgreSQL backed Store
+type PostgresStore struct {
+	db  *sql.DB
+	env *Env
+}
+
+// NewPostgresStore connects to databaseURL and applies pending migrations
+func NewPostgresStore(databaseURL string, env *Env) (*PostgresStore, error) {
+	if databaseURL == "" {
+		return nil, fmt.Errorf("postgres backend requires a database URL")
+	}
+
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to reach database: %v", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to apply migrations: %v", err)
+	}
+
+	// Configured role sheets beyond the seeded ones become roles too
+	for _, role := range config.Get().RoleSheets {
+		if _, err := db.Exec(`INSERT INTO roles (name) VALUES ($1) ON CONFLICT DO NOTHING`, role); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to register role '%s': %v", role, err)
+		}
+	}
+
+	return &PostgresStore{db: db, env: env}, nil
+}
+
+// migrate applies every embedded migration not yet recorded in schema_migrations
+func migrate(db *sql.DB) error {
+	// Advisory locks are per session, so hold one connection for the whole run
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+
+		var applied bool
+		if err := conn.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		body, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(body)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %v", version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("Applied migration %s", version)
+	}
+	return nil
+}
+
+// Helper: Find or create an employee by name (case-insensitive)
+func ensureEmployee(tx *sql.Tx, name string) (int, error) {
+	cleanName := strings.TrimSpace(name)
+	if _, err := tx.Exec(`INSERT INTO employees (name) VALUES ($1) ON CONFLICT ((lower(name))) DO NOTHING`, cleanName); err != nil {
+		return 0, err
+	}
+	var id int
+	err := tx.QueryRow(`SELECT id FROM employees WHERE lower(name) = lower($1)`, cleanName).Scan(&id)
+	return id, err
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// roleDay is one decoded day of a role
+type roleDay struct {
+	date  models.Date
+	label string
+}
+
+// Helper: Decode the day labels of a role in position (column) order.
+// ids[i] is the role_days ID of headers column i; column 0 stands for the name column.
+func roleDayHeaders(q querier, roleID int) ([]int, dateHeaders, error) {
+	rows, err := q.Query(`SELECT id, label FROM role_days WHERE role_id = $1 ORDER BY position`, roleID)
+	if err != nil {
+		return nil, dateHeaders{}, err
+	}
+	defer rows.Close()
+
+	ids := []int{0}
+	labels := []string{"Name"}
+	for rows.Next() {
+		var id int
+		var label string
+		if err := rows.Scan(&id, &label); err != nil {
+			return nil, dateHeaders{}, err
+		}
+		ids = append(ids, id)
+		labels = append(labels, label)
+	}
+	return ids, decodeHeaders(labels, models.Today()), rows.Err()
+}
+
+// Helper: Decoded days of a role keyed by role_days ID
+func roleDays(q querier, roleID int) (map[int]roleDay, error) {
+	ids, headers, err := roleDayHeaders(q, roleID)
+	if err != nil {
+		return nil, err
+	}
+	days := map[int]roleDay{}
+	for i := 1; i < len(ids); i++ {
+		date, label := headers.column(i)
+		days[ids[i]] = roleDay{date: date, label: label}
+	}
+	return days, nil
+}
+
+// Helper: Find the day of a role by day key (see dayKey), creating it when missing. A new day goes in
+// date order, as its column does in the sheets, so the history queries can order by position.
+// Callers hold the role row lock.
+func ensureRoleDay(tx *sql.Tx, roleID int, key string) (int, error) {
+	ids, headers, err := roleDayHeaders(tx, roleID)
+	if err != nil {
+		return 0, err
+	}
+	if i := headers.findKey(key); i != -1 {
+		return ids[i], nil
+	}
+
+	label := key
+	if d, err := models.ParseDate(key); err == nil {
+		label = d.Header()
+		if i := headers.insertAt(d); i < len(ids) {
+			// A back-dated day: make room before the first later day
+			var pos int
+			if err := tx.QueryRow(`SELECT position FROM role_days WHERE id = $1`, ids[i]).Scan(&pos); err != nil {
+				return 0, err
+			}
+			// In two steps, as (role_id, position) is checked row by row
+			if _, err := tx.Exec(`UPDATE role_days SET position = -(position + 1) WHERE role_id = $1 AND position >= $2`, roleID, pos); err != nil {
+				return 0, err
+			}
+			if _, err := tx.Exec(`UPDATE role_days SET position = -position WHERE role_id = $1 AND position < 0`, roleID); err != nil {
+				return 0, err
+			}
+			var dayID int
+			err := tx.QueryRow(`INSERT INTO role_days (role_id, label, position) VALUES ($1, $2, $3) RETURNING id`, roleID, label, pos).Scan(&dayID)
+			return dayID, err
+		}
+	}
+	var dayID int
+	err = tx.QueryRow(`
+		INSERT INTO role_days (role_id, label, position)
+		SELECT $1::integer, $2::text, COALESCE(MAX(position), 0) + 1 FROM role_days WHERE role_id = $1
+		RETURNING id`, roleID, label).Scan(&dayID)
+	return dayID, err
+}
+
+// Helper: Load the history of a role, newest day first, keyed by employee ID.
+// employeeID 0 loads every member; limit > 0 keeps at most limit days per employee.
+func (p *PostgresStore) roleHistories(roleID, employeeID, limit int) (map[int][]models.DayTasks, error) {
+	days, err := roleDays(p.db, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := p.db.Query(`
+		SELECT t.employee_id, d.id, t.uid, t.task, t.status, t.carried_from
+		FROM day_tasks t
+		JOIN role_days d ON d.id = t.role_day_id
+		WHERE d.role_id = $1 AND ($2 = 0 OR t.employee_id = $2)
+		ORDER BY t.employee_id, d.position DESC, t.position`, roleID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	histories := map[int][]models.DayTasks{}
+	counted := map[int]int{} // Days per employee counting toward limit
+	names, err := p.employeeNames()
+	if err != nil {
+		return nil, err
+	}
+	var curEmp, curDay int
+	var items []models.TaskItem
+
+	flush := func() {
+		if len(items) == 0 {
+			return
+		}
+		if limit <= 0 || counted[curEmp] < limit {
+			day := days[curDay]
+			histories[curEmp] = append(histories[curEmp], newDayTasks(day.date, day.label, items))
+			if p.env.countsTowardLatest(names[curEmp], day.date) {
+				counted[curEmp]++
+			}
+		}
+		items = nil
+	}
+
+	for rows.Next() {
+		var empID, dayID int
+		var item models.TaskItem
+		var carriedFrom string
+		if err := rows.Scan(&empID, &dayID, &item.ID, &item.Task, &item.Status, &carriedFrom); err != nil {
+			return nil, err
+		}
+		item.CarriedFrom = parseCarriedFrom(carriedFrom)
+		if empID != curEmp || dayID != curDay {
+			flush()
+			curEmp, curDay = empID, dayID
+		}
+		items = append(items, item)
+	}
+	flush()
+
+	return histories, rows.Err()
+}
+
+// Helper: Employee names by ID
+func (p *PostgresStore) employeeNames() (map[int]string, error) {
+	rows, err := p.db.Query(`SELECT id, name FROM employees`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := map[int]string{}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+// GetLatestTasks returns an employee's full history across the roles
+func (p *PostgresStore) GetLatestTasks(employeeName string) (models.EmployeeTasksResponse, error) {
+	var empID int
+	var empName string
+	err := p.db.QueryRow(`SELECT id, name FROM employees WHERE lower(name) = lower($1)`, strings.TrimSpace(employeeName)).Scan(&empID, &empName)
+	if err == sql.ErrNoRows {
+		return models.EmployeeTasksResponse{}, p.env.errEmployeeNotFound(employeeName)
+	}
+	if err != nil {
+		return models.EmployeeTasksResponse{}, err
+	}
+
+	rows, err := p.db.Query(`
+		SELECT r.id, r.name
+		FROM roles r
+		JOIN role_members m ON m.role_id = r.id
+		WHERE m.employee_id = $1
+		ORDER BY r.id`, empID)
+	if err != nil {
+		return models.EmployeeTasksResponse{}, err
+	}
+	type role struct {
+		id   int
+		name string
+	}
+	var roles []role
+	for rows.Next() {
+		var r role
+		if err := rows.Scan(&r.id, &r.name); err != nil {
+			rows.Close()
+			return models.EmployeeTasksResponse{}, err
+		}
+		roles = append(roles, r)
+	}
+	rows.Close()
+
+	var allHistory []models.DayTasks
+	var foundSheet string
+	for _, r := range roles {
+		histories, err := p.roleHistories(r.id, empID, 0)
+		if err != nil {
+			return models.EmployeeTasksResponse{}, err
+		}
+		if len(histories[empID]) == 0 {
+			continue
+		}
+		allHistory = append(allHistory, histories[empID]...)
+		if foundSheet == "" {
+			foundSheet = r.name
+		}
+	}
+
+	return p.env.withSheetStatuses(models.EmployeeTasksResponse{
+		EmployeeName: empName,
+		SheetName:    foundSheet,
+		History:      allHistory,
+	}, len(allHistory) > 0, employeeName, p.env.roleStatuses())
+}
+
+// GetAllEmployeesLatestTasks returns the last 7 populated days of every role member
+func (p *PostgresStore) GetAllEmployeesLatestTasks() (models.TeamTasksResponse, error) {
+	rows, err := p.db.Query(`
+		SELECT r.id, r.name, e.id, e.name
+		FROM role_members m
+		JOIN roles r ON r.id = m.role_id
+		JOIN employees e ON e.id = m.employee_id
+		ORDER BY r.id, m.id`)
+	if err != nil {
+		return models.TeamTasksResponse{}, err
+	}
+	type member struct {
+		roleID   int
+		roleName string
+		empID    int
+		empName  string
+	}
+	var members []member
+	for rows.Next() {
+		var m member
+		if err := rows.Scan(&m.roleID, &m.roleName, &m.empID, &m.empName); err != nil {
+			rows.Close()
+			return models.TeamTasksResponse{}, err
+		}
+		members = append(members, m)
+	}
+	rows.Close()
+
+	allEmployees := []models.EmployeeTasksResponse{}
+	histories := map[int]map[int][]models.DayTasks{}
+	for _, m := range members {
+		if _, ok := histories[m.roleID]; !ok {
+			h, err := p.roleHistories(m.roleID, 0, latestDays)
+			if err != nil {
+				return models.TeamTasksResponse{}, err
+			}
+			histories[m.roleID] = h
+		}
+		allEmployees = append(allEmployees, models.EmployeeTasksResponse{
+			EmployeeName: m.empName,
+			SheetName:    m.roleName,
+			History:      histories[m.roleID][m.empID],
+		})
+	}
+
+	return newTeamTasks(allEmployees, p.env.roleStatuses())
+}
+
+// Helper: Every role lives in the one database, so each configured role reads or fails together
+func (e *Env) roleStatuses() []models.SheetStatus {
+	var statuses []models.SheetStatus
+	for _, title := range e.roleSheets() {
+		statuses = append(statuses, sheetStatus(title, nil))
+	}
+	return statuses
+}
+
+// EnsureRoleSheet registers the role of a new team
+func (p *PostgresStore) EnsureRoleSheet(title string) error {
+	_, err := p.db.Exec(`INSERT INTO roles (name) VALUES ($1) ON CONFLICT DO NOTHING`, title)
+	return err
+}
+
+// AddTask updates or creates tasks
+func (p *PostgresStore) AddTask(req models.TaskRequest) error {
+	items, err := validateTaskItems(req.Tasks)
+	if err != nil {
+		return err
+	}
+	req.Tasks = items
+
+	// 1-2. Determine Target Date and Validate Allowed Edit Window
+	targetDate, err := p.env.resolveTargetDate(p, req)
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 3. Determine Target Role (row lock serializes writers of the same role)
+	role, err := p.env.resolveRole(req.Role)
+	if err != nil {
+		return err
+	}
+	var roleID int
+	err = tx.QueryRow(`SELECT id FROM roles WHERE lower(name) = lower($1) FOR UPDATE`, role).Scan(&roleID)
+	if err == sql.ErrNoRows {
+		if req.Role == "" {
+			return fmt.Errorf("default sheet '%s' not found", role)
+		}
+		return errUnknownRole(req.Role)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 4. Find or Create Employee and Role Membership
+	empID, err := ensureEmployee(tx, req.EmployeeName)
+	if err != nil {
+		return fmt.Errorf("failed to add new employee: %v", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO role_members (role_id, employee_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, roleID, empID); err != nil {
+		return err
+	}
+
+	// 5. Find or Create Day
+	dayID, err := ensureRoleDay(tx, roleID, targetDate.String())
+	if err != nil {
+		return err
+	}
+
+	// 6. Merge Tasks and Rewrite the Day
+	existingTasks, err := dayTaskItems(tx, dayID, empID)
+	if err != nil {
+		return err
+	}
+	before := append([]models.TaskItem(nil), existingTasks...)
+	after := mergeTasks(existingTasks, req.Tasks)
+	if err := writeDayTaskItems(tx, dayID, empID, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	p.env.publishCellChange(TaskLocation{EmployeeName: req.EmployeeName, Sheet: role, Date: targetDate}, req.Actor, EventSourceAPI, before, after)
+	return nil
+}
+
+// Helper: The lines of one employee/day, in order
+func dayTaskItems(tx *sql.Tx, dayID, empID int) ([]models.TaskItem, error) {
+	rows, err := tx.Query(`SELECT uid, task, status, carried_from FROM day_tasks WHERE role_day_id = $1 AND employee_id = $2 ORDER BY position`, dayID, empID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []models.TaskItem
+	for rows.Next() {
+		var item models.TaskItem
+		var carriedFrom string
+		if err := rows.Scan(&item.ID, &item.Task, &item.Status, &carriedFrom); err != nil {
+			return nil, err
+		}
+		item.CarriedFrom = parseCarriedFrom(carriedFrom)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Helper: Rewrite one employee/day with items; items without an ID get a fresh one
+func writeDayTaskItems(tx *sql.Tx, dayID, empID int, items []models.TaskItem) error {
+	if _, err := tx.Exec(`DELETE FROM day_tasks WHERE role_day_id = $1 AND employee_id = $2`, dayID, empID); err != nil {
+		return err
+	}
+	for i, item := range withTaskIDs(items) {
+		if _, err := tx.Exec(`INSERT INTO day_tasks (role_day_id, employee_id, position, uid, task, status, carried_from) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			dayID, empID, i, item.ID, item.Task, normalizeStatus(item.Status), carriedFromColumn(item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Helper: Value of the carried_from column for an item
+func carriedFromColumn(item models.TaskItem) string {
+	if item.CarriedFrom == nil {
+		return ""
+	}
+	return item.CarriedFrom.String()
+}
+
+// Helper: Parse the carried_from column, nil for tasks that were not carried over
+func parseCarriedFrom(s string) *models.Date {
+	d, err := models.ParseDate(s)
+	if err != nil {
+		return nil
+	}
+	return &d
+}
+
+// FindTask locates a task by ID in the roles
+func (p *PostgresStore) FindTask(id string) (TaskLocation, error) {
+	return p.findTask(id)
+}
+
+// UpdateTask renames a task and/or changes its status
+func (p *PostgresStore) UpdateTask(id string, update TaskUpdate, actor string) (TaskLocation, error) {
+	return p.env.updateTask(p, id, update, actor)
+}
+
+// DeleteTask removes a task from its day
+func (p *PostgresStore) DeleteTask(id string, actor string) (TaskLocation, error) {
+	return p.env.deleteTask(p, id, actor)
+}
+
+// UpdateCellTask renames and/or recolors one line of an employee/day cell
+func (p *PostgresStore) UpdateCellTask(cell CellRef, line string, update TaskUpdate) (models.DayTasks, error) {
+	return p.env.updateCellTask(p, cell, line, update)
+}
+
+// DeleteCellTask removes one line of an employee/day cell
+func (p *PostgresStore) DeleteCellTask(cell CellRef, line string) (models.DayTasks, error) {
+	return p.env.deleteCellTask(p, cell, line)
+}
+
+// ReorderCell puts the lines of an employee/day cell in a new order
+func (p *PostgresStore) ReorderCell(cell CellRef, order []string) (models.DayTasks, error) {
+	return p.env.reorderCell(p, cell, order)
+}
+
+// CarryOver copies an employee's unfinished tasks into a day, keeping their IDs and statuses
+func (p *PostgresStore) CarryOver(req CarryOverRequest) (CarryOverResult, error) {
+	return p.env.carryOver(p, req)
+}
+
+// Helper: Look a task up with its role, employee and day (the latest day holding a carried-over task)
+func (p *PostgresStore) findTask(id string) (TaskLocation, error) {
+	var loc TaskLocation
+	var roleID, dayID int
+	var carriedFrom string
+	err := p.db.QueryRow(`
+		SELECT t.uid, t.task, t.status, t.carried_from, t.position, e.name, r.name, r.id, d.id
+		FROM day_tasks t
+		JOIN role_days d ON d.id = t.role_day_id
+		JOIN roles r ON r.id = d.role_id
+		JOIN employees e ON e.id = t.employee_id
+		WHERE t.uid = $1
+		ORDER BY r.id, d.position DESC, t.id
+		LIMIT 1`, id).Scan(&loc.ID, &loc.Task, &loc.Status, &carriedFrom, &loc.Position, &loc.EmployeeName, &loc.Sheet, &roleID, &dayID)
+	if err == sql.ErrNoRows {
+		return TaskLocation{}, errTaskNotFound(id)
+	}
+	if err != nil {
+		return TaskLocation{}, err
+	}
+
+	days, err := roleDays(p.db, roleID)
+	if err != nil {
+		return TaskLocation{}, err
+	}
+	loc.Date, loc.Label = days[dayID].date, days[dayID].label
+	loc.CarriedFrom = parseCarriedFrom(carriedFrom)
+	return loc, nil
+}
+
+// Helper: Create the employee, role membership and day of a cell when missing
+func (p *PostgresStore) ensureCell(loc TaskLocation) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var roleID int
+	err = tx.QueryRow(`SELECT id FROM roles WHERE lower(name) = lower($1) FOR UPDATE`, loc.Sheet).Scan(&roleID)
+	if err == sql.ErrNoRows {
+		return errUnknownRole(loc.Sheet)
+	}
+	if err != nil {
+		return err
+	}
+	empID, err := ensureEmployee(tx, loc.EmployeeName)
+	if err != nil {
+		return fmt.Errorf("failed to add new employee: %v", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO role_members (role_id, employee_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, roleID, empID); err != nil {
+		return err
+	}
+	if _, err := ensureRoleDay(tx, roleID, loc.Date.String()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Helper: Replace the lines of one existing employee/day
+func (p *PostgresStore) editCell(loc TaskLocation, fn func(items []models.TaskItem) ([]models.TaskItem, error)) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Role row lock serializes writers of the same role, as in AddTask
+	var roleID int
+	err = tx.QueryRow(`SELECT id FROM roles WHERE lower(name) = lower($1) FOR UPDATE`, loc.Sheet).Scan(&roleID)
+	if err == sql.ErrNoRows {
+		return errUnknownRole(loc.Sheet)
+	}
+	if err != nil {
+		return err
+	}
+
+	notFound := newError(ErrNotFound, "cell_not_found", "%s has no cell for %s in %s", loc.EmployeeName, loc.Label, loc.Sheet)
+	var empID int
+	err = tx.QueryRow(`SELECT id FROM employees WHERE lower(name) = lower($1)`, strings.TrimSpace(loc.EmployeeName)).Scan(&empID)
+	if err == sql.ErrNoRows {
+		return notFound
+	}
+	if err != nil {
+		return err
+	}
+	ids, headers, err := roleDayHeaders(tx, roleID)
+	if err != nil {
+		return err
+	}
+	col := headers.findKey(dayKey(loc.Date, loc.Label))
+	if col == -1 {
+		return notFound
+	}
+
+	items, err := dayTaskItems(tx, ids[col], empID)
+	if err != nil {
+		return err
+	}
+	items, err = fn(items)
+	if err != nil {
+		return err
+	}
+	if err := writeDayTaskItems(tx, ids[col], empID, items); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetAllEmployeesMetadata lists the employee records
+func (p *PostgresStore) GetAllEmployeesMetadata() ([]EmployeeMetadata, error) {
+	rows, err := p.db.Query(`SELECT id, name, created_at, updated_at, timezone FROM employees ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	employees := []EmployeeMetadata{}
+	for rows.Next() {
+		var id int
+		var emp EmployeeMetadata
+		var createdAt time.Time
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&id, &emp.EmployeeName, &createdAt, &updatedAt, &emp.Timezone); err != nil {
+			return nil, err
+		}
+		emp.ID = fmt.Sprintf("%d", id)
+		emp.CreatedAt = createdAt.Format(time.RFC3339)
+		if updatedAt.Valid {
+			upd := updatedAt.Time.Format(time.RFC3339)
+			emp.UpdatedAt = &upd
+		}
+		employees = append(employees, emp)
+	}
+	return employees, rows.Err()
+}
+
+// GetAllDailyLogs lists the daily log records
+func (p *PostgresStore) GetAllDailyLogs() ([]DailyLog, error) {
+	rows, err := p.db.Query(`
+		SELECT e.name, l.task_date, l.created_at, l.updated_at, l.timezone
+		FROM daily_logs l
+		JOIN employees e ON e.id = l.employee_id
+		ORDER BY l.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []DailyLog{}
+	for rows.Next() {
+		var l DailyLog
+		var createdAt, updatedAt time.Time
+		if err := rows.Scan(&l.EmployeeName, &l.TaskDate, &createdAt, &updatedAt, &l.Timezone); err != nil {
+			return nil, err
+		}
+		loc := time.Local
+		if l.Timezone != "" {
+			if z, err := time.LoadLocation(l.Timezone); err == nil {
+				loc = z
+			}
+		}
+		l.CreatedAt = createdAt.In(loc).Format(time.RFC3339)
+		l.UpdatedAt = updatedAt.In(loc).Format(time.RFC3339)
+		l.CreatedAtUTC = createdAt.UTC().Format(time.RFC3339)
+		l.UpdatedAtUTC = updatedAt.UTC().Format(time.RFC3339)
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
+// UpsertEmployeeMetadata updates or inserts employee info
+func (p *PostgresStore) UpsertEmployeeMetadata(name, timezone string) error {
+	if err := ValidateTimezone(timezone); err != nil {
+		return err
+	}
+	defer p.env.forgetEmployeeZones()
+
+	var inserted bool
+	err := p.db.QueryRow(`
+		INSERT INTO employees (name, updated_at, timezone) VALUES ($1, now(), $2)
+		ON CONFLICT ((lower(name))) DO UPDATE SET updated_at = now(),
+			timezone = CASE WHEN $2 = '' THEN employees.timezone ELSE $2 END
+		RETURNING xmax = 0`, strings.TrimSpace(name), timezone).Scan(&inserted)
+	if err != nil {
+		return err
+	}
+	if inserted {
+		p.env.publishEmployeeCreated(name)
+	}
+	return nil
+}
+
+// UpsertDailyLog updates or inserts a log for an employee and date
+func (p *PostgresStore) UpsertDailyLog(name, date string) error {
+	_, _, zone := p.env.logTimestamps(p, name)
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	empID, err := ensureEmployee(tx, name)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO daily_logs (employee_id, task_date, timezone) VALUES ($1, $2, $3)
+		ON CONFLICT (employee_id, (lower(task_date))) DO UPDATE SET updated_at = now()`, empID, strings.TrimSpace(date), zone); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// mirrorCell is one employee/day of a role as stored in the database
+type mirrorCell struct {
+	Role         string
+	EmployeeName string
+	Date         string // Day key, see dayKey
+	Items        []models.TaskItem
+}
+
+// Helper: Load every non-empty employee/day of every role, in role/member/day order
+func (p *PostgresStore) mirrorCells() ([]mirrorCell, error) {
+	roleIDs, err := p.db.Query(`SELECT id FROM roles`)
+	if err != nil {
+		return nil, err
+	}
+	days := map[int]roleDay{}
+	var ids []int
+	for roleIDs.Next() {
+		var id int
+		if err := roleIDs.Scan(&id); err != nil {
+			roleIDs.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	roleIDs.Close()
+	for _, id := range ids {
+		rd, err := roleDays(p.db, id)
+		if err != nil {
+			return nil, err
+		}
+		for dayID, day := range rd {
+			days[dayID] = day
+		}
+	}
+
+	rows, err := p.db.Query(`
+		SELECT r.name, e.name, d.id, t.uid, t.task, t.status, t.carried_from
+		FROM day_tasks t
+		JOIN role_days d ON d.id = t.role_day_id
+		JOIN roles r ON r.id = d.role_id
+		JOIN employees e ON e.id = t.employee_id
+		ORDER BY r.id, e.id, d.position, t.position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cells []mirrorCell
+	for rows.Next() {
+		var role, empName string
+		var dayID int
+		var item models.TaskItem
+		var carriedFrom string
+		if err := rows.Scan(&role, &empName, &dayID, &item.ID, &item.Task, &item.Status, &carriedFrom); err != nil {
+			return nil, err
+		}
+		item.CarriedFrom = parseCarriedFrom(carriedFrom)
+		key := dayKey(days[dayID].date, days[dayID].label)
+		n := len(cells)
+		if n == 0 || cells[n-1].Role != role || cells[n-1].EmployeeName != empName || cells[n-1].Date != key {
+			cells = append(cells, mirrorCell{Role: role, EmployeeName: empName, Date: key})
+			n++
+		}
+		cells[n-1].Items = append(cells[n-1].Items, item)
+	}
+	return cells, rows.Err()
+}
+
+// Helper: Replace one employee/day (date is a day key) with items, but only if its current content still hashes to expectedHash.
+// Returns false when the cell changed underneath (e.g. an API write raced the sync).
+func (p *PostgresStore) replaceCell(role, employeeName, date, expectedHash string, items []models.TaskItem) (bool, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Role rows are locked by AddTask too, so this serializes against API writes
+	if _, err := tx.Exec(`INSERT INTO roles (name) VALUES ($1) ON CONFLICT ((lower(name))) DO NOTHING`, role); err != nil {
+		return false, err
+	}
+	var roleID int
+	if err := tx.QueryRow(`SELECT id FROM roles WHERE lower(name) = lower($1) FOR UPDATE`, role).Scan(&roleID); err != nil {
+		return false, err
+	}
+
+	empID, err := ensureEmployee(tx, employeeName)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`INSERT INTO role_members (role_id, employee_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, roleID, empID); err != nil {
+		return false, err
+	}
+
+	dayID, err := ensureRoleDay(tx, roleID, date)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := dayTaskItems(tx, dayID, empID)
+	if err != nil {
+		return false, err
+	}
+	if hashItems(current) != expectedHash {
+		return false, nil
+	}
+
+	if err := writeDayTaskItems(tx, dayID, empID, items); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// Helper: Load the stored sync state of every cell
+func (p *PostgresStore) syncStates() ([]SyncCellStatus, error) {
+	rows, err := p.db.Query(`
+		SELECT role_name, employee_name, day_label, status, synced_hash, sheet_hash, db_hash, last_error, last_synced_at, updated_at
+		FROM sync_cells
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := []SyncCellStatus{}
+	for rows.Next() {
+		var st SyncCellStatus
+		var lastSynced sql.NullTime
+		var updatedAt time.Time
+		if err := rows.Scan(&st.Role, &st.EmployeeName, &st.Date, &st.Status, &st.syncedHash, &st.sheetHash, &st.dbHash,
+			&st.LastError, &lastSynced, &updatedAt); err != nil {
+			return nil, err
+		}
+		if lastSynced.Valid {
+			ts := lastSynced.Time.Format(time.RFC3339)
+			st.LastSyncedAt = &ts
+		}
+		st.UpdatedAt = updatedAt.Format(time.RFC3339)
+		states = append(states, st)
+	}
+	return states, rows.Err()
+}
+
+// Helper: Insert or update the sync state of a cell
+func (p *PostgresStore) saveSyncState(st SyncCellStatus) error {
+	_, err := p.db.Exec(`
+		INSERT INTO sync_cells (role_name, employee_name, day_label, status, synced_hash, sheet_hash, db_hash, last_error, last_synced_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CASE WHEN $4::text = 'synced' THEN now() END)
+		ON CONFLICT ((lower(role_name)), (lower(employee_name)), (lower(day_label))) DO UPDATE SET
+			status         = EXCLUDED.status,
+			synced_hash    = EXCLUDED.synced_hash,
+			sheet_hash     = EXCLUDED.sheet_hash,
+			db_hash        = EXCLUDED.db_hash,
+			last_error     = EXCLUDED.last_error,
+			last_synced_at = COALESCE(EXCLUDED.last_synced_at, sync_cells.last_synced_at),
+			updated_at     = now()`,
+		st.Role, st.EmployeeName, st.Date, st.Status, st.syncedHash, st.sheetHash, st.dbHash, st.LastError)
+	return err
+}
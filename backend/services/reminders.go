@@ -0,0 +1,136 @@
+// services/reminders.go
+package services
+
+import (
+	"go-backend/models"
+	"strings"
+	"time"
+)
+
+// NotifierInfo describes a configured notifier
+type NotifierInfo struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Target string `json:"target"`
+}
+
+// NotifierResult is the outcome of one notifier in a reminder pass
+type NotifierResult struct {
+	NotifierInfo
+	OK    bool   `json:"ok"`
+	Sent  int    `json:"sent"` // Reminders this notifier was given; the ones it already delivered are left out
+	Error string `json:"error,omitempty"`
+}
+
+// ReminderReport summarizes one reminder pass
+type ReminderReport struct {
+	StartedAt string           `json:"started_at"`
+	Duration  string           `json:"duration"`
+	Reminded  []MissingUpdate  `json:"reminded"`
+	Notifiers []NotifierResult `json:"notifiers"`
+}
+
+// Reminders sends reminders to employees with no update on their today; the scheduler runs it as a job.
+// Each notifier reminds each employee about a day at most once, and a notifier that failed tries again
+// on the next pass.
+type Reminders struct {
+	store     Store
+	env       *Env
+	notifiers []Notifier
+}
+
+// NewReminders returns Reminders reading through store, on the working days of env's calendar,
+// and sending through notifiers
+func NewReminders(store Store, notifiers []Notifier, env *Env) *Reminders {
+	return &Reminders{store: store, env: env, notifiers: notifiers}
+}
+
+// Notifiers describes the configured notifiers
+func (r *Reminders) Notifiers() []NotifierInfo {
+	out := []NotifierInfo{}
+	for _, n := range r.notifiers {
+		out = append(out, notifierInfo(n))
+	}
+	return out
+}
+
+// Helper: Description of a notifier
+func notifierInfo(n Notifier) NotifierInfo {
+	return NotifierInfo{Name: n.Name(), Type: n.Type(), Target: n.Target()}
+}
+
+// RunOnce reminds every employee missing today's update through each notifier that has not
+// reminded them about it yet. reminded maps "<notifier>:<employee>" (lower-cased) to the last day
+// that notifier got a reminder through about ("2006-01-02") and is updated; the scheduler keeps it
+// between runs. Reminded lists the reminders at least one notifier was given.
+func (r *Reminders) RunOnce(reminded map[string]string) (report ReminderReport, err error) {
+	started := time.Now()
+	report = ReminderReport{StartedAt: started.Format(time.RFC3339), Reminded: []MissingUpdate{}, Notifiers: []NotifierResult{}}
+	defer func() {
+		report.Duration = time.Since(started).String()
+	}()
+
+	missing, err := r.env.MissingUpdates(r.store, nil, "")
+	if err != nil {
+		return report, err
+	}
+	var all []MissingUpdate
+	for _, sheet := range missing.Sheets {
+		all = append(all, sheet.Missing...)
+	}
+
+	given := map[string]bool{}
+	sent := false
+	for _, n := range r.notifiers {
+		var due []MissingUpdate
+		for _, m := range all {
+			if reminded[reminderKey(n, m.EmployeeName)] != m.Date.String() {
+				due = append(due, m)
+			}
+		}
+		if len(due) == 0 {
+			continue
+		}
+
+		res := NotifierResult{NotifierInfo: notifierInfo(n), OK: true, Sent: len(due)}
+		if err := n.Notify(due); err != nil {
+			res.OK, res.Error = false, err.Error()
+		}
+		report.Notifiers = append(report.Notifiers, res)
+		for _, m := range due {
+			if key := strings.ToLower(m.EmployeeName) + "\x00" + m.Date.String(); !given[key] {
+				given[key] = true
+				report.Reminded = append(report.Reminded, m)
+			}
+			if res.OK {
+				reminded[reminderKey(n, m.EmployeeName)] = m.Date.String()
+			}
+		}
+		sent = sent || res.OK
+	}
+	if len(report.Notifiers) > 0 && !sent {
+		return report, newError(ErrUnavailable, "notify_failed", "no notifier could send the reminders")
+	}
+	return report, nil
+}
+
+// Helper: Key of the last day a notifier reminded an employee about
+func reminderKey(n Notifier, employeeName string) string {
+	return strings.ToLower(n.Name()) + ":" + strings.ToLower(employeeName)
+}
+
+// Test sends a sample reminder through one notifier
+func (r *Reminders) Test(name string) (NotifierResult, error) {
+	for _, n := range r.notifiers {
+		if !strings.EqualFold(n.Name(), name) {
+			continue
+		}
+		sample := MissingUpdate{EmployeeName: "Test Employee", Sheet: "Test", Date: models.Today()}
+		res := NotifierResult{NotifierInfo: notifierInfo(n), OK: true}
+		if err := n.Notify([]MissingUpdate{sample}); err != nil {
+			res.OK, res.Error = false, err.Error()
+		}
+		return res, nil
+	}
+	return NotifierResult{}, newError(ErrNotFound, "notifier_not_found", "notifier '%s' not found", name)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"go-backend/config"
+	"go-backend/models"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRunOnceRetriesFailedNotifier(t *testing.T) {
+	env := everyDayWorking(t)
+	srv, _, httpURL := startFakeNotify(t)
+
+	store := NewMemoryStore(env)
+	yesterday := env.EmployeeToday(store, "Ann").AddDays(-1)
+	if err := store.AddTask(models.TaskRequest{EmployeeName: "Ann", Date: yesterday, Tasks: []models.TaskItem{{Task: "write docs", Status: "todo"}}}); err != nil {
+		t.Fatal(err)
+	}
+	notifiers, err := NewNotifiers([]config.NotifierConfig{
+		{Name: "hook", Type: "webhook", URL: httpURL + "/hook"},
+		{Name: "down", Type: "webhook", URL: httpURL + "/down"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The "down" notifier's first call fails
+	down := &flakyNotifier{Notifier: notifiers[1], failures: 1}
+	reminders := NewReminders(store, []Notifier{notifiers[0], down}, env)
+	reminded := map[string]string{}
+	today := env.EmployeeToday(store, "Ann").String()
+
+	calls := func(path string) int {
+		n := 0
+		for _, m := range srv.Messages() {
+			if m.Path == path {
+				n++
+			}
+		}
+		return n
+	}
+
+	tests := []struct {
+		name           string
+		hookCalls      int
+		downCalls      int
+		downReminded   string
+		wantReminded   int
+		wantNotifiers  int
+		wantDownFailed bool
+	}{
+		{name: "first pass", hookCalls: 1, downCalls: 0, downReminded: "", wantReminded: 1, wantNotifiers: 2, wantDownFailed: true},
+		{name: "retry of the failed notifier", hookCalls: 1, downCalls: 1, downReminded: today, wantReminded: 1, wantNotifiers: 1},
+		{name: "nothing left to send", hookCalls: 1, downCalls: 1, downReminded: today, wantReminded: 0, wantNotifiers: 0},
+	}
+	for _, tt := range tests {
+		report, err := reminders.RunOnce(reminded)
+		if err != nil {
+			t.Fatalf("%s: RunOnce: %v", tt.name, err)
+		}
+		if got := calls("/hook"); got != tt.hookCalls {
+			t.Errorf("%s: hook called %d times, want %d", tt.name, got, tt.hookCalls)
+		}
+		if got := calls("/down"); got != tt.downCalls {
+			t.Errorf("%s: down called %d times, want %d", tt.name, got, tt.downCalls)
+		}
+		if reminded["hook:ann"] != today || reminded["down:ann"] != tt.downReminded {
+			t.Errorf("%s: reminded = %v", tt.name, reminded)
+		}
+		if len(report.Reminded) != tt.wantReminded || len(report.Notifiers) != tt.wantNotifiers {
+			t.Errorf("%s: %d reminded by %d notifiers, want %d by %d", tt.name, len(report.Reminded), len(report.Notifiers), tt.wantReminded, tt.wantNotifiers)
+		}
+		for _, res := range report.Notifiers {
+			if res.Name == "down" && res.OK == tt.wantDownFailed {
+				t.Errorf("%s: down ok = %v, error %q", tt.name, res.OK, res.Error)
+			}
+		}
+	}
+}
+
+func TestRunOnceFailsWhenNoNotifierGetsThrough(t *testing.T) {
+	env := everyDayWorking(t)
+	srv, _, httpURL := startFakeNotify(t)
+	srv.SetStatus(http.StatusBadGateway)
+
+	store := NewMemoryStore(env)
+	if err := store.AddTask(models.TaskRequest{EmployeeName: "Ann", Date: env.EmployeeToday(store, "Ann").AddDays(-1), Tasks: []models.TaskItem{{Task: "a", Status: "todo"}}}); err != nil {
+		t.Fatal(err)
+	}
+	notifiers, err := NewNotifiers([]config.NotifierConfig{{Name: "hook", Type: "webhook", URL: httpURL + "/hook"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reminded := map[string]string{}
+	_, err = NewReminders(store, notifiers, env).RunOnce(reminded)
+	if e := AsError(err); e == nil || e.Code != "notify_failed" {
+		t.Fatalf("RunOnce = %v, want notify_failed", err)
+	}
+	if len(reminded) != 0 {
+		t.Errorf("reminded = %v after a failed pass, want it empty", reminded)
+	}
+}
+
+// flakyNotifier fails its first calls without sending
+type flakyNotifier struct {
+	Notifier
+	failures int
+}
+
+func (f *flakyNotifier) Notify(reminders []MissingUpdate) error {
+	if f.failures > 0 {
+		f.failures--
+		return newError(ErrUnavailable, "notify_failed", "%s is down", strings.ToLower(f.Name()))
+	}
+	return f.Notifier.Notify(reminders)
+}
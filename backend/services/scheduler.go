@@ -0,0 +1,392 @@
+// services/scheduler.go
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-backend/config"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// How often the scheduler looks for due jobs
+const schedulerTick = 15 * time.Second
+
+// Default lease of a running job, renewed while it runs
+const defaultJobTimeout = 30 * time.Minute
+
+// Jobs the scheduler hosts
+const (
+	JobCarryOver       = "carry-over"
+	JobArchiveRollover = "archive-rollover"
+	JobCacheWarm       = "cache-warm"
+	JobReminders       = "missing-reminders"
+)
+
+// Job triggers
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// Job run statuses
+const (
+	JobStatusOK     = "ok"
+	JobStatusFailed = "failed"
+	JobStatusLost   = "lost" // The lease ran out mid-run; the state it saved was discarded
+)
+
+// Job is background work the scheduler runs on its schedule or on request
+type Job struct {
+	Name        string
+	Schedule    string        // Cron spec or @every, see ParseSchedule; empty runs only on request
+	Timeout     time.Duration // Lease length, renewed while the job runs; defaultJobTimeout when 0
+	PerInstance bool          // Runs on every instance (e.g. cache warming) rather than once per deployment
+
+	// Run does the work. state is kept between runs (and shared by instances); changes to it are saved.
+	// The result is recorded with the run.
+	Run func(state map[string]string) (interface{}, error)
+}
+
+// JobRun is one recorded run of a job
+type JobRun struct {
+	Job        string          `json:"job"`
+	Instance   string          `json:"instance"`
+	Trigger    string          `json:"trigger"` // "schedule" or "manual"
+	StartedAt  string          `json:"started_at"`
+	FinishedAt string          `json:"finished_at"`
+	Duration   string          `json:"duration"`
+	Status     string          `json:"status"` // "ok", "failed" or "lost"
+	Error      string          `json:"error,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+}
+
+// JobStatus describes a registered job
+type JobStatus struct {
+	Name        string     `json:"name"`
+	Schedule    string     `json:"schedule"` // Empty: runs only on request
+	PerInstance bool       `json:"per_instance"`
+	NextRunAt   *time.Time `json:"next_run_at"`
+	RunningOn   string     `json:"running_on,omitempty"` // Instance holding the lease
+	LastRun     *JobRun    `json:"last_run"`
+}
+
+// scheduledJob is a registered job with its parsed schedule
+type scheduledJob struct {
+	Job
+	schedule Schedule // nil: runs only on request
+	store    jobStore
+	running  sync.Mutex // One run at a time on this instance
+}
+
+// Scheduler runs registered jobs on cron-like schedules. Job state, leases and run history
+// live in a jobStore, so instances sharing it run each scheduled slot once.
+type Scheduler struct {
+	instance string
+	shared   jobStore // Jobs run once per deployment
+	local    jobStore // Per-instance jobs, in memory
+	loc      *time.Location
+	history  int
+
+	mu   sync.RWMutex
+	jobs map[string]*scheduledJob
+}
+
+// NewScheduler keeps job state in Postgres when store is the postgres backend, on cfg.Tab
+// when it is the sheets backend and cfg.StoreFile is empty, otherwise in cfg.StoreFile
+// (in memory when empty, which is only safe with a single instance)
+func NewScheduler(cfg config.JobsConfig, store Store) (*Scheduler, error) {
+	loc := time.Local
+	if cfg.Timezone != "" {
+		l, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("jobs.timezone: %v", err)
+		}
+		loc = l
+	}
+
+	var shared jobStore = &fileJobStore{path: cfg.StoreFile}
+	switch s := store.(type) {
+	case *PostgresStore:
+		shared = &postgresJobStore{db: s.db}
+	case *SheetsStore:
+		if cfg.StoreFile == "" {
+			shared = &sheetJobStore{client: s.client, tab: cfg.Tab, settle: jobLeaseSettle}
+		}
+	}
+
+	return &Scheduler{
+		instance: instanceID(),
+		shared:   shared,
+		local:    &fileJobStore{},
+		loc:      loc,
+		history:  cfg.History,
+		jobs:     map[string]*scheduledJob{},
+	}, nil
+}
+
+// Helper: Identity of this process in leases and run history, e.g. "web-1:4242:9f3a"
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	b := make([]byte, 2)
+	rand.Read(b)
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Instance returns the identity this process runs jobs under
+func (s *Scheduler) Instance() string {
+	return s.instance
+}
+
+// Describe says where job state is kept
+func (s *Scheduler) Describe() string {
+	return s.shared.describe()
+}
+
+// Register adds a job; its next run is scheduled from now unless the store has an earlier one
+func (s *Scheduler) Register(job Job) error {
+	sj := &scheduledJob{Job: job, store: s.shared}
+	if job.PerInstance {
+		sj.store = s.local
+	}
+	if sj.Timeout <= 0 {
+		sj.Timeout = defaultJobTimeout
+	}
+
+	var next *time.Time
+	if job.Schedule != "" {
+		sched, err := ParseSchedule(job.Schedule, s.loc)
+		if err != nil {
+			return fmt.Errorf("job %s: %v", job.Name, err)
+		}
+		sj.schedule = sched
+		t := sched.Next(time.Now())
+		next = &t
+	}
+	if err := sj.store.register(job.Name, next); err != nil {
+		return fmt.Errorf("job %s: %v", job.Name, err)
+	}
+
+	s.mu.Lock()
+	s.jobs[job.Name] = sj
+	s.mu.Unlock()
+	return nil
+}
+
+// Start runs due jobs until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		s.mu.RLock()
+		for _, sj := range s.jobs {
+			if sj.schedule != nil {
+				go s.runScheduled(sj)
+			}
+		}
+		s.mu.RUnlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Helper: Run a job if it is due and no one else is running it
+func (s *Scheduler) runScheduled(sj *scheduledJob) {
+	if !sj.running.TryLock() {
+		return
+	}
+	defer sj.running.Unlock()
+
+	if _, ran, err := s.run(sj, JobTriggerSchedule); err != nil && !ran {
+		log.Printf("Job %s: %v", sj.Name, err)
+	}
+}
+
+// RunNow runs a job immediately unless it is already running here or on another instance.
+// It returns the recorded run and the job's error.
+func (s *Scheduler) RunNow(name string) (JobRun, error) {
+	sj, err := s.job(name)
+	if err != nil {
+		return JobRun{}, err
+	}
+	if !sj.running.TryLock() {
+		return JobRun{}, errJobRunning(name)
+	}
+	defer sj.running.Unlock()
+
+	run, ran, err := s.run(sj, JobTriggerManual)
+	if !ran && err == nil {
+		return JobRun{}, errJobRunning(name)
+	}
+	return run, err
+}
+
+// Helper: Lease, run and record a job; ran is false when it was not due or leased elsewhere
+func (s *Scheduler) run(sj *scheduledJob, trigger string) (run JobRun, ran bool, err error) {
+	now := time.Now()
+	state, ok, err := sj.store.acquire(sj.Name, s.instance, now, now.Add(sj.Timeout), trigger == JobTriggerManual)
+	if err != nil || !ok {
+		return JobRun{}, false, err
+	}
+
+	// Keep the lease while the job runs
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(sj.Timeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case t := <-ticker.C:
+				if err := sj.store.renew(sj.Name, s.instance, t.Add(sj.Timeout)); err != nil {
+					log.Printf("Job %s: failed to renew lease: %v", sj.Name, err)
+				}
+			}
+		}
+	}()
+
+	result, jobErr := safeRun(sj.Run, state)
+	close(done)
+
+	finished := time.Now()
+	run = JobRun{
+		Job:        sj.Name,
+		Instance:   s.instance,
+		Trigger:    trigger,
+		StartedAt:  now.UTC().Format(time.RFC3339Nano),
+		FinishedAt: finished.UTC().Format(time.RFC3339Nano),
+		Duration:   finished.Sub(now).String(),
+		Status:     JobStatusOK,
+	}
+	if jobErr != nil {
+		run.Status, run.Error = JobStatusFailed, jobErr.Error()
+		log.Printf("Job %s failed: %v", sj.Name, jobErr)
+	}
+	if result != nil {
+		if b, err := json.Marshal(result); err == nil {
+			run.Result = b
+		}
+	}
+
+	var next *time.Time
+	if trigger == JobTriggerSchedule {
+		t := sj.schedule.Next(finished)
+		next = &t
+	}
+	lost, err := sj.store.finish(run, state, next, s.history)
+	if err != nil {
+		log.Printf("Job %s: failed to record run: %v", sj.Name, err)
+	}
+	if lost {
+		markLost(&run, "")
+		log.Printf("Job %s: the lease expired before the run finished; its state was not saved", sj.Name)
+	}
+	return run, true, jobErr
+}
+
+// Helper: Run a job, turning a panic into an error so the lease is still released
+func safeRun(fn func(map[string]string) (interface{}, error), state map[string]string) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(state)
+}
+
+// UpdateState read-modifies-writes shared state kept under key outside any job run, for state
+// every instance adds to (e.g. the sheet watch snapshot). fn returning false skips the write.
+// Runs see their own job's state as it was when they started, so key should not be a job name.
+func (s *Scheduler) UpdateState(key string, fn func(state map[string]string) bool) error {
+	return s.shared.updateState(key, fn)
+}
+
+// Jobs describes every registered job, by name
+func (s *Scheduler) Jobs() ([]JobStatus, error) {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+
+	out := []JobStatus{}
+	for _, name := range names {
+		st, err := s.Job(name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// Job describes one registered job
+func (s *Scheduler) Job(name string) (JobStatus, error) {
+	sj, err := s.job(name)
+	if err != nil {
+		return JobStatus{}, err
+	}
+	recs, err := sj.store.records()
+	if err != nil {
+		return JobStatus{}, err
+	}
+	rec := recs[name]
+	st := JobStatus{Name: name, Schedule: sj.Schedule, PerInstance: sj.PerInstance, NextRunAt: rec.NextRunAt}
+	if rec.LeaseOwner != "" && rec.LeaseUntil != nil && time.Now().Before(*rec.LeaseUntil) {
+		st.RunningOn = rec.LeaseOwner
+	}
+	runs, err := sj.store.runs(name, 1)
+	if err != nil {
+		return JobStatus{}, err
+	}
+	if len(runs) > 0 {
+		st.LastRun = &runs[0]
+	}
+	return st, nil
+}
+
+// Runs returns the recorded runs of a job, newest first
+func (s *Scheduler) Runs(name string) ([]JobRun, error) {
+	sj, err := s.job(name)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := sj.store.runs(name, s.history)
+	if runs == nil {
+		runs = []JobRun{}
+	}
+	return runs, err
+}
+
+// Helper: A registered job by name
+func (s *Scheduler) job(name string) (*scheduledJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sj, ok := s.jobs[name]
+	if !ok {
+		return nil, newError(ErrNotFound, "job_not_found", "job '%s' not found", name)
+	}
+	return sj, nil
+}
+
+// Helper: "conflict" for a job that is already running
+func errJobRunning(name string) error {
+	return newError(ErrConflict, "job_running", "job '%s' is already running", name)
+}
@@ -2,20 +2,46 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"go-backend/config"
 	"go-backend/models"
+	"log"
 	"math"
-	"sort"
 	"strings"
 	"sync"
-	"time"
 
 	"google.golang.org/api/sheets/v4"
 )
 
-// Target specific sheets (Exact names as provided)
-var targetSheets = []string{"DEV", "Managers"}
+
+// errSheetMissing marks a configured role sheet that does not exist in the spreadsheet
+var errSheetMissing = errors.New("sheet not found in spreadsheet")
+
+// Helper: "employee not found" error listing the searched role sheets
+func (e *Env) errEmployeeNotFound(employeeName string) error {
+	return newError(ErrNotFound, "employee_not_found", "employee '%s' not found in %s sheets", employeeName, strings.Join(e.roleSheets(), " or "))
+}
+
+// SheetsStore is the Google Sheets backed Store
+type SheetsStore struct {
+	client *config.SheetsClient
+	env    *Env
+}
+
+// NewSheetsStore returns a Store that reads and writes the configured spreadsheet through client
+func NewSheetsStore(client *config.SheetsClient, env *Env) *SheetsStore {
+	return &SheetsStore{client: client, env: env}
+}
+
+// EnsureRoleSheet creates the role sheet of a new team, with its "Name" header
+func (s *SheetsStore) EnsureRoleSheet(title string) error {
+	srv, err := s.client.Service()
+	if err != nil {
+		return err
+	}
+	return config.EnsureSheet(srv, title, []interface{}{"Name"})
+}
 
 // Helper: Exact Name Match
 func namesMatch(sheetName, searchName string) bool {
@@ -38,25 +64,21 @@ func getStatusFromColor(color *sheets.Color) string {
 		return "todo"
 	}
 
-	targetGreenR := 52.0 / 255.0
-	targetGreenG := 168.0 / 255.0
-	targetGreenB := 83.0 / 255.0
+	palette := config.Get().StatusColors
+	tol := palette.Tolerance
 
-	targetPendingR := 231.0 / 255.0
-	targetPendingG := 149.0 / 255.0
-	targetPendingB := 63.0 / 255.0
-
-	const tol = 0.35
+	near := func(hex string) bool {
+		r, g, b := config.ParseHexColor(hex)
+		return math.Abs(color.Red-r) < tol &&
+			math.Abs(color.Green-g) < tol &&
+			math.Abs(color.Blue-b) < tol
+	}
 
-	if math.Abs(color.Red-targetGreenR) < tol &&
-		math.Abs(color.Green-targetGreenG) < tol &&
-		math.Abs(color.Blue-targetGreenB) < tol {
+	if near(palette.Complete) {
 		return "complete"
 	}
 
-	if math.Abs(color.Red-targetPendingR) < tol &&
-		math.Abs(color.Green-targetPendingG) < tol &&
-		math.Abs(color.Blue-targetPendingB) < tol {
+	if near(palette.Pending) {
 		return "pending"
 	}
 
@@ -73,32 +95,35 @@ func getStatusFromColor(color *sheets.Color) string {
 
 // Helper: Determine color from status
 func getColorFromStatus(status string) *sheets.Color {
+	palette := config.Get().StatusColors
+	hex := palette.Todo
 	switch strings.ToLower(status) {
 	case "complete":
-		return &sheets.Color{Red: 52.0 / 255.0, Green: 168.0 / 255.0, Blue: 83.0 / 255.0}
+		hex = palette.Complete
 	case "pending":
-		return &sheets.Color{Red: 231.0 / 255.0, Green: 149.0 / 255.0, Blue: 63.0 / 255.0}
-	default:
-		return &sheets.Color{Red: 0.0, Green: 0.0, Blue: 0.0}
+		hex = palette.Pending
 	}
+	r, g, b := config.ParseHexColor(hex)
+	return &sheets.Color{Red: r, Green: g, Blue: b}
 }
 
 // Helper: Parse cell data into categorized tasks
-func parseCellToDayTasks(date string, cellData *sheets.CellData) models.DayTasks {
-	dt := models.DayTasks{
-		Date:     date,
-		Todo:     []string{},
-		Pending:  []string{},
-		Complete: []string{},
-	}
+func parseCellToDayTasks(employeeName string, date models.Date, label string, cellData *sheets.CellData) models.DayTasks {
+	return newDayTasks(date, label, parseCellLines(cellKey(employeeName, dayKey(date, label)), cellData))
+}
+
+// Helper: Parse cell data into its task lines, in order, with the status and ID of each line.
+// key identifies the cell, see cellKey.
+func parseCellLines(key string, cellData *sheets.CellData) []models.TaskItem {
+	var items []models.TaskItem
 
 	if cellData == nil || cellData.UserEnteredValue == nil || cellData.UserEnteredValue.StringValue == nil {
-		return dt
+		return items
 	}
 
 	text := *cellData.UserEnteredValue.StringValue
 	if text == "" {
-		return dt
+		return items
 	}
 
 	lines := strings.Split(text, "\n")
@@ -134,35 +159,35 @@ func parseCellToDayTasks(date string, cellData *sheets.CellData) models.DayTasks
 
 		cleanLine := strings.TrimSpace(line)
 		if cleanLine != "" {
-			switch status {
-			case "complete":
-				dt.Complete = append(dt.Complete, cleanLine)
-			case "pending":
-				dt.Pending = append(dt.Pending, cleanLine)
-			default:
-				dt.Todo = append(dt.Todo, cleanLine)
-			}
+			items = append(items, models.TaskItem{Task: cleanLine, Status: status})
 		}
 		currentIdx += lineLen + 1
 	}
 
-	return dt
+	tasks := make([]string, len(items))
+	for i, item := range items {
+		tasks[i] = item.Task
+	}
+	for i, n := range assignTaskIDs(key, tasks, parseTaskIDNote(cellData.Note)) {
+		items[i].ID, items[i].CarriedFrom = n.ID, n.From
+	}
+	return items
 }
 
-// Helper: Fetch data from a single sheet
-func fetchSheetData(srv *sheets.Service, sheetTitle string, employeeName string) ([]models.DayTasks, string, error) {
+// Helper: Fetch data from a single sheet of a spreadsheet (the main one or the archive)
+func fetchSheetData(srv *sheets.Service, spreadsheetID string, sheetTitle string, employeeName string) ([]models.DayTasks, string, error) {
 	// 1. Fetch Header Row
-	respHeader, err := srv.Spreadsheets.Values.Get(config.SpreadsheetID, fmt.Sprintf("'%s'!1:1", sheetTitle)).Do()
+	respHeader, err := srv.Spreadsheets.Values.Get(spreadsheetID, fmt.Sprintf("'%s'!1:1", sheetTitle)).Do()
 	if err != nil {
 		return nil, "", err
 	}
 	if len(respHeader.Values) == 0 {
 		return nil, "", nil
 	}
-	headerRow := respHeader.Values[0]
+	headers := decodeHeaderRow(respHeader.Values[0])
 
 	// 2. Fetch All Values (Lightweight) to find row
-	respValues, err := srv.Spreadsheets.Values.Get(config.SpreadsheetID, fmt.Sprintf("'%s'!A:A", sheetTitle)).Do()
+	respValues, err := srv.Spreadsheets.Values.Get(spreadsheetID, fmt.Sprintf("'%s'!A:A", sheetTitle)).Do()
 	if err != nil {
 		return nil, "", err
 	}
@@ -186,10 +211,10 @@ func fetchSheetData(srv *sheets.Service, sheetTitle string, employeeName string)
 	}
 
 	// 3. Fetch Specific Row with Formatting
-	req := srv.Spreadsheets.Get(config.SpreadsheetID).
+	req := srv.Spreadsheets.Get(spreadsheetID).
 		Ranges(fmt.Sprintf("'%s'!A%d:ZZ%d", sheetTitle, rowIndex+1, rowIndex+1)).
 		IncludeGridData(true).
-		Fields("sheets(data(rowData(values(userEnteredValue,textFormatRuns,userEnteredFormat(textFormat(foregroundColor))))))")
+		Fields("sheets(data(rowData(values(userEnteredValue,note,textFormatRuns,userEnteredFormat(textFormat(foregroundColor))))))")
 
 	resp, err := req.Do()
 	if err != nil {
@@ -207,26 +232,56 @@ func fetchSheetData(srv *sheets.Service, sheetTitle string, employeeName string)
 				continue
 			}
 
-			dateStr := "Unknown"
-			if i < len(headerRow) {
-				dateStr = fmt.Sprintf("%v", headerRow[i])
-			}
-
-			dayTask := parseCellToDayTasks(dateStr, cell)
+			date, label := headers.column(i)
+			dayTask := parseCellToDayTasks(fullEmployeeName, date, label, cell)
 			sheetHistory = append(sheetHistory, dayTask)
 		}
 	}
 	return sheetHistory, fullEmployeeName, nil
 }
 
-// GetLatestTasks fetches tasks from specific sheets (DEV, Managers)
-func GetLatestTasks(employeeName string) (models.EmployeeTasksResponse, error) {
-	srv, err := config.GetSheetsService()
+// Helper: Fetch every row of a role sheet with formatting, plus its header row
+func fetchSheetGrid(srv *sheets.Service, sheetTitle string) ([]*sheets.RowData, []interface{}, error) {
+	return fetchSheetGridIn(srv, config.Get().SpreadsheetID, sheetTitle)
+}
+
+// Helper: Fetch every row of a sheet of any spreadsheet with formatting, plus its header row
+func fetchSheetGridIn(srv *sheets.Service, spreadsheetID string, sheetTitle string) ([]*sheets.RowData, []interface{}, error) {
+	req := srv.Spreadsheets.Get(spreadsheetID).
+		Ranges(fmt.Sprintf("'%s'!A:ZZ", sheetTitle)).
+		IncludeGridData(true).
+		Fields("sheets(data(rowData(values(userEnteredValue,note,textFormatRuns,userEnteredFormat(textFormat(foregroundColor))))))")
+
+	resp, err := req.Do()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Headers
+	respHeader, err := srv.Spreadsheets.Values.Get(spreadsheetID, fmt.Sprintf("'%s'!1:1", sheetTitle)).Do()
+	if err != nil {
+		return nil, nil, err
+	}
+	var headerRow []interface{}
+	if len(respHeader.Values) > 0 {
+		headerRow = respHeader.Values[0]
+	}
+
+	var rows []*sheets.RowData
+	if len(resp.Sheets) > 0 && len(resp.Sheets[0].Data) > 0 {
+		rows = resp.Sheets[0].Data[0].RowData
+	}
+	return rows, headerRow, nil
+}
+
+// GetLatestTasks fetches tasks from the configured role sheets
+func (s *SheetsStore) GetLatestTasks(employeeName string) (models.EmployeeTasksResponse, error) {
+	srv, err := s.client.Service()
 	if err != nil {
 		return models.EmployeeTasksResponse{}, err
 	}
 
-	meta, err := srv.Spreadsheets.Get(config.SpreadsheetID).Do()
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Do()
 	if err != nil {
 		return models.EmployeeTasksResponse{}, err
 	}
@@ -237,16 +292,27 @@ func GetLatestTasks(employeeName string) (models.EmployeeTasksResponse, error) {
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	for _, targetTitle := range targetSheets {
+	titles := s.env.roleSheets()
+	statuses := make([]models.SheetStatus, len(titles))
+	archiveStatuses := make([][]models.SheetStatus, len(titles))
+	for i, targetTitle := range titles {
 		sheet := findSheetByTitle(meta, targetTitle)
 		if sheet == nil {
+			statuses[i] = sheetStatus(targetTitle, errSheetMissing)
 			continue 
 		}
 		
 		wg.Add(1)
-		go func(title string) {
+		go func(i int, title string) {
 			defer wg.Done()
-			hist, name, err := fetchSheetData(srv, title, employeeName)
+			hist, name, err := fetchSheetData(srv, config.Get().SpreadsheetID, title, employeeName)
+			statuses[i] = sheetStatus(title, err)
+			if err == nil && name != "" {
+				// Closed periods continue in the archive tabs
+				archived, archStatuses := archivedHistory(srv, title, employeeName)
+				hist = append(hist, archived...)
+				archiveStatuses[i] = archStatuses
+			}
 			if err == nil && len(hist) > 0 {
 				mu.Lock()
 				allHistory = append(allHistory, hist...)
@@ -256,259 +322,613 @@ func GetLatestTasks(employeeName string) (models.EmployeeTasksResponse, error) {
 				}
 				mu.Unlock()
 			}
-		}(sheet.Properties.Title)
+		}(i, sheet.Properties.Title)
 	}
 	wg.Wait()
-
-	if len(allHistory) == 0 {
-		return models.EmployeeTasksResponse{}, fmt.Errorf("employee '%s' not found in DEV or Managers sheets", employeeName)
+	for _, st := range archiveStatuses {
+		statuses = append(statuses, st...)
 	}
 
-	return models.EmployeeTasksResponse{
+	return s.env.withSheetStatuses(models.EmployeeTasksResponse{
 		EmployeeName: foundName,
 		SheetName:    foundSheet,
 		History:      allHistory,
-	}, nil
+	}, len(allHistory) > 0, employeeName, statuses)
 }
 
-// GetAllEmployeesLatestTasks fetches from "DEV" and "Managers" sheets
-func GetAllEmployeesLatestTasks() ([]models.EmployeeTasksResponse, error) {
-	srv, err := config.GetSheetsService()
+// GetAllEmployeesLatestTasks fetches from the configured role sheets, reporting each sheet's outcome
+func (s *SheetsStore) GetAllEmployeesLatestTasks() (models.TeamTasksResponse, error) {
+	srv, err := s.client.Service()
 	if err != nil {
-		return nil, err
+		return models.TeamTasksResponse{}, err
 	}
 
-	meta, err := srv.Spreadsheets.Get(config.SpreadsheetID).Do()
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Do()
 	if err != nil {
-		return nil, err
+		return models.TeamTasksResponse{}, err
 	}
 
 	var allEmployees []models.EmployeeTasksResponse
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	for _, targetTitle := range targetSheets {
+	titles := s.env.roleSheets()
+	statuses := make([]models.SheetStatus, len(titles))
+	for i, targetTitle := range titles {
 		sheet := findSheetByTitle(meta, targetTitle)
 		if sheet == nil {
+			statuses[i] = sheetStatus(targetTitle, errSheetMissing)
 			continue
 		}
 		
 		wg.Add(1)
-		go func(title string) {
+		go func(i int, title string) {
 			defer wg.Done()
 			
-			req := srv.Spreadsheets.Get(config.SpreadsheetID).
-				Ranges(fmt.Sprintf("'%s'!A:ZZ", title)).
-				IncludeGridData(true).
-				Fields("sheets(data(rowData(values(userEnteredValue,textFormatRuns,userEnteredFormat(textFormat(foregroundColor))))))")
-			
-			resp, err := req.Do()
-			if err != nil { return }
-
-			// Headers
-			respHeader, err := srv.Spreadsheets.Values.Get(config.SpreadsheetID, fmt.Sprintf("'%s'!1:1", title)).Do()
-			if err != nil || len(respHeader.Values) == 0 { return }
-			headerRow := respHeader.Values[0]
-
-			var sheetEmployees []models.EmployeeTasksResponse
-
-			if len(resp.Sheets) > 0 && len(resp.Sheets[0].Data) > 0 {
-				rows := resp.Sheets[0].Data[0].RowData
-				
-				for rIdx, row := range rows {
-					if rIdx == 0 { continue }
-					
-					if len(row.Values) == 0 || row.Values[0].UserEnteredValue == nil || row.Values[0].UserEnteredValue.StringValue == nil {
-						continue
-					}
-					empName := *row.Values[0].UserEnteredValue.StringValue
-					if empName == "" { continue }
-
-					var localHist []models.DayTasks
-					count := 0 
-					
-					for cIdx := len(row.Values) - 1; cIdx >= 1; cIdx-- {
-						if count >= 7 { break }
-						
-						cell := row.Values[cIdx]
-						if cell.UserEnteredValue == nil || cell.UserEnteredValue.StringValue == nil || *cell.UserEnteredValue.StringValue == "" {
-							continue
-						}
-
-						dateStr := "Unknown"
-						if cIdx < len(headerRow) {
-							dateStr = fmt.Sprintf("%v", headerRow[cIdx])
-						}
-
-						dayTask := parseCellToDayTasks(dateStr, cell)
-						localHist = append(localHist, dayTask)
-						count++
-					}
-
-					sheetEmployees = append(sheetEmployees, models.EmployeeTasksResponse{
-						EmployeeName: empName,
-						SheetName:    title,
-						History:      localHist,
-					})
-				}
+			sheetEmployees, err := s.env.sheetLatestTasks(srv, title)
+			statuses[i] = sheetStatus(title, err)
+			if err != nil {
+				log.Printf("Failed to read sheet %s: %v", title, err)
+				return
 			}
 
 			mu.Lock()
 			allEmployees = append(allEmployees, sheetEmployees...)
 			mu.Unlock()
 
-		}(sheet.Properties.Title)
+		}(i, sheet.Properties.Title)
 	}
 	wg.Wait()
-	
-	sort.Slice(allEmployees, func(i, j int) bool {
-		return allEmployees[i].EmployeeName < allEmployees[j].EmployeeName
-	})
 
-	return allEmployees, nil
+	return newTeamTasks(allEmployees, statuses)
+}
+
+// GetSheetLatestTasks fetches the last 7 days of every employee on one role sheet
+func (s *SheetsStore) GetSheetLatestTasks(sheetTitle string) ([]models.EmployeeTasksResponse, error) {
+	srv, err := s.client.Service()
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Fields("sheets.properties").Do()
+	if err != nil {
+		return nil, err
+	}
+	sheet := findSheetByTitle(meta, sheetTitle)
+	if sheet == nil {
+		return nil, errSheetMissing
+	}
+
+	return s.env.sheetLatestTasks(srv, sheet.Properties.Title)
+}
+
+// Revision returns the spreadsheet's Drive version and modified time, which change on every
+// edit of any tab, this backend's own writes included
+func (s *SheetsStore) Revision() (string, error) {
+	drv, err := s.client.Drive()
+	if err != nil {
+		return "", err
+	}
+
+	file, err := drv.Files.Get(config.Get().SpreadsheetID).Fields("version", "modifiedTime").SupportsAllDrives(true).Do()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d@%s", file.Version, file.ModifiedTime), nil
+}
+
+// Helper: Last 7 non-empty working days (plus entries on days off between them) of every employee
+// on a role sheet, continued from its newest archive tab for employees with fewer days since the last rollover
+func (e *Env) sheetLatestTasks(srv *sheets.Service, title string) ([]models.EmployeeTasksResponse, error) {
+	sheetEmployees, err := e.gridLatestTasks(srv, config.Get().SpreadsheetID, title)
+	if err != nil {
+		return nil, err
+	}
+	return e.withArchivedLatestTasks(srv, title, sheetEmployees), nil
+}
+
+// Helper: Last 7 non-empty working days of every employee on a sheet of a spreadsheet
+func (e *Env) gridLatestTasks(srv *sheets.Service, spreadsheetID string, title string) ([]models.EmployeeTasksResponse, error) {
+	rows, headerRow, err := fetchSheetGridIn(srv, spreadsheetID, title)
+	if err != nil {
+		return nil, err
+	}
+	if len(headerRow) == 0 {
+		return nil, nil
+	}
+	headers := decodeHeaderRow(headerRow)
+
+	var sheetEmployees []models.EmployeeTasksResponse
+
+	for rIdx, row := range rows {
+		if rIdx == 0 { continue }
+		
+		if len(row.Values) == 0 || row.Values[0].UserEnteredValue == nil || row.Values[0].UserEnteredValue.StringValue == nil {
+			continue
+		}
+		empName := *row.Values[0].UserEnteredValue.StringValue
+		if empName == "" { continue }
+
+		var localHist []models.DayTasks
+		count := 0 
+		
+		for cIdx := len(row.Values) - 1; cIdx >= 1; cIdx-- {
+			if count >= latestDays { break }
+			
+			cell := row.Values[cIdx]
+			if cell.UserEnteredValue == nil || cell.UserEnteredValue.StringValue == nil || *cell.UserEnteredValue.StringValue == "" {
+				continue
+			}
+
+			date, label := headers.column(cIdx)
+			dayTask := parseCellToDayTasks(empName, date, label, cell)
+			localHist = append(localHist, dayTask)
+			if e.countsTowardLatest(empName, date) { count++ }
+		}
+
+		sheetEmployees = append(sheetEmployees, models.EmployeeTasksResponse{
+			EmployeeName: empName,
+			SheetName:    title,
+			History:      localHist,
+		})
+	}
+
+	return sheetEmployees, nil
 }
 
 // AddTask updates or creates tasks
-func AddTask(req models.TaskRequest) error {
-	srv, err := config.GetSheetsService()
+func (s *SheetsStore) AddTask(req models.TaskRequest) error {
+	items, err := validateTaskItems(req.Tasks)
 	if err != nil { return err }
+	req.Tasks = items
 
-	meta, err := srv.Spreadsheets.Get(config.SpreadsheetID).Do()
+	srv, err := s.client.Service()
 	if err != nil { return err }
 
-	// 1. Determine Target Date Header
-	targetHeader := req.Date
-	if targetHeader == "" {
-		targetHeader = time.Now().Format("Mon 02-Jan")
-	}
+	// Cells are addressed by column index from here on; hold off a rollover
+	layoutMu.RLock()
+	defer layoutMu.RUnlock()
 
-	// 2. Validate Allowed Edit Window (Today or Yesterday)
-	todayHeader := time.Now().Format("Mon 02-Jan")
-	yesterdayHeader := time.Now().AddDate(0, 0, -1).Format("Mon 02-Jan")
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Do()
+	if err != nil { return err }
 
-	if !strings.EqualFold(targetHeader, todayHeader) && !strings.EqualFold(targetHeader, yesterdayHeader) {
-		return fmt.Errorf("restriction: can only edit Today's (%s) or Yesterday's (%s) tasks", todayHeader, yesterdayHeader)
-	}
+	// 1-2. Determine Target Date and Validate Allowed Edit Window
+	targetDate, err := s.env.resolveTargetDate(s, req)
+	if err != nil { return err }
 	
 	var targetSheetID int64 = -1
 	var targetSheetTitle string = ""
-	var rowIndex int = -1
 	
 	// 3. Determine Target Sheet
+	role, err := s.env.resolveRole(req.Role)
+	if err != nil { return err }
 	if req.Role != "" {
-		sheet := findSheetByTitle(meta, req.Role)
+		sheet := findSheetByTitle(meta, role)
 		if sheet != nil {
 			targetSheetID = sheet.Properties.SheetId
 			targetSheetTitle = sheet.Properties.Title
 		} else {
-			return fmt.Errorf("sheet '%s' not found", req.Role)
+			return errUnknownRole(req.Role)
 		}
 	} else {
-		// Fallback: Default to "DEV"
-		sheet := findSheetByTitle(meta, "DEV")
+		// Fallback: Default role sheet
+		defaultRole := role
+		sheet := findSheetByTitle(meta, defaultRole)
 		if sheet != nil {
 			targetSheetID = sheet.Properties.SheetId
 			targetSheetTitle = sheet.Properties.Title
 		} else {
-			return fmt.Errorf("default sheet 'DEV' not found")
+			return fmt.Errorf("default sheet '%s' not found", defaultRole)
 		}
 	}
 
-	// 4. Find or Create Employee Row
-	resp, _ := srv.Spreadsheets.Values.Get(config.SpreadsheetID, fmt.Sprintf("'%s'!A:A", targetSheetTitle)).Do()
-	if resp != nil {
-		for r, row := range resp.Values {
-			if len(row) > 0 && namesMatch(fmt.Sprintf("%v", row[0]), req.EmployeeName) {
-				rowIndex = r
-				break
+	var before, after []models.TaskItem
+	err = retryColumnMoved(func() error {
+		// 4. Find or Create Employee Row
+		rowIndex, err := findOrCreateEmployeeRow(srv, targetSheetTitle, req.EmployeeName)
+		if err != nil { return err }
+
+		// 5. Find or Create Date Column
+		targetColIndex, err := findOrCreateDateColumn(srv, meta, targetSheetID, targetSheetTitle, targetDate)
+		if err != nil { return err }
+
+		// 6. Update Cell (Rich Text)
+		existingTasks, err := readCellLines(srv, targetSheetTitle, cellKey(req.EmployeeName, targetDate.String()), rowIndex, targetColIndex)
+		if err != nil { return err }
+		before = cellItems(existingTasks)
+
+		for _, newTask := range req.Tasks {
+			found := false
+			for i, existing := range existingTasks {
+				if strings.EqualFold(existing.Task, newTask.Task) {
+					existingTasks[i].Color = getColorFromStatus(newTask.Status)
+					found = true
+					break
+				}
 			}
+			if !found {
+				existingTasks = append(existingTasks, cellLine{
+					ID:    newTaskID(),
+					Task:  newTask.Task,
+					Color: getColorFromStatus(newTask.Status),
+				})
+			}
+		}
+
+		if err := checkDayColumn(srv, targetSheetTitle, targetColIndex, targetDate.String()); err != nil {
+			return err
+		}
+		if err := writeCellLines(srv, targetSheetID, rowIndex, targetColIndex, existingTasks); err != nil {
+			return err
 		}
+		after = cellItems(existingTasks)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	s.env.publishCellChange(TaskLocation{EmployeeName: req.EmployeeName, Sheet: targetSheetTitle, Date: targetDate}, req.Actor, EventSourceAPI, before, after)
+	return nil
+}
 
-	if rowIndex == -1 {
-		// Append New Employee
-		appendRange := fmt.Sprintf("'%s'!A:A", targetSheetTitle)
-		vr := &sheets.ValueRange{
-			Values: [][]interface{}{{req.EmployeeName}},
+// FindTask locates a task by ID in the role sheets
+func (s *SheetsStore) FindTask(id string) (TaskLocation, error) {
+	return s.findTask(id)
+}
+
+// UpdateTask renames a task and/or changes its status; the other lines keep their formatting
+func (s *SheetsStore) UpdateTask(id string, update TaskUpdate, actor string) (TaskLocation, error) {
+	return s.env.updateTask(s, id, update, actor)
+}
+
+// DeleteTask removes a task from its cell; the other lines keep their formatting
+func (s *SheetsStore) DeleteTask(id string, actor string) (TaskLocation, error) {
+	return s.env.deleteTask(s, id, actor)
+}
+
+// UpdateCellTask renames and/or recolors one line of an employee/day cell
+func (s *SheetsStore) UpdateCellTask(cell CellRef, line string, update TaskUpdate) (models.DayTasks, error) {
+	return s.env.updateCellTask(s, cell, line, update)
+}
+
+// DeleteCellTask removes one line of an employee/day cell
+func (s *SheetsStore) DeleteCellTask(cell CellRef, line string) (models.DayTasks, error) {
+	return s.env.deleteCellTask(s, cell, line)
+}
+
+// ReorderCell puts the lines of an employee/day cell in a new order
+func (s *SheetsStore) ReorderCell(cell CellRef, order []string) (models.DayTasks, error) {
+	return s.env.reorderCell(s, cell, order)
+}
+
+// CarryOver copies an employee's unfinished tasks into a day, keeping their IDs and statuses
+func (s *SheetsStore) CarryOver(req CarryOverRequest) (CarryOverResult, error) {
+	return s.env.carryOver(s, req)
+}
+
+// Helper: Scan the role sheets for the cell holding a task (the latest one for a carried-over task)
+func (s *SheetsStore) findTask(id string) (TaskLocation, error) {
+	if !isTaskID(id) {
+		return TaskLocation{}, errTaskNotFound(id)
+	}
+	srv, err := s.client.Service()
+	if err != nil {
+		return TaskLocation{}, err
+	}
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Fields("sheets.properties").Do()
+	if err != nil {
+		return TaskLocation{}, err
+	}
+
+	var found []TaskLocation
+	for _, targetTitle := range s.env.roleSheets() {
+		sheet := findSheetByTitle(meta, targetTitle)
+		if sheet == nil {
+			continue
 		}
-		_, err := srv.Spreadsheets.Values.Append(config.SpreadsheetID, appendRange, vr).ValueInputOption("RAW").Do()
+		title := sheet.Properties.Title
+		rows, headerRow, err := fetchSheetGrid(srv, title)
 		if err != nil {
-			return fmt.Errorf("failed to add new employee: %v", err)
+			return TaskLocation{}, err
 		}
-		
-		// Re-fetch to find index
-		respRetry, _ := srv.Spreadsheets.Values.Get(config.SpreadsheetID, fmt.Sprintf("'%s'!A:A", targetSheetTitle)).Do()
-		for r, row := range respRetry.Values {
-			if len(row) > 0 && namesMatch(fmt.Sprintf("%v", row[0]), req.EmployeeName) {
-				rowIndex = r
-				break
+		headers := decodeHeaderRow(headerRow)
+
+		for rIdx, row := range rows {
+			if rIdx == 0 || len(row.Values) == 0 {
+				continue
+			}
+			empName := strings.TrimSpace(cellText(row.Values[0]))
+			if empName == "" {
+				continue
 			}
+			for cIdx := 1; cIdx < len(row.Values); cIdx++ {
+				date, label := headers.column(cIdx)
+				items := parseCellLines(cellKey(empName, dayKey(date, label)), row.Values[cIdx])
+				if i := taskIndex(items, id); i != -1 {
+					found = append(found, TaskLocation{TaskItem: items[i], EmployeeName: empName, Sheet: title, Date: date, Label: label, Position: i})
+				}
+			}
+		}
+	}
+	return latestLocation(id, found)
+}
+
+// Helper: Rewrite one existing cell through the rich-text path
+func (s *SheetsStore) editCell(loc TaskLocation, fn func(items []models.TaskItem) ([]models.TaskItem, error)) error {
+	srv, err := s.client.Service()
+	if err != nil {
+		return err
+	}
+
+	// Cells are addressed by column index from here on; hold off a rollover
+	layoutMu.RLock()
+	defer layoutMu.RUnlock()
+
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Fields("sheets.properties").Do()
+	if err != nil {
+		return err
+	}
+	sheet := findSheetByTitle(meta, loc.Sheet)
+	if sheet == nil {
+		return errUnknownRole(loc.Sheet)
+	}
+	title := sheet.Properties.Title
+
+	day := dayKey(loc.Date, loc.Label)
+	return retryColumnMoved(func() error {
+		rowIndex, colIndex, err := findCell(srv, title, loc.EmployeeName, day)
+		if err != nil {
+			return err
+		}
+		if rowIndex == -1 || colIndex == -1 {
+			return newError(ErrNotFound, "cell_not_found", "%s has no cell for %s in %s", loc.EmployeeName, loc.Label, title)
+		}
+
+		// A failed read must abort the edit: writing fn's result over an unread cell would drop its other lines
+		lines, err := readCellLines(srv, title, cellKey(loc.EmployeeName, day), rowIndex, colIndex)
+		if err != nil {
+			return err
+		}
+		items, err := fn(cellItems(lines))
+		if err != nil {
+			return err
 		}
-		if rowIndex == -1 {
-			return fmt.Errorf("failed to locate employee after creation")
+		if err := checkDayColumn(srv, title, colIndex, day); err != nil {
+			return err
 		}
+		return writeCellLines(srv, sheet.Properties.SheetId, rowIndex, colIndex, cellLinesAfterEdit(lines, items))
+	})
+}
+
+// Helper: A cell's lines as task items, with the status their color stands for
+func cellItems(lines []cellLine) []models.TaskItem {
+	items := make([]models.TaskItem, len(lines))
+	for i, line := range lines {
+		items[i] = models.TaskItem{ID: line.ID, Task: line.Task, Status: getStatusFromColor(line.Color), CarriedFrom: line.CarriedFrom}
+	}
+	return items
+}
+
+// Helper: Create the employee row and day column of a cell when missing
+func (s *SheetsStore) ensureCell(loc TaskLocation) error {
+	srv, err := s.client.Service()
+	if err != nil {
+		return err
 	}
 
-	// 5. Find or Create Date Column
-	targetColIndex := -1
-	resp, err = srv.Spreadsheets.Values.Get(config.SpreadsheetID, fmt.Sprintf("'%s'!1:1", targetSheetTitle)).Do()
+	layoutMu.RLock()
+	defer layoutMu.RUnlock()
+
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Do()
+	if err != nil {
+		return err
+	}
+	sheet := findSheetByTitle(meta, loc.Sheet)
+	if sheet == nil {
+		return errUnknownRole(loc.Sheet)
+	}
+	if _, err := findOrCreateEmployeeRow(srv, sheet.Properties.Title, loc.EmployeeName); err != nil {
+		return err
+	}
+	_, err = findOrCreateDateColumn(srv, meta, sheet.Properties.SheetId, sheet.Properties.Title, loc.Date)
+	return err
+}
+
+// errColumnMoved reports that a day column shifted between locating a cell and writing it:
+// another instance rolled columns over into the archive or inserted a back-dated day
+var errColumnMoved = errors.New("day column moved")
+
+// Helper: Check, right before a write, that column col of a sheet is still the column of day
+// (a day key, see dayKey). layoutMu only holds off this process; other instances are caught here.
+func checkDayColumn(srv *sheets.Service, sheetTitle string, col int, day string) error {
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!1:1", sheetTitle)).Do()
+	if err != nil {
+		return err
+	}
 	var headerRow []interface{}
-	
-	if err == nil && len(resp.Values) > 0 {
+	if len(resp.Values) > 0 {
 		headerRow = resp.Values[0]
-		for i, h := range headerRow {
-			if strings.EqualFold(fmt.Sprintf("%v", h), targetHeader) {
-				targetColIndex = i
-				break
-			}
+	}
+	if decodeHeaderRow(headerRow).findKey(day) != col {
+		return errColumnMoved
+	}
+	return nil
+}
+
+// Helper: Run a cell write, locating the cell again when its column moved under it
+func retryColumnMoved(write func() error) error {
+	for attempt := 0; attempt < 3; attempt++ {
+		if err := write(); !errors.Is(err, errColumnMoved) {
+			return err
 		}
 	}
+	return newError(ErrConflict, "layout_changed", "the sheet's columns kept moving during the write, try again")
+}
 
-	if targetColIndex == -1 {
-		if headerRow == nil {
-			targetColIndex = 1 
-		} else {
-			targetColIndex = len(headerRow)
+// Helper: Row of an employee and column of a day (a day key, see dayKey) in a sheet, -1 when missing
+func findCell(srv *sheets.Service, sheetTitle string, employeeName string, day string) (int, int, error) {
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A:A", sheetTitle)).Do()
+	if err != nil {
+		return -1, -1, err
+	}
+	rowIndex := -1
+	for r, row := range resp.Values {
+		if r > 0 && len(row) > 0 && namesMatch(fmt.Sprintf("%v", row[0]), employeeName) {
+			rowIndex = r
+			break
 		}
+	}
 
-		var maxCol int64
-		for _, s := range meta.Sheets {
-			if s.Properties.SheetId == targetSheetID {
-				maxCol = s.Properties.GridProperties.ColumnCount
-				break
-			}
+	resp, err = srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!1:1", sheetTitle)).Do()
+	if err != nil {
+		return -1, -1, err
+	}
+	colIndex := -1
+	if len(resp.Values) > 0 {
+		colIndex = decodeHeaderRow(resp.Values[0]).findKey(day)
+	}
+	return rowIndex, colIndex, nil
+}
+
+// Helper: Cell lines for edited items; a line whose status did not change keeps its exact color
+func cellLinesAfterEdit(before []cellLine, items []models.TaskItem) []cellLine {
+	colors := map[string]*sheets.Color{}
+	for _, line := range before {
+		colors[line.ID] = line.Color
+	}
+	lines := make([]cellLine, 0, len(items))
+	for _, item := range items {
+		color, ok := colors[item.ID]
+		if !ok || getStatusFromColor(color) != item.Status {
+			color = getColorFromStatus(item.Status)
 		}
+		lines = append(lines, cellLine{ID: item.ID, Task: item.Task, Color: color, CarriedFrom: item.CarriedFrom})
+	}
+	return lines
+}
 
-		if int64(targetColIndex) >= maxCol {
-			appendReq := &sheets.BatchUpdateSpreadsheetRequest{
-				Requests: []*sheets.Request{{
-					AppendDimension: &sheets.AppendDimensionRequest{
-						SheetId: targetSheetID, Dimension: "COLUMNS", Length: 1,
-					},
-				}},
-			}
-			srv.Spreadsheets.BatchUpdate(config.SpreadsheetID, appendReq).Do()
+// Helper: Find the employee's row in a sheet, appending a new row when missing
+func findOrCreateEmployeeRow(srv *sheets.Service, sheetTitle string, employeeName string) (int, error) {
+	rowIndex := -1
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A:A", sheetTitle)).Do()
+	if err != nil {
+		// Appending without knowing the rows would add the employee twice
+		return -1, err
+	}
+	for r, row := range resp.Values {
+		if r > 0 && len(row) > 0 && namesMatch(fmt.Sprintf("%v", row[0]), employeeName) {
+			return r, nil
+		}
+	}
+
+	// Append New Employee
+	appendRange := fmt.Sprintf("'%s'!A:A", sheetTitle)
+	vr := &sheets.ValueRange{
+		Values: [][]interface{}{{employeeName}},
+	}
+	_, err = srv.Spreadsheets.Values.Append(config.Get().SpreadsheetID, appendRange, vr).ValueInputOption("RAW").Do()
+	if err != nil {
+		return -1, fmt.Errorf("failed to add new employee: %v", err)
+	}
+
+	// Re-fetch to find index
+	respRetry, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A:A", sheetTitle)).Do()
+	if err != nil {
+		return -1, err
+	}
+	for r, row := range respRetry.Values {
+		if r > 0 && len(row) > 0 && namesMatch(fmt.Sprintf("%v", row[0]), employeeName) {
+			rowIndex = r
+			break
+		}
+	}
+	if rowIndex == -1 {
+		return -1, fmt.Errorf("failed to locate employee after creation")
+	}
+	return rowIndex, nil
+}
+
+// Helper: Find the column of a calendar day (legacy headers included), adding a new column when missing.
+// The new column goes in date order, so a back-dated day lands left of the later days.
+func findOrCreateDateColumn(srv *sheets.Service, meta *sheets.Spreadsheet, sheetID int64, sheetTitle string, date models.Date) (int, error) {
+	// A failed read must not look like an empty header row: the new header would overwrite B1
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!1:1", sheetTitle)).Do()
+	if err != nil {
+		return -1, fmt.Errorf("failed to read the header row of '%s': %w", sheetTitle, err)
+	}
+	var headerRow []interface{}
+	if len(resp.Values) > 0 {
+		headerRow = resp.Values[0]
+	}
+	headers := decodeHeaderRow(headerRow)
+	if i := headers.find(date); i != -1 {
+		return i, nil
+	}
+	targetColIndex := headers.insertAt(date)
+
+	var maxCol int64
+	for _, s := range meta.Sheets {
+		if s.Properties.SheetId == sheetID {
+			maxCol = s.Properties.GridProperties.ColumnCount
+			break
+		}
+	}
+
+	var req *sheets.Request
+	switch {
+	case targetColIndex < len(headerRow):
+		// Shift the later days right; the new column takes its neighbour's formatting
+		req = &sheets.Request{InsertDimension: &sheets.InsertDimensionRequest{
+			Range: &sheets.DimensionRange{
+				SheetId: sheetID, Dimension: "COLUMNS",
+				StartIndex: int64(targetColIndex), EndIndex: int64(targetColIndex) + 1,
+			},
+			InheritFromBefore: targetColIndex > 1,
+		}}
+	case int64(targetColIndex) >= maxCol:
+		req = &sheets.Request{AppendDimension: &sheets.AppendDimensionRequest{
+			SheetId: sheetID, Dimension: "COLUMNS", Length: 1,
+		}}
+	}
+	if req != nil {
+		update := &sheets.BatchUpdateSpreadsheetRequest{Requests: []*sheets.Request{req}}
+		if _, err := srv.Spreadsheets.BatchUpdate(config.Get().SpreadsheetID, update).Do(); err != nil {
+			return -1, fmt.Errorf("failed to add a column to '%s': %w", sheetTitle, err)
 		}
+	}
 
-		writeRange := fmt.Sprintf("'%s'!%s1", targetSheetTitle, getColumnName(targetColIndex+1))
-		vr := &sheets.ValueRange{Values: [][]interface{}{{targetHeader}}}
-		srv.Spreadsheets.Values.Update(config.SpreadsheetID, writeRange, vr).ValueInputOption("RAW").Do()
+	writeRange := fmt.Sprintf("'%s'!%s1", sheetTitle, getColumnName(targetColIndex+1))
+	vr := &sheets.ValueRange{Values: [][]interface{}{{date.Header()}}}
+	if _, err := srv.Spreadsheets.Values.Update(config.Get().SpreadsheetID, writeRange, vr).ValueInputOption("RAW").Do(); err != nil {
+		return -1, fmt.Errorf("failed to write the %s header in '%s': %w", date, sheetTitle, err)
 	}
 
-	// 6. Update Cell (Rich Text)
-	cellRangeA1 := fmt.Sprintf("'%s'!%s%d", targetSheetTitle, getColumnName(targetColIndex+1), rowIndex+1)
-	cellResp, err := srv.Spreadsheets.Get(config.SpreadsheetID).
+	return targetColIndex, nil
+}
+
+// cellLine is one line of a rich-text cell with its exact foreground color and its task ID
+type cellLine struct {
+	ID    string
+	Task  string
+	Color *sheets.Color
+
+	CarriedFrom *models.Date
+}
+
+// Helper: Read a single cell's lines, keeping each line's color as-is (key identifies the cell, see cellKey)
+func readCellLines(srv *sheets.Service, sheetTitle string, key string, rowIndex, colIndex int) ([]cellLine, error) {
+	cellRangeA1 := fmt.Sprintf("'%s'!%s%d", sheetTitle, getColumnName(colIndex+1), rowIndex+1)
+	cellResp, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).
 		Ranges(cellRangeA1).
-		Fields("sheets(data(rowData(values(userEnteredValue,textFormatRuns,userEnteredFormat(textFormat(foregroundColor))))))").
+		Fields("sheets(data(rowData(values(userEnteredValue,note,textFormatRuns,userEnteredFormat(textFormat(foregroundColor))))))").
 		Do()
-
-	type taskData struct {
-		Task  string
-		Color *sheets.Color
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cell %s: %w", cellRangeA1, err)
 	}
-	var existingTasks []taskData
 
-	if err == nil && len(cellResp.Sheets) > 0 && len(cellResp.Sheets[0].Data) > 0 &&
+	var existingTasks []cellLine
+
+	if len(cellResp.Sheets) > 0 && len(cellResp.Sheets[0].Data) > 0 &&
 		len(cellResp.Sheets[0].Data[0].RowData) > 0 && len(cellResp.Sheets[0].Data[0].RowData[0].Values) > 0 {
 
 		cell := cellResp.Sheets[0].Data[0].RowData[0].Values[0]
@@ -535,44 +955,54 @@ func AddTask(req models.TaskRequest) error {
 				}
 
 				if strings.TrimSpace(line) != "" {
-					existingTasks = append(existingTasks, taskData{
+					existingTasks = append(existingTasks, cellLine{
 						Task:  strings.TrimSpace(line),
 						Color: activeColor,
 					})
 				}
 				currIdx += len(line) + 1
 			}
-		}
-	}
 
-	for _, newTask := range req.Tasks {
-		found := false
-		for i, existing := range existingTasks {
-			if strings.EqualFold(existing.Task, newTask.Task) {
-				existingTasks[i].Color = getColorFromStatus(newTask.Status)
-				found = true
-				break
+			tasks := make([]string, len(existingTasks))
+			for i, line := range existingTasks {
+				tasks[i] = line.Task
+			}
+			for i, n := range assignTaskIDs(key, tasks, parseTaskIDNote(cell.Note)) {
+				existingTasks[i].ID, existingTasks[i].CarriedFrom = n.ID, n.From
 			}
 		}
-		if !found {
-			existingTasks = append(existingTasks, taskData{
-				Task:  newTask.Task,
-				Color: getColorFromStatus(newTask.Status),
-			})
-		}
 	}
+	return existingTasks, nil
+}
+
+// Helper: Convert task items into colored cell lines
+func cellLinesFromItems(items []models.TaskItem) []cellLine {
+	lines := make([]cellLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, cellLine{ID: item.ID, Task: item.Task, Color: getColorFromStatus(item.Status), CarriedFrom: item.CarriedFrom})
+	}
+	return lines
+}
 
+// Helper: Rebuild a cell's text, TextFormatRuns and task ID note from its lines and write it with UpdateCells
+func writeCellLines(srv *sheets.Service, sheetID int64, rowIndex, colIndex int, lines []cellLine) error {
 	var newTextBuilder string
 	var newRuns []*sheets.TextFormatRun
 
-	for i, item := range existingTasks {
+	for i := range lines {
+		if lines[i].ID == "" {
+			lines[i].ID = newTaskID()
+		}
+	}
+
+	for i, item := range lines {
 		startIdx := int64(len([]rune(newTextBuilder)))
 		newTextBuilder += item.Task
 		newRuns = append(newRuns, &sheets.TextFormatRun{
 			StartIndex: startIdx,
 			Format: &sheets.TextFormat{ ForegroundColor: item.Color },
 		})
-		if i < len(existingTasks)-1 { newTextBuilder += "\n" }
+		if i < len(lines)-1 { newTextBuilder += "\n" }
 	}
 
 	reqBatch := &sheets.BatchUpdateSpreadsheetRequest{
@@ -580,11 +1010,11 @@ func AddTask(req models.TaskRequest) error {
 			{
 				UpdateCells: &sheets.UpdateCellsRequest{
 					Range: &sheets.GridRange{
-						SheetId:          targetSheetID,
+						SheetId:          sheetID,
 						StartRowIndex:    int64(rowIndex),
 						EndRowIndex:      int64(rowIndex + 1),
-						StartColumnIndex: int64(targetColIndex),
-						EndColumnIndex:   int64(targetColIndex + 1),
+						StartColumnIndex: int64(colIndex),
+						EndColumnIndex:   int64(colIndex + 1),
 					},
 					Rows: []*sheets.RowData{
 						{
@@ -592,17 +1022,18 @@ func AddTask(req models.TaskRequest) error {
 								{
 									UserEnteredValue: &sheets.ExtendedValue{StringValue: &newTextBuilder},
 									TextFormatRuns:   newRuns,
+									Note:             taskIDNote(lines),
 								},
 							},
 						},
 					},
-					Fields: "userEnteredValue,textFormatRuns",
+					Fields: "userEnteredValue,textFormatRuns,note",
 				},
 			},
 		},
 	}
 
-	_, err = srv.Spreadsheets.BatchUpdate(config.SpreadsheetID, reqBatch).Do()
+	_, err := srv.Spreadsheets.BatchUpdate(config.Get().SpreadsheetID, reqBatch).Do()
 	return err
 }
 
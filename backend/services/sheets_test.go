@@ -0,0 +1,180 @@
+package services
+
+import (
+	"go-backend/config"
+	"go-backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestSheetsStoreTaskRoundTrip(t *testing.T) {
+	fake, client := startFakeSheets(t)
+	config.InitDB(client)
+	env := everyDayWorking(t)
+	store := NewSheetsStore(client, env)
+	today := env.EmployeeToday(store, "Ann")
+
+	req := models.TaskRequest{EmployeeName: "Ann", Role: "DEV", Date: today, Tasks: []models.TaskItem{
+		{Task: "write docs", Status: "todo"},
+		{Task: "review PR", Status: "pending"},
+	}}
+	if err := store.AddTask(req); err != nil {
+		t.Fatalf("AddTask: %v", err)
+	}
+	// A rate-limited read is retried by the client
+	fake.FailNext(1, http.StatusTooManyRequests, "")
+	emp, err := store.GetLatestTasks("ann")
+	if err != nil {
+		t.Fatalf("GetLatestTasks: %v", err)
+	}
+	if emp.SheetName != "DEV" || len(emp.History) != 1 || emp.History[0].Date != today {
+		t.Fatalf("history %+v on %q, want today's cell on DEV", emp.History, emp.SheetName)
+	}
+	items := emp.History[0].Tasks
+	if len(items) != 2 || items[0].ID == "" || items[0].ID == items[1].ID {
+		t.Fatalf("tasks %+v, want two lines with their own IDs", items)
+	}
+
+	name, status := "write the docs", "complete"
+	steps := []struct {
+		name  string
+		apply func() (TaskLocation, error)
+		want  []models.TaskItem
+	}{
+		{
+			name: "rename and complete",
+			apply: func() (TaskLocation, error) {
+				return store.UpdateTask(items[0].ID, TaskUpdate{Task: &name, Status: &status}, "Ann")
+			},
+			want: []models.TaskItem{{ID: items[0].ID, Task: name, Status: status}, items[1]},
+		},
+		{
+			name:  "find by ID",
+			apply: func() (TaskLocation, error) { return store.FindTask(items[1].ID) },
+			want:  []models.TaskItem{{ID: items[0].ID, Task: name, Status: status}, items[1]},
+		},
+		{
+			name:  "delete",
+			apply: func() (TaskLocation, error) { return store.DeleteTask(items[1].ID, "Ann") },
+			want:  []models.TaskItem{{ID: items[0].ID, Task: name, Status: status}},
+		},
+	}
+	for _, step := range steps {
+		loc, err := step.apply()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if loc.Sheet != "DEV" || loc.Date != today || loc.EmployeeName != "Ann" {
+			t.Errorf("%s: located at %+v", step.name, loc)
+		}
+		emp, err := store.GetLatestTasks("Ann")
+		if err != nil {
+			t.Fatalf("%s: GetLatestTasks: %v", step.name, err)
+		}
+		got := emp.History[0].Tasks
+		if len(got) != len(step.want) {
+			t.Fatalf("%s: tasks %+v, want %+v", step.name, got, step.want)
+		}
+		for i := range got {
+			if got[i].ID != step.want[i].ID || got[i].Task != step.want[i].Task || got[i].Status != step.want[i].Status {
+				t.Errorf("%s: task %d = %+v, want %+v", step.name, i, got[i], step.want[i])
+			}
+		}
+	}
+
+	if _, err := store.FindTask(items[1].ID); AsError(err) == nil || AsError(err).Kind != ErrNotFound {
+		t.Errorf("FindTask of a deleted task = %v, want not found", err)
+	}
+}
+
+func TestSheetsStoreInsertsBackDatedColumnInOrder(t *testing.T) {
+	fake, client := startFakeSheets(t)
+	config.InitDB(client)
+	config.Get().EditWindow.DaysBack = 5
+	env := everyDayWorking(t)
+	store := NewSheetsStore(client, env)
+	today := env.EmployeeToday(store, "Ann")
+	legacy := func(d models.Date) string { return d.Time().Format(models.LegacyHeaderLayout) }
+
+	// A sheet written before headers had a year
+	id := config.Get().SpreadsheetID
+	for _, v := range []struct {
+		row, col int
+		value    string
+	}{
+		{0, 1, legacy(today.AddDays(-1))},
+		{0, 2, legacy(today)},
+		{1, 0, "Ann"},
+		{1, 1, "yesterday's task"},
+		{1, 2, "today's task"},
+	} {
+		if err := fake.SetValue(id, "DEV", v.row, v.col, v.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	back := today.AddDays(-3)
+	if err := store.AddTask(models.TaskRequest{EmployeeName: "Ann", Role: "DEV", Date: back, Tasks: []models.TaskItem{{Task: "late entry", Status: "todo"}}}); err != nil {
+		t.Fatalf("AddTask: %v", err)
+	}
+
+	srv, err := client.Service()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Spreadsheets.Values.Get(id, "'DEV'!1:1").Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Employee Name", back.Header(), legacy(today.AddDays(-1)), legacy(today)}
+	if len(resp.Values) != 1 || len(resp.Values[0]) != len(want) {
+		t.Fatalf("header row %v, want %v", resp.Values, want)
+	}
+	for i, h := range resp.Values[0] {
+		if h != want[i] {
+			t.Errorf("header %d = %v, want %q", i, h, want[i])
+		}
+	}
+
+	emp, err := store.GetLatestTasks("Ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[models.Date]string{}
+	for _, day := range emp.History {
+		if len(day.Tasks) == 1 {
+			got[day.Date] = day.Tasks[0].Task
+		}
+	}
+	for d, task := range map[models.Date]string{back: "late entry", today.AddDays(-1): "yesterday's task", today: "today's task"} {
+		if got[d] != task {
+			t.Errorf("%s holds %q, want %q (history %v)", d, got[d], task, got)
+		}
+	}
+}
+
+func TestFindOrCreateEmployeeRowFailedReadAddsNoRow(t *testing.T) {
+	fake, client := startFakeSheets(t)
+	srv, err := client.Service()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake.FailNext(1, http.StatusForbidden, "")
+	if _, err := findOrCreateEmployeeRow(srv, "DEV", "Ann"); err == nil {
+		t.Fatal("a failed read of the names was not returned")
+	}
+	for i := 0; i < 2; i++ {
+		row, err := findOrCreateEmployeeRow(srv, "DEV", "Ann")
+		if err != nil || row != 1 {
+			t.Fatalf("call %d: row %d, %v; want row 1", i, row, err)
+		}
+	}
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, "'DEV'!A:A").Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Values) != 2 {
+		t.Fatalf("names %v, want the header and one row for Ann", resp.Values)
+	}
+}
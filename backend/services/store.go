@@ -0,0 +1,189 @@
+// services/store.go
+package services
+
+import (
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"sort"
+	"strings"
+)
+
+// TaskStore reads and writes the per-day task cells of each employee
+type TaskStore interface {
+	GetLatestTasks(employeeName string) (models.EmployeeTasksResponse, error)
+	GetAllEmployeesLatestTasks() (models.TeamTasksResponse, error)
+	AddTask(req models.TaskRequest) error
+
+	// By stable task ID, see models.TaskItem.ID
+	FindTask(id string) (TaskLocation, error)
+	UpdateTask(id string, update TaskUpdate, actor string) (TaskLocation, error)
+	DeleteTask(id string, actor string) (TaskLocation, error)
+
+	// On one employee/day cell; a line is named by its task ID or its text
+	UpdateCellTask(cell CellRef, line string, update TaskUpdate) (models.DayTasks, error)
+	DeleteCellTask(cell CellRef, line string) (models.DayTasks, error)
+	ReorderCell(cell CellRef, order []string) (models.DayTasks, error)
+
+	// CarryOver copies the unfinished tasks of an employee's last populated day into a day
+	CarryOver(req CarryOverRequest) (CarryOverResult, error)
+}
+
+// MetadataStore keeps one record per known employee
+type MetadataStore interface {
+	GetAllEmployeesMetadata() ([]EmployeeMetadata, error)
+	UpsertEmployeeMetadata(name, timezone string) error // timezone "" keeps the recorded zone
+}
+
+// LogStore keeps one record per employee and task date, timestamped in the employee's zone and in UTC
+type LogStore interface {
+	GetAllDailyLogs() ([]DailyLog, error)
+	UpsertDailyLog(name, date string) error
+}
+
+// Store is the full storage backend used by the handlers
+type Store interface {
+	TaskStore
+	MetadataStore
+	LogStore
+}
+
+// Storage backend names accepted by NewStore
+const (
+	BackendSheets   = "sheets"
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// NewStore builds the storage backend selected at startup.
+// databaseURL is only used by the postgres backend, client only by the sheets backend.
+func NewStore(backend, databaseURL string, client *config.SheetsClient, env *Env) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", BackendSheets:
+		return NewSheetsStore(client, env), nil
+	case BackendMemory:
+		return NewMemoryStore(env), nil
+	case BackendPostgres:
+		return NewPostgresStore(databaseURL, env)
+	default:
+		return nil, fmt.Errorf("unknown storage backend '%s'", backend)
+	}
+}
+
+// Helper: Merge incoming tasks into a cell's lines (case-insensitive match updates the status)
+func mergeTasks(existing []models.TaskItem, updates []models.TaskItem) []models.TaskItem {
+	for _, newTask := range updates {
+		found := false
+		for i, item := range existing {
+			if strings.EqualFold(item.Task, newTask.Task) {
+				existing[i].Status = normalizeStatus(newTask.Status)
+				found = true
+				break
+			}
+		}
+		if !found && strings.TrimSpace(newTask.Task) != "" {
+			existing = append(existing, models.TaskItem{
+				ID:     newTaskID(),
+				Task:   strings.TrimSpace(newTask.Task),
+				Status: normalizeStatus(newTask.Status),
+			})
+		}
+	}
+	return existing
+}
+
+// Helper: Normalize a status the way a color round-trip through the sheet would
+func normalizeStatus(status string) string {
+	switch strings.ToLower(status) {
+	case "complete":
+		return "complete"
+	case "pending":
+		return "pending"
+	default:
+		return "todo"
+	}
+}
+
+// Helper: Categorize a cell's lines by status
+func newDayTasks(date models.Date, label string, items []models.TaskItem) models.DayTasks {
+	dt := models.DayTasks{
+		Date:     date,
+		Label:    label,
+		Todo:     []string{},
+		Pending:  []string{},
+		Complete: []string{},
+		Tasks:    []models.TaskItem{},
+	}
+	for _, item := range items {
+		dt.Tasks = append(dt.Tasks, item)
+		switch item.Status {
+		case "complete":
+			dt.Complete = append(dt.Complete, item.Task)
+		case "pending":
+			dt.Pending = append(dt.Pending, item.Task)
+		default:
+			dt.Todo = append(dt.Todo, item.Task)
+		}
+	}
+	return dt
+}
+
+// Helper: Outcome of reading one role sheet
+func sheetStatus(title string, err error) models.SheetStatus {
+	if err != nil {
+		return models.SheetStatus{Sheet: title, Error: err.Error()}
+	}
+	return models.SheetStatus{Sheet: title, OK: true}
+}
+
+// Helper: Describe the failed sheets, e.g. "Managers (quota exceeded)"
+func failedSheets(statuses []models.SheetStatus) string {
+	var failed []string
+	for _, st := range statuses {
+		if !st.OK {
+			failed = append(failed, fmt.Sprintf("%s (%s)", st.Sheet, st.Error))
+		}
+	}
+	return strings.Join(failed, ", ")
+}
+
+// Helper: Assemble the team view from per-sheet results; it fails only when no sheet could be read
+func newTeamTasks(employees []models.EmployeeTasksResponse, statuses []models.SheetStatus) (models.TeamTasksResponse, error) {
+	okCount := 0
+	for _, st := range statuses {
+		if st.OK {
+			okCount++
+		}
+	}
+	if okCount == 0 && len(statuses) > 0 {
+		return models.TeamTasksResponse{}, newError(ErrUnavailable, "sheets_unavailable", "unable to read any role sheet: %s", failedSheets(statuses))
+	}
+
+	sort.Slice(employees, func(i, j int) bool {
+		return employees[i].EmployeeName < employees[j].EmployeeName
+	})
+	if employees == nil {
+		employees = []models.EmployeeTasksResponse{}
+	}
+
+	return models.TeamTasksResponse{
+		Employees: employees,
+		Sheets:    statuses,
+		Partial:   okCount < len(statuses),
+	}, nil
+}
+
+// Helper: Attach the searched sheets to a single-employee lookup.
+// A miss is only "not found" when every sheet could be read.
+func (e *Env) withSheetStatuses(resp models.EmployeeTasksResponse, found bool, employeeName string, statuses []models.SheetStatus) (models.EmployeeTasksResponse, error) {
+	partial := failedSheets(statuses) != ""
+	if !found {
+		if partial {
+			return models.EmployeeTasksResponse{}, newError(ErrUnavailable, "sheets_unavailable", "%v; unreadable sheets: %s", e.errEmployeeNotFound(employeeName), failedSheets(statuses))
+		}
+		return models.EmployeeTasksResponse{}, e.errEmployeeNotFound(employeeName)
+	}
+	resp.Sheets = statuses
+	resp.Partial = partial
+	return resp, nil
+}
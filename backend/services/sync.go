@@ -0,0 +1,601 @@
+// services/sync.go
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Per-cell sync statuses
+const (
+	SyncStatusSynced   = "synced"   // Sheet and database agree
+	SyncStatusPending  = "pending"  // Database changed since the last pass, not yet written to the sheet
+	SyncStatusConflict = "conflict" // Both sides changed since the last pass
+	SyncStatusError    = "error"    // The last pass failed for this cell
+)
+
+// SyncCellStatus is the sync state of one employee/day cell
+type SyncCellStatus struct {
+	Role         string  `json:"role"`
+	EmployeeName string  `json:"employee_name"`
+	Date         string  `json:"date"` // "2006-01-02", or the column header when it is not a date
+	Status       string  `json:"status"`
+	LastError    string  `json:"last_error,omitempty"`
+	LastSyncedAt *string `json:"last_synced_at"`
+	UpdatedAt    string  `json:"updated_at,omitempty"`
+
+	// Content hashes: at the last successful pass, and as last seen on each side
+	syncedHash string
+	sheetHash  string
+	dbHash     string
+}
+
+// SyncReport summarizes one sync pass
+type SyncReport struct {
+	StartedAt  string `json:"started_at"`
+	Duration   string `json:"duration"`
+	Pulled     int    `json:"pulled"` // Sheet -> database
+	Pushed     int    `json:"pushed"` // Database -> sheet
+	Unchanged  int    `json:"unchanged"`
+	Conflicts  int    `json:"conflicts"`
+	Errors     int    `json:"errors"`
+	Skipped    int    `json:"skipped"` // Changed during the pass, retried next time
+	FatalError string `json:"fatal_error,omitempty"`
+}
+
+// sheetCell is one employee/day cell as read from a role sheet
+type sheetCell struct {
+	Role         string
+	SheetID      int64
+	EmployeeName string
+	Date         string // Day key, see dayKey
+	Row          int
+	Col          int
+	Items        []models.TaskItem
+	Archived     bool // Read from an archive tab (closed period), never written back
+}
+
+// Syncer mirrors the role sheets into the Postgres store and writes
+// API-originated database changes back to the sheets
+type Syncer struct {
+	db      *PostgresStore
+	env     *Env // db's
+	sheets  *config.SheetsClient
+	mu      sync.Mutex // One pass at a time
+	trigger chan struct{}
+
+	lastMu     sync.RWMutex
+	lastReport *SyncReport
+
+	onChange func(role, employeeName string) // Called after a cell is pulled into the database
+}
+
+// NewSyncer returns a Syncer between the spreadsheet behind client and the given Postgres store
+func NewSyncer(db *PostgresStore, client *config.SheetsClient) *Syncer {
+	return &Syncer{db: db, env: db.env, sheets: client, trigger: make(chan struct{}, 1)}
+}
+
+// SetOnChange registers fn to be called whenever the sync changes a database cell
+func (s *Syncer) SetOnChange(fn func(role, employeeName string)) {
+	s.onChange = fn
+}
+
+// Helper: Notify the change hook, if any
+func (s *Syncer) changed(role, employeeName string) {
+	if s.onChange != nil {
+		s.onChange(role, employeeName)
+	}
+}
+
+// Run syncs every interval (and whenever triggered) until ctx is cancelled
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SyncOnce(); err != nil {
+			log.Printf("Sync failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.trigger:
+		}
+	}
+}
+
+// Trigger asks the background loop to run a pass as soon as possible
+func (s *Syncer) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// LastReport returns the report of the most recent pass, if any
+func (s *Syncer) LastReport() *SyncReport {
+	s.lastMu.RLock()
+	defer s.lastMu.RUnlock()
+	return s.lastReport
+}
+
+// Helper: The cell a sync status is about
+func (st SyncCellStatus) location() TaskLocation {
+	d, _ := models.ParseDate(st.Date) // Zero for headers that are not dates
+	return TaskLocation{EmployeeName: st.EmployeeName, Sheet: st.Role, Date: d, Label: st.Date}
+}
+
+// Helper: Hash the ordered lines of a cell ("" for an empty cell)
+func hashItems(items []models.TaskItem) string {
+	if len(items) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, item := range items {
+		fmt.Fprintf(h, "%s\x1f%s\n", normalizeStatus(item.Status), item.Task)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// What a pass does with a cell
+const (
+	syncKeep     = "keep"     // Both sides agree
+	syncPull     = "pull"     // Only the sheet changed: sheet -> database
+	syncPush     = "push"     // Only the database changed: database -> sheet
+	syncConflict = "conflict" // Both changed, and differently; left for Resolve
+)
+
+// Helper: Decide a cell from the hashes of its lines on each side and at the last pass ("" when
+// it was empty or never synced). Both sides making the same change is no conflict.
+func reconcileCell(sheetHash, dbHash, base string) string {
+	switch {
+	case sheetHash == dbHash:
+		return syncKeep
+	case dbHash == base:
+		return syncPull
+	case sheetHash == base:
+		return syncPush
+	default:
+		return syncConflict
+	}
+}
+
+// Helper: Case-insensitive identity of a cell
+func syncKey(role, employeeName, date string) string {
+	return strings.ToLower(strings.TrimSpace(role)) + "\x00" +
+		strings.ToLower(strings.TrimSpace(employeeName)) + "\x00" +
+		strings.ToLower(strings.TrimSpace(date))
+}
+
+// Helper: Read every non-empty cell of the role sheets, and of their archive tabs when archiving is on
+func (e *Env) readSheetCells(srv *sheets.Service, meta *sheets.Spreadsheet) ([]*sheetCell, error) {
+	var cells []*sheetCell
+	for _, targetTitle := range e.roleSheets() {
+		sheet := findSheetByTitle(meta, targetTitle)
+		if sheet == nil {
+			continue
+		}
+		title := sheet.Properties.Title
+
+		rows, headerRow, err := fetchSheetGrid(srv, title)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet '%s': %v", title, err)
+		}
+		cells = append(cells, gridCells(title, sheet.Properties.SheetId, rows, headerRow, false)...)
+
+		// Archived days keep their role so moving them out of the live tab is not a deletion
+		if !archiveEnabled() {
+			continue
+		}
+		tabs, err := archiveTabs(srv, title)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list archive tabs of '%s': %v", title, err)
+		}
+		for _, tab := range tabs {
+			rows, headerRow, err := fetchSheetGridIn(srv, archiveSpreadsheetID(), tab.Title)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read sheet '%s': %v", tab.Title, err)
+			}
+			cells = append(cells, gridCells(title, 0, rows, headerRow, true)...)
+		}
+	}
+	return cells, nil
+}
+
+// Helper: The non-empty employee/day cells of one tab
+func gridCells(role string, sheetID int64, rows []*sheets.RowData, headerRow []interface{}, archived bool) []*sheetCell {
+	var cells []*sheetCell
+	headers := decodeHeaderRow(headerRow)
+
+	for rIdx, row := range rows {
+		if rIdx == 0 || len(row.Values) == 0 {
+			continue
+		}
+		nameCell := row.Values[0]
+		if nameCell.UserEnteredValue == nil || nameCell.UserEnteredValue.StringValue == nil {
+			continue
+		}
+		empName := strings.TrimSpace(*nameCell.UserEnteredValue.StringValue)
+		if empName == "" {
+			continue
+		}
+
+		for cIdx := 1; cIdx < len(row.Values) && cIdx < len(headerRow); cIdx++ {
+			date := dayKey(headers.column(cIdx))
+			items := parseCellLines(cellKey(empName, date), row.Values[cIdx])
+			if date == "" || len(items) == 0 {
+				continue
+			}
+			cells = append(cells, &sheetCell{
+				Role:         role,
+				SheetID:      sheetID,
+				EmployeeName: empName,
+				Date:         date,
+				Row:          rIdx,
+				Col:          cIdx,
+				Items:        items,
+				Archived:     archived,
+			})
+		}
+	}
+	return cells
+}
+
+// SyncOnce runs a single pass over every cell known to either side.
+// A cell is pulled when only the sheet changed since the last pass, pushed when
+// only the database changed, and marked as a conflict when both changed.
+func (s *Syncer) SyncOnce() (SyncReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	started := time.Now()
+	report := SyncReport{StartedAt: started.Format(time.RFC3339)}
+	defer func() {
+		report.Duration = time.Since(started).String()
+		s.lastMu.Lock()
+		s.lastReport = &report
+		s.lastMu.Unlock()
+	}()
+
+	fail := func(err error) (SyncReport, error) {
+		report.FatalError = err.Error()
+		return report, err
+	}
+
+	srv, err := s.sheets.Service()
+	if err != nil {
+		return fail(err)
+	}
+	layoutMu.RLock()
+	defer layoutMu.RUnlock()
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Do()
+	if err != nil {
+		return fail(err)
+	}
+
+	// 1. Snapshot both sides and the last known state.
+	// A failed sheet read aborts the pass: a missing tab must not look like deleted cells.
+	sheetList, err := s.env.readSheetCells(srv, meta)
+	if err != nil {
+		return fail(err)
+	}
+	dbList, err := s.db.mirrorCells()
+	if err != nil {
+		return fail(err)
+	}
+	stateList, err := s.db.syncStates()
+	if err != nil {
+		return fail(err)
+	}
+
+	var keys []string
+	seen := map[string]bool{}
+	addKey := func(k string) {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	sheetCells := map[string]*sheetCell{}
+	for _, c := range sheetList {
+		k := syncKey(c.Role, c.EmployeeName, c.Date)
+		sheetCells[k] = c
+		addKey(k)
+	}
+	dbCells := map[string]mirrorCell{}
+	for _, c := range dbList {
+		k := syncKey(c.Role, c.EmployeeName, c.Date)
+		dbCells[k] = c
+		addKey(k)
+	}
+	states := map[string]SyncCellStatus{}
+	for _, st := range stateList {
+		k := syncKey(st.Role, st.EmployeeName, st.Date)
+		states[k] = st
+		if st.syncedHash != "" {
+			// Previously non-empty, now possibly cleared on one or both sides
+			addKey(k)
+		}
+	}
+
+	// Cells already in the sheet go first: pushing a new day may insert a column,
+	// which shifts the positions read above
+	sort.SliceStable(keys, func(i, j int) bool {
+		return sheetCells[keys[i]] != nil && sheetCells[keys[j]] == nil
+	})
+
+	// 2. Reconcile each cell
+	for _, k := range keys {
+		sc := sheetCells[k]
+		dc, inDB := dbCells[k]
+		prev, hasPrev := states[k]
+
+		st := SyncCellStatus{}
+		switch {
+		case sc != nil:
+			st.Role, st.EmployeeName, st.Date = sc.Role, sc.EmployeeName, sc.Date
+		case inDB:
+			st.Role, st.EmployeeName, st.Date = dc.Role, dc.EmployeeName, dc.Date
+		default:
+			st.Role, st.EmployeeName, st.Date = prev.Role, prev.EmployeeName, prev.Date
+		}
+
+		var sheetItems, dbItems []models.TaskItem
+		if sc != nil {
+			sheetItems = sc.Items
+		}
+		if inDB {
+			dbItems = dc.Items
+		}
+		st.sheetHash = hashItems(sheetItems)
+		st.dbHash = hashItems(dbItems)
+		base := ""
+		if hasPrev {
+			base = prev.syncedHash
+		}
+
+		switch reconcileCell(st.sheetHash, st.dbHash, base) {
+		case syncKeep:
+			if hasPrev && prev.Status == SyncStatusSynced && prev.syncedHash == st.sheetHash {
+				report.Unchanged++
+				continue
+			}
+			st.Status = SyncStatusSynced
+			st.syncedHash = st.sheetHash
+			report.Unchanged++
+
+		case syncPull:
+			ok, err := s.db.replaceCell(st.Role, st.EmployeeName, st.Date, st.dbHash, sheetItems)
+			if err != nil {
+				st.Status, st.LastError, st.syncedHash = SyncStatusError, err.Error(), base
+				report.Errors++
+				break
+			}
+			if !ok {
+				report.Skipped++
+				continue
+			}
+			st.Status = SyncStatusSynced
+			st.syncedHash = st.sheetHash
+			report.Pulled++
+			s.changed(st.Role, st.EmployeeName)
+			s.env.publishCellChange(st.location(), "", EventSourceSheet, dbItems, sheetItems)
+			logSheetEdit(s.db, st.location(), sheetItems)
+
+		case syncPush:
+			if err := s.pushCell(srv, sc, st, dbItems); err != nil {
+				st.Status, st.LastError, st.syncedHash = SyncStatusError, err.Error(), base
+				report.Errors++
+				break
+			}
+			st.Status = SyncStatusSynced
+			st.syncedHash = st.dbHash
+			report.Pushed++
+
+		default:
+			st.Status = SyncStatusConflict
+			st.syncedHash = base
+			report.Conflicts++
+		}
+
+		if err := s.db.saveSyncState(st); err != nil {
+			log.Printf("Sync: failed to save state for %s/%s/%s: %v", st.Role, st.EmployeeName, st.Date, err)
+		}
+	}
+
+	return report, nil
+}
+
+// Helper: Write database items to the sheet cell through the rich-text UpdateCells path.
+// sc is nil when the cell does not exist in the sheet yet.
+func (s *Syncer) pushCell(srv *sheets.Service, sc *sheetCell, st SyncCellStatus, items []models.TaskItem) error {
+	if sc != nil && sc.Archived {
+		return newError(ErrConflict, "cell_archived", "%s is archived and read-only", st.Date)
+	}
+	if sc != nil {
+		// Positions are from the start of the pass; another instance may have moved the column since
+		err := checkDayColumn(srv, sc.Role, sc.Col, sc.Date)
+		if err == nil {
+			return writeCellLines(srv, sc.SheetID, sc.Row, sc.Col, cellLinesFromItems(items))
+		}
+		if !errors.Is(err, errColumnMoved) {
+			return err
+		}
+	}
+
+	// Fresh metadata: earlier pushes in this pass may have added columns
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Do()
+	if err != nil {
+		return err
+	}
+	sheet := findSheetByTitle(meta, st.Role)
+	if sheet == nil {
+		return fmt.Errorf("sheet '%s' not found", st.Role)
+	}
+	title := sheet.Properties.Title
+	date, err := models.ParseDate(st.Date)
+	if err != nil {
+		return fmt.Errorf("column '%s' no longer exists in sheet '%s'", st.Date, title)
+	}
+
+	return retryColumnMoved(func() error {
+		rowIndex, err := findOrCreateEmployeeRow(srv, title, st.EmployeeName)
+		if err != nil {
+			return err
+		}
+		colIndex, err := findOrCreateDateColumn(srv, meta, sheet.Properties.SheetId, title, date)
+		if err != nil {
+			return err
+		}
+		if err := checkDayColumn(srv, title, colIndex, st.Date); err != nil {
+			return err
+		}
+		return writeCellLines(srv, sheet.Properties.SheetId, rowIndex, colIndex, cellLinesFromItems(items))
+	})
+}
+
+// Status reports the sync state of every cell, optionally filtered by status.
+// Cells changed through the API since the last pass are reported as pending.
+func (s *Syncer) Status(filter string) ([]SyncCellStatus, error) {
+	stateList, err := s.db.syncStates()
+	if err != nil {
+		return nil, err
+	}
+	dbList, err := s.db.mirrorCells()
+	if err != nil {
+		return nil, err
+	}
+
+	dbHashes := map[string]string{}
+	for _, c := range dbList {
+		dbHashes[syncKey(c.Role, c.EmployeeName, c.Date)] = hashItems(c.Items)
+	}
+
+	seen := map[string]bool{}
+	var result []SyncCellStatus
+	for _, st := range stateList {
+		k := syncKey(st.Role, st.EmployeeName, st.Date)
+		seen[k] = true
+		if st.Status == SyncStatusSynced && dbHashes[k] != st.syncedHash {
+			st.Status = SyncStatusPending
+		}
+		result = append(result, st)
+	}
+	for _, c := range dbList {
+		if !seen[syncKey(c.Role, c.EmployeeName, c.Date)] {
+			result = append(result, SyncCellStatus{
+				Role:         c.Role,
+				EmployeeName: c.EmployeeName,
+				Date:         c.Date,
+				Status:       SyncStatusPending,
+			})
+		}
+	}
+
+	filtered := []SyncCellStatus{}
+	for _, st := range result {
+		if filter == "" || strings.EqualFold(filter, st.Status) {
+			filtered = append(filtered, st)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].Role != filtered[j].Role {
+			return filtered[i].Role < filtered[j].Role
+		}
+		return filtered[i].EmployeeName < filtered[j].EmployeeName
+	})
+	return filtered, nil
+}
+
+// Resolve settles a conflicted cell by keeping one side ("sheet" or "database")
+func (s *Syncer) Resolve(role, employeeName, date, keep string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	srv, err := s.sheets.Service()
+	if err != nil {
+		return err
+	}
+	layoutMu.RLock()
+	defer layoutMu.RUnlock()
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Do()
+	if err != nil {
+		return err
+	}
+
+	// Accept any date spelling for the day key
+	if d, err := models.ParseDate(date); err == nil {
+		date = d.String()
+	}
+	k := syncKey(role, employeeName, date)
+	sheetList, err := s.env.readSheetCells(srv, meta)
+	if err != nil {
+		return err
+	}
+	var sc *sheetCell
+	for _, c := range sheetList {
+		if syncKey(c.Role, c.EmployeeName, c.Date) == k {
+			sc = c
+			break
+		}
+	}
+	dbList, err := s.db.mirrorCells()
+	if err != nil {
+		return err
+	}
+	var dbItems []models.TaskItem
+	for _, c := range dbList {
+		if syncKey(c.Role, c.EmployeeName, c.Date) == k {
+			dbItems = c.Items
+			break
+		}
+	}
+
+	st := SyncCellStatus{Role: role, EmployeeName: employeeName, Date: date, Status: SyncStatusSynced}
+	if sc != nil {
+		st.Role, st.EmployeeName, st.Date = sc.Role, sc.EmployeeName, sc.Date
+	}
+	var sheetItems []models.TaskItem
+	if sc != nil {
+		sheetItems = sc.Items
+	}
+
+	switch strings.ToLower(keep) {
+	case "sheet":
+		ok, err := s.db.replaceCell(st.Role, st.EmployeeName, st.Date, hashItems(dbItems), sheetItems)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return newError(ErrConflict, "cell_changed", "cell changed while resolving, try again")
+		}
+		st.syncedHash = hashItems(sheetItems)
+		s.changed(st.Role, st.EmployeeName)
+		s.env.publishCellChange(st.location(), "", EventSourceSheet, dbItems, sheetItems)
+		logSheetEdit(s.db, st.location(), sheetItems)
+	case "database":
+		if err := s.pushCell(srv, sc, st, dbItems); err != nil {
+			return err
+		}
+		st.syncedHash = hashItems(dbItems)
+	default:
+		return newError(ErrValidation, "invalid_keep", "keep must be 'sheet' or 'database'")
+	}
+
+	st.sheetHash, st.dbHash = st.syncedHash, st.syncedHash
+	return s.db.saveSyncState(st)
+}
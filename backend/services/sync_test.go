@@ -0,0 +1,65 @@
+package services
+
+import (
+	"go-backend/models"
+	"testing"
+)
+
+func TestReconcileCell(t *testing.T) {
+	lines := func(tasks ...string) string {
+		var items []models.TaskItem
+		for _, task := range tasks {
+			items = append(items, models.TaskItem{Task: task, Status: "todo"})
+		}
+		return hashItems(items)
+	}
+	tests := []struct {
+		name              string
+		sheet, db, synced string
+		want              string
+	}{
+		{"nothing changed", lines("a"), lines("a"), lines("a"), syncKeep},
+		{"first pass, both sides equal", lines("a"), lines("a"), "", syncKeep},
+		{"same edit on both sides", lines("a", "b"), lines("a", "b"), lines("a"), syncKeep},
+		{"edited in the sheet", lines("a", "b"), lines("a"), lines("a"), syncPull},
+		{"typed into a new sheet cell", lines("a"), "", "", syncPull},
+		{"cleared in the sheet", "", lines("a"), lines("a"), syncPull},
+		{"edited through the API", lines("a"), lines("a", "c"), lines("a"), syncPush},
+		{"new API cell", "", lines("a"), "", syncPush},
+		{"cleared through the API", lines("a"), "", lines("a"), syncPush},
+		{"both edited", lines("a", "b"), lines("a", "c"), lines("a"), syncConflict},
+		{"both new, different", lines("b"), lines("c"), "", syncConflict},
+		{"one edited, one cleared", lines("a", "b"), "", lines("a"), syncConflict},
+	}
+	for _, tt := range tests {
+		if got := reconcileCell(tt.sheet, tt.db, tt.synced); got != tt.want {
+			t.Errorf("%s: reconcileCell = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHashItems(t *testing.T) {
+	item := func(task, status string) models.TaskItem {
+		return models.TaskItem{ID: "t" + task, Task: task, Status: status}
+	}
+	base := hashItems([]models.TaskItem{item("a", "todo"), item("b", "complete")})
+	tests := []struct {
+		name  string
+		items []models.TaskItem
+		same  bool
+	}{
+		{"status spelling", []models.TaskItem{item("a", ""), item("b", "COMPLETE")}, true},
+		{"other IDs", []models.TaskItem{{ID: "tx", Task: "a", Status: "todo"}, {Task: "b", Status: "complete"}}, true},
+		{"reordered", []models.TaskItem{item("b", "complete"), item("a", "todo")}, false},
+		{"status changed", []models.TaskItem{item("a", "pending"), item("b", "complete")}, false},
+		{"renamed", []models.TaskItem{item("A", "todo"), item("b", "complete")}, false},
+	}
+	for _, tt := range tests {
+		if got := hashItems(tt.items) == base; got != tt.same {
+			t.Errorf("%s: same hash = %v, want %v", tt.name, got, tt.same)
+		}
+	}
+	if hashItems(nil) != "" {
+		t.Error("an empty cell has a hash")
+	}
+}
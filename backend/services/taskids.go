@@ -0,0 +1,147 @@
+// services/taskids.go
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go-backend/models"
+	"strings"
+)
+
+// Task IDs are "t" followed by 10 hex digits, e.g. "t3f9a1c2b7d".
+// The Sheets backend records them in the note of each task cell, one "<id> <task>" line per task
+// under taskIDNoteHeader; notes travel with the cell when rows and columns move.
+// A carried-over task is noted as "<id>/<day it was carried from> <task>".
+const taskIDNoteHeader = "Task IDs (kept by the standup tracker; edit the cell text, not this note)"
+
+// Helper: A fresh random task ID
+func newTaskID() string {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return "t" + hex.EncodeToString(b)
+}
+
+// Helper: ID of a line no write has recorded yet (typed straight into the sheet).
+// It is derived from the cell and the text so every read agrees on it until a write records it.
+func derivedTaskID(key, task string, n int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d", key, strings.ToLower(task), n)))
+	return "t" + hex.EncodeToString(sum[:5])
+}
+
+// Helper: Whether s looks like a task ID
+func isTaskID(s string) bool {
+	if len(s) != 11 || s[0] != 't' {
+		return false
+	}
+	_, err := hex.DecodeString(s[1:])
+	return err == nil
+}
+
+// Helper: Identity of an employee/day cell (day is a day key, see dayKey) that derived IDs are scoped to
+func cellKey(employeeName, day string) string {
+	return strings.ToLower(strings.TrimSpace(employeeName)) + "\x00" + strings.ToLower(strings.TrimSpace(day))
+}
+
+// notedTask is one line of a task ID note
+type notedTask struct {
+	ID   string
+	From *models.Date // Carried over from this day
+	Task string
+}
+
+// Helper: The tasks recorded in a cell note, in line order (other lines are ignored)
+func parseTaskIDNote(note string) []notedTask {
+	var noted []notedTask
+	for _, line := range strings.Split(note, "\n") {
+		token, task, _ := strings.Cut(strings.TrimSpace(line), " ")
+		id, from, carried := strings.Cut(token, "/")
+		if !isTaskID(id) {
+			continue
+		}
+		n := notedTask{ID: id, Task: strings.TrimSpace(task)}
+		if d, err := models.ParseDate(from); carried && err == nil {
+			n.From = &d
+		}
+		noted = append(noted, n)
+	}
+	return noted
+}
+
+// Helper: The note recording the IDs of a cell's lines, "" for an empty cell
+func taskIDNote(lines []cellLine) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(taskIDNoteHeader)
+	for _, line := range lines {
+		if line.CarriedFrom != nil {
+			fmt.Fprintf(&b, "\n%s/%s %s", line.ID, line.CarriedFrom, line.Task)
+		} else {
+			fmt.Fprintf(&b, "\n%s %s", line.ID, line.Task)
+		}
+	}
+	return b.String()
+}
+
+// Helper: IDs (and carry-over days) for the lines of a cell given what its note recorded.
+// A line keeps the ID noted for the same text; a line whose text changed keeps the unused ID
+// noted at its position (an edit in place); anything else gets a derived ID.
+func assignTaskIDs(key string, tasks []string, noted []notedTask) []notedTask {
+	ids := make([]string, len(tasks))
+	from := make([]*models.Date, len(tasks))
+	used := make([]bool, len(noted))
+	taken := map[string]bool{}
+
+	for i, task := range tasks {
+		for j, n := range noted {
+			if !used[j] && strings.EqualFold(n.Task, task) {
+				ids[i], from[i], used[j] = n.ID, n.From, true
+				taken[n.ID] = true
+				break
+			}
+		}
+	}
+	for i := range tasks {
+		if ids[i] == "" && i < len(noted) && !used[i] && !taken[noted[i].ID] {
+			ids[i], from[i], used[i] = noted[i].ID, noted[i].From, true
+			taken[noted[i].ID] = true
+		}
+	}
+
+	seen := map[string]int{}
+	for i, task := range tasks {
+		if ids[i] != "" {
+			continue
+		}
+		for {
+			id := derivedTaskID(key, task, seen[strings.ToLower(task)])
+			seen[strings.ToLower(task)]++
+			if !taken[id] {
+				ids[i] = id
+				taken[id] = true
+				break
+			}
+		}
+	}
+
+	assigned := make([]notedTask, len(tasks))
+	for i, task := range tasks {
+		assigned[i] = notedTask{ID: ids[i], From: from[i], Task: task}
+	}
+	return assigned
+}
+
+// Helper: Give items without an ID a fresh one
+func withTaskIDs(items []models.TaskItem) []models.TaskItem {
+	for i := range items {
+		if items[i].ID == "" {
+			items[i].ID = newTaskID()
+		}
+	}
+	return items
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"go-backend/models"
+	"testing"
+)
+
+func TestParseTaskIDNote(t *testing.T) {
+	tests := []struct {
+		name string
+		note string
+		want []string // "id task", or "id/from task" for carried tasks
+	}{
+		{"empty", "", nil},
+		{"header and lines", taskIDNoteHeader + "\nt0123456789 write docs\nt89abcdef01 fix bug", []string{"t0123456789 write docs", "t89abcdef01 fix bug"}},
+		{"carried", "t0123456789/2026-10-15 write docs", []string{"t0123456789/2026-10-15 write docs"}},
+		{"bad carry day kept as plain", "t0123456789/yesterday write docs", []string{"t0123456789 write docs"}},
+		{"other lines ignored", "a comment\nt012 too short\nx0123456789 wrong prefix\ntzz23456789 not hex\n  t0123456789   spaced  ", []string{"t0123456789 spaced"}},
+		{"task text with spaces", "t0123456789 ship  v2 / docs", []string{"t0123456789 ship  v2 / docs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTaskIDNote(tt.note)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d tasks %+v, want %v", len(got), got, tt.want)
+			}
+			for i, n := range got {
+				s := n.ID
+				if n.From != nil {
+					s += "/" + n.From.String()
+				}
+				if s += " " + n.Task; s != tt.want[i] {
+					t.Errorf("line %d = %q, want %q", i, s, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTaskIDNoteRoundTrip(t *testing.T) {
+	from := date(t, "2026-10-15")
+	lines := []cellLine{{ID: "t0123456789", Task: "write docs"}, {ID: "t89abcdef01", Task: "fix bug", CarriedFrom: &from}}
+	got := parseTaskIDNote(taskIDNote(lines))
+	if len(got) != 2 || got[0].ID != "t0123456789" || got[0].From != nil || got[1].Task != "fix bug" || got[1].From == nil || *got[1].From != from {
+		t.Errorf("round trip = %+v", got)
+	}
+	if taskIDNote(nil) != "" {
+		t.Error("an empty cell has a note")
+	}
+}
+
+func TestAssignTaskIDs(t *testing.T) {
+	const key = "ann\x002026-10-16"
+	from := date(t, "2026-10-15")
+	noted := []notedTask{{ID: "t0000000001", Task: "write docs"}, {ID: "t0000000002", Task: "fix bug", From: &from}, {ID: "t0000000003", Task: "review"}}
+	derived := func(task string, n int) string { return derivedTaskID(key, task, n) }
+
+	tests := []struct {
+		name  string
+		tasks []string
+		want  []string
+	}{
+		{"unchanged", []string{"write docs", "fix bug", "review"}, []string{"t0000000001", "t0000000002", "t0000000003"}},
+		{"reordered, case-insensitive", []string{"REVIEW", "write docs", "fix bug"}, []string{"t0000000003", "t0000000001", "t0000000002"}},
+		{"edited in place", []string{"write the docs", "fix bug", "review"}, []string{"t0000000001", "t0000000002", "t0000000003"}},
+		{"line typed in the sheet", []string{"write docs", "fix bug", "review", "deploy"}, []string{"t0000000001", "t0000000002", "t0000000003", derived("deploy", 0)}},
+		{"line removed", []string{"write docs", "review"}, []string{"t0000000001", "t0000000003"}},
+		{"duplicate text", []string{"write docs", "write docs"}, []string{"t0000000001", "t0000000002"}}, // The second takes the free ID at its position
+		{"no note", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := assignTaskIDs(key, tt.tasks, noted)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %v", got, tt.want)
+			}
+			for i, n := range got {
+				if n.ID != tt.want[i] || n.Task != tt.tasks[i] {
+					t.Errorf("line %d = %s %q, want %s %q", i, n.ID, n.Task, tt.want[i], tt.tasks[i])
+				}
+				if (n.ID == "t0000000002") != (n.From != nil) {
+					t.Errorf("line %d (%s) carried from %v", i, n.ID, n.From)
+				}
+			}
+		})
+	}
+
+	// Lines no note knows get IDs that every read agrees on, distinct for repeated text
+	fresh := assignTaskIDs(key, []string{"a", "a", "b"}, nil)
+	again := assignTaskIDs(key, []string{"a", "a", "b"}, nil)
+	if fresh[0].ID == fresh[1].ID || !isTaskID(fresh[0].ID) {
+		t.Errorf("derived IDs %+v", fresh)
+	}
+	for i := range fresh {
+		if fresh[i].ID != again[i].ID {
+			t.Errorf("derived ID of line %d changed between reads: %s, %s", i, fresh[i].ID, again[i].ID)
+		}
+	}
+}
+
+func TestAddTaskValidatesItems(t *testing.T) {
+	_, client := startFakeSheets(t)
+	env := everyDayWorking(t)
+	stores := map[string]Store{"memory": NewMemoryStore(env), "sheets": NewSheetsStore(client, env)}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			for _, items := range [][]models.TaskItem{
+				{{Task: "  ", Status: "todo"}},
+				{{Task: "write docs\nfix bug", Status: "todo"}},
+				{{Task: "write docs", Status: "done"}},
+				{{Task: "write docs", Status: "todo"}, {Task: "", Status: "todo"}},
+			} {
+				err := store.AddTask(models.TaskRequest{EmployeeName: "Ann", Role: "DEV", Tasks: items})
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("AddTask(%+v) = %v, want a validation error", items, err)
+				}
+			}
+
+			if err := store.AddTask(models.TaskRequest{EmployeeName: "Ann", Role: "DEV", Tasks: []models.TaskItem{{Task: "  write docs ", Status: "Pending"}}}); err != nil {
+				t.Fatal(err)
+			}
+			emp, err := store.GetLatestTasks("Ann")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(emp.History) != 1 || len(emp.History[0].Tasks) != 1 || emp.History[0].Tasks[0].Task != "write docs" || emp.History[0].Tasks[0].Status != "pending" {
+				t.Fatalf("history %+v, want one trimmed pending task", emp.History)
+			}
+		})
+	}
+}
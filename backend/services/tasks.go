@@ -0,0 +1,318 @@
+// services/tasks.go
+package services
+
+import (
+	"go-backend/models"
+	"strings"
+)
+
+// TaskLocation is a task line together with the employee/day cell holding it
+type TaskLocation struct {
+	models.TaskItem
+	EmployeeName string      `json:"employee_name"`
+	Sheet        string      `json:"sheet"`
+	Date         models.Date `json:"date"`     // null when the column header is not a date
+	Label        string      `json:"label"`    // Column header as written in the sheet
+	Position     int         `json:"position"` // Line of the cell, from 0
+}
+
+// TaskUpdate renames a task, changes its status, or both; nil fields are left as they are
+type TaskUpdate struct {
+	Task   *string `json:"task"`
+	Status *string `json:"status"`
+}
+
+// Helper: Reject empty updates, blank or multi-line names and unknown statuses
+func (u TaskUpdate) validate() error {
+	if u.Task == nil && u.Status == nil {
+		return newError(ErrValidation, "validation_failed", "task or status is required")
+	}
+	if u.Task != nil {
+		if err := validateTaskText(*u.Task); err != nil {
+			return err
+		}
+	}
+	if u.Status != nil {
+		return validateStatus(*u.Status)
+	}
+	return nil
+}
+
+// Helper: Trimmed copies of tasks being added; blank or multi-line names and unknown statuses are rejected,
+// since a cell keeps one task per line
+func validateTaskItems(items []models.TaskItem) ([]models.TaskItem, error) {
+	out := make([]models.TaskItem, len(items))
+	for i, item := range items {
+		if err := validateTaskText(item.Task); err != nil {
+			return nil, err
+		}
+		if err := validateStatus(item.Status); err != nil {
+			return nil, err
+		}
+		item.Task, item.Status = strings.TrimSpace(item.Task), strings.ToLower(item.Status)
+		out[i] = item
+	}
+	return out, nil
+}
+
+// Helper: A task name must be a non-blank single line
+func validateTaskText(task string) error {
+	task = strings.TrimSpace(task)
+	if task == "" {
+		return newError(ErrValidation, "validation_failed", "task must not be empty")
+	}
+	if strings.ContainsAny(task, "\r\n") {
+		return newError(ErrValidation, "validation_failed", "task must be a single line")
+	}
+	return nil
+}
+
+// Helper: A status must be todo, pending or complete
+func validateStatus(status string) error {
+	switch strings.ToLower(status) {
+	case "todo", "pending", "complete":
+		return nil
+	}
+	return newError(ErrValidation, "invalid_status", "status must be todo, pending or complete, not '%s'", status)
+}
+
+// CellRef names one employee/day cell of a role sheet
+type CellRef struct {
+	EmployeeName string
+	Role         string      // Default role when empty
+	Date         models.Date // The employee's today when zero
+	Actor        string      // Who is writing: the bearer token owner or the X-Actor header
+}
+
+// taskCells is what the by-ID operations need from a backend
+type taskCells interface {
+	MetadataStore
+	GetLatestTasks(employeeName string) (models.EmployeeTasksResponse, error)
+
+	// findTask locates a task in the role sheets (archived days are not searched)
+	findTask(id string) (TaskLocation, error)
+
+	// editCell replaces the lines of the cell at loc with what fn makes of its current lines
+	editCell(loc TaskLocation, fn func(items []models.TaskItem) ([]models.TaskItem, error)) error
+
+	// ensureCell creates the employee row and the day column of loc when missing, as AddTask does
+	ensureCell(loc TaskLocation) error
+}
+
+// Helper: Position of a task in a cell's lines, -1 when missing
+func taskIndex(items []models.TaskItem, id string) int {
+	for i, item := range items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Helper: Position of a line named by its task ID or, failing that, its text; -1 when missing
+func lineIndex(items []models.TaskItem, line string) int {
+	if i := taskIndex(items, line); i != -1 {
+		return i
+	}
+	line = strings.TrimSpace(line)
+	for i, item := range items {
+		if strings.EqualFold(item.Task, line) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Helper: Rename and/or recolor line i of a cell; names stay unique within the cell
+func applyTaskUpdate(items []models.TaskItem, i int, update TaskUpdate, loc TaskLocation) error {
+	if update.Task != nil {
+		task := strings.TrimSpace(*update.Task)
+		for j, other := range items {
+			if j != i && strings.EqualFold(other.Task, task) {
+				return newError(ErrConflict, "duplicate_task", "'%s' is already a task of %s on %s", task, loc.EmployeeName, loc.Label)
+			}
+		}
+		items[i].Task = task
+	}
+	if update.Status != nil {
+		items[i].Status = normalizeStatus(*update.Status)
+	}
+	return nil
+}
+
+// Helper: The latest of the cells holding a task; a carried-over task shares its ID with the
+// days it was carried from. Cells under headers that are not dates count as oldest.
+func latestLocation(id string, found []TaskLocation) (TaskLocation, error) {
+	if len(found) == 0 {
+		return TaskLocation{}, errTaskNotFound(id)
+	}
+	latest := found[0]
+	for _, loc := range found[1:] {
+		if !loc.Date.IsZero() && !loc.Date.Before(latest.Date) {
+			latest = loc
+		}
+	}
+	return latest, nil
+}
+
+// Helper: Locate a task and check that its day is still inside the actor's edit window
+func (e *Env) locateForEdit(s taskCells, id, actor string) (TaskLocation, error) {
+	loc, err := s.findTask(id)
+	if err != nil {
+		return TaskLocation{}, err
+	}
+	if loc.Date.IsZero() {
+		return TaskLocation{}, newError(ErrConflict, "task_not_dated", "task '%s' is under the column '%s', which is not a date", id, loc.Label)
+	}
+	_, err = e.resolveTargetDate(s, models.TaskRequest{
+		EmployeeName: loc.EmployeeName,
+		Role:         loc.Sheet,
+		Date:         loc.Date,
+		Actor:        actor,
+	})
+	if err != nil {
+		return TaskLocation{}, err
+	}
+	return loc, nil
+}
+
+// Helper: Rename a task and/or change its status in place
+func (e *Env) updateTask(s taskCells, id string, update TaskUpdate, actor string) (TaskLocation, error) {
+	if err := update.validate(); err != nil {
+		return TaskLocation{}, err
+	}
+	loc, err := e.locateForEdit(s, id, actor)
+	if err != nil {
+		return TaskLocation{}, err
+	}
+
+	err = e.editCellPublishing(s, loc, actor, func(items []models.TaskItem) ([]models.TaskItem, error) {
+		i := taskIndex(items, id)
+		if i == -1 {
+			return nil, errTaskNotFound(id)
+		}
+		if err := applyTaskUpdate(items, i, update, loc); err != nil {
+			return nil, err
+		}
+		loc.TaskItem, loc.Position = items[i], i
+		return items, nil
+	})
+	if err != nil {
+		return TaskLocation{}, err
+	}
+	return loc, nil
+}
+
+// Helper: Remove a task from its cell; the other lines keep their order and IDs
+func (e *Env) deleteTask(s taskCells, id string, actor string) (TaskLocation, error) {
+	loc, err := e.locateForEdit(s, id, actor)
+	if err != nil {
+		return TaskLocation{}, err
+	}
+
+	err = e.editCellPublishing(s, loc, actor, func(items []models.TaskItem) ([]models.TaskItem, error) {
+		i := taskIndex(items, id)
+		if i == -1 {
+			return nil, errTaskNotFound(id)
+		}
+		loc.TaskItem, loc.Position = items[i], i
+		return append(items[:i], items[i+1:]...), nil
+	})
+	if err != nil {
+		return TaskLocation{}, err
+	}
+	return loc, nil
+}
+
+// Helper: Resolve a cell's sheet and day and check that the day is inside the actor's edit window
+func (e *Env) locateCell(s taskCells, cell CellRef) (TaskLocation, error) {
+	date, err := e.resolveTargetDate(s, models.TaskRequest{
+		EmployeeName: cell.EmployeeName,
+		Role:         cell.Role,
+		Date:         cell.Date,
+		Actor:        cell.Actor,
+	})
+	if err != nil {
+		return TaskLocation{}, err
+	}
+	sheet, err := e.resolveRole(cell.Role)
+	if err != nil {
+		return TaskLocation{}, err
+	}
+	return TaskLocation{EmployeeName: cell.EmployeeName, Sheet: sheet, Date: date, Label: date.Header()}, nil
+}
+
+// Helper: Rewrite one cell with fn and return the cell as it now reads
+func (e *Env) editCellAt(s taskCells, cell CellRef, fn func(loc TaskLocation, items []models.TaskItem) ([]models.TaskItem, error)) (models.DayTasks, error) {
+	loc, err := e.locateCell(s, cell)
+	if err != nil {
+		return models.DayTasks{}, err
+	}
+
+	var after []models.TaskItem
+	err = e.editCellPublishing(s, loc, cell.Actor, func(items []models.TaskItem) ([]models.TaskItem, error) {
+		items, err := fn(loc, items)
+		after = items
+		return items, err
+	})
+	if err != nil {
+		return models.DayTasks{}, err
+	}
+	return newDayTasks(loc.Date, loc.Label, after), nil
+}
+
+// Helper: Rename and/or recolor one line of a cell
+func (e *Env) updateCellTask(s taskCells, cell CellRef, line string, update TaskUpdate) (models.DayTasks, error) {
+	if err := update.validate(); err != nil {
+		return models.DayTasks{}, err
+	}
+	return e.editCellAt(s, cell, func(loc TaskLocation, items []models.TaskItem) ([]models.TaskItem, error) {
+		i := lineIndex(items, line)
+		if i == -1 {
+			return nil, errLineNotFound(loc, line)
+		}
+		if err := applyTaskUpdate(items, i, update, loc); err != nil {
+			return nil, err
+		}
+		return items, nil
+	})
+}
+
+// Helper: Remove one line of a cell
+func (e *Env) deleteCellTask(s taskCells, cell CellRef, line string) (models.DayTasks, error) {
+	return e.editCellAt(s, cell, func(loc TaskLocation, items []models.TaskItem) ([]models.TaskItem, error) {
+		i := lineIndex(items, line)
+		if i == -1 {
+			return nil, errLineNotFound(loc, line)
+		}
+		return append(items[:i], items[i+1:]...), nil
+	})
+}
+
+// Helper: Put the lines of a cell in a new order; order must name every line exactly once
+func (e *Env) reorderCell(s taskCells, cell CellRef, order []string) (models.DayTasks, error) {
+	return e.editCellAt(s, cell, func(loc TaskLocation, items []models.TaskItem) ([]models.TaskItem, error) {
+		if len(order) != len(items) {
+			return nil, newError(ErrValidation, "invalid_order", "order must name each of the %d tasks once, got %d", len(items), len(order))
+		}
+		placed := make([]bool, len(items))
+		reordered := make([]models.TaskItem, 0, len(items))
+		for _, line := range order {
+			i := lineIndex(items, line)
+			if i == -1 {
+				return nil, errLineNotFound(loc, line)
+			}
+			if placed[i] {
+				return nil, newError(ErrValidation, "invalid_order", "'%s' is named more than once", line)
+			}
+			placed[i] = true
+			reordered = append(reordered, items[i])
+		}
+		return reordered, nil
+	})
+}
+
+// Helper: "not found" for a line a cell does not hold
+func errLineNotFound(loc TaskLocation, line string) error {
+	return newError(ErrNotFound, "task_not_found", "%s has no task '%s' on %s", loc.EmployeeName, line, loc.Label)
+}
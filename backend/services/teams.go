@@ -0,0 +1,556 @@
+// services/teams.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-backend/config"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Team is one team of the registry and the role sheet its tasks live on
+type Team struct {
+	Name        string       `json:"name"`         // Key, e.g. "DEV"
+	DisplayName string       `json:"display_name"` // e.g. "Developers"
+	Sheet       string       `json:"sheet"`        // Role sheet tab, defaults to Name
+	Aliases     []string     `json:"aliases"`      // Other names requests may use, e.g. "Engineering"
+	Settings    TeamSettings `json:"settings"`
+}
+
+// TeamSettings are per-team overrides of the global configuration
+type TeamSettings struct {
+	EditWindow *TeamEditWindow `json:"edit_window,omitempty"` // Replaces the edit_window rule for this team
+}
+
+// TeamEditWindow is an edit window rule as stored with a team (see config.EditWindowRule)
+type TeamEditWindow struct {
+	DaysBack        int    `json:"days_back"`
+	WorkingDays     bool   `json:"working_days"`
+	Timezone        string `json:"timezone,omitempty"`
+	Grace           string `json:"grace,omitempty"` // Duration, e.g. "2h"
+	ManagerDaysBack int    `json:"manager_days_back"`
+}
+
+// Helper: The rule as the edit-window policy uses it (validated on the way in)
+func (w TeamEditWindow) rule() config.EditWindowRule {
+	grace, _ := time.ParseDuration(w.Grace)
+	return config.EditWindowRule{
+		DaysBack:        w.DaysBack,
+		WorkingDays:     w.WorkingDays,
+		Timezone:        w.Timezone,
+		Grace:           grace,
+		ManagerDaysBack: w.ManagerDaysBack,
+	}
+}
+
+// Helper: Whether a team answers to name (its name, display name, sheet or an alias)
+func (t Team) matches(name string) bool {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return false
+	}
+	for _, n := range append([]string{t.Name, t.DisplayName, t.Sheet}, t.Aliases...) {
+		if n != "" && strings.EqualFold(strings.TrimSpace(n), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// teamStore persists the registry
+type teamStore interface {
+	load() ([]Team, error)
+	save(teams []Team) error
+	describe() string
+}
+
+// SheetProvisioner is implemented by storage backends that can create a role sheet for a new team
+type SheetProvisioner interface {
+	EnsureRoleSheet(title string) error
+}
+
+// TeamRegistry maps team names and aliases to role sheets. Reads are served from memory;
+// the backing tab or file is read at startup and on Reload.
+type TeamRegistry struct {
+	mu        sync.RWMutex
+	teams     []Team
+	store     teamStore
+	provision SheetProvisioner // nil when new teams' sheets are created by hand
+}
+
+// NewTeamRegistry loads the registry from the spreadsheet's teams tab when client is set,
+// otherwise from cfg.StoreFile. An empty registry is seeded from role_sheets.
+func NewTeamRegistry(cfg config.TeamsConfig, client *config.SheetsClient, provision SheetProvisioner) (*TeamRegistry, error) {
+	var store teamStore
+	if client != nil {
+		store = &sheetTeamStore{client: client, tab: cfg.Tab}
+	} else {
+		store = &fileTeamStore{path: cfg.StoreFile}
+	}
+	r := &TeamRegistry{store: store, provision: provision}
+
+	loaded, err := store.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load teams from %s: %v", store.describe(), err)
+	}
+	if len(loaded) == 0 {
+		for _, title := range config.Get().RoleSheets {
+			loaded = append(loaded, Team{Name: title, DisplayName: title, Sheet: title, Aliases: []string{}})
+		}
+		if err := store.save(loaded); err != nil {
+			return nil, fmt.Errorf("failed to seed teams into %s: %v", store.describe(), err)
+		}
+		log.Printf("Seeded %d teams into %s", len(loaded), store.describe())
+	}
+	for i := range loaded {
+		loaded[i] = normalizeTeam(loaded[i])
+	}
+	r.teams = loaded
+
+	// Backends without the tab yet (memory, a fresh database) get one per team
+	if provision != nil {
+		for _, t := range loaded {
+			if err := provision.EnsureRoleSheet(t.Sheet); err != nil {
+				log.Printf("Failed to provision sheet '%s' of team %s: %v", t.Sheet, t.Name, err)
+			}
+		}
+	}
+	return r, nil
+}
+
+// Reload re-reads the backing tab or file, e.g. after the teams tab was edited by hand
+func (r *TeamRegistry) Reload() ([]Team, error) {
+	loaded, err := r.store.load()
+	if err != nil {
+		return nil, newError(ErrValidation, "teams_invalid", "failed to load teams from %s: %v", r.store.describe(), err)
+	}
+	if len(loaded) == 0 {
+		return nil, newError(ErrValidation, "teams_invalid", "%s lists no teams; keeping the loaded ones", r.store.describe())
+	}
+	for i := range loaded {
+		loaded[i] = normalizeTeam(loaded[i])
+	}
+	r.mu.Lock()
+	r.teams = loaded
+	r.mu.Unlock()
+	return r.List(), nil
+}
+
+// List returns every team in registry order
+func (r *TeamRegistry) List() []Team {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Team, len(r.teams))
+	for i, t := range r.teams {
+		out[i] = cloneTeam(t)
+	}
+	return out
+}
+
+// Find returns the team answering to name
+func (r *TeamRegistry) Find(name string) (Team, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if i := r.index(name); i >= 0 {
+		return cloneTeam(r.teams[i]), true
+	}
+	return Team{}, false
+}
+
+// Helper: Index of the team answering to name, -1 when none does (caller holds mu)
+func (r *TeamRegistry) index(name string) int {
+	// Exact team names win over aliases
+	for i, t := range r.teams {
+		if strings.EqualFold(t.Name, strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	for i, t := range r.teams {
+		if t.matches(name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Create adds a team and provisions its role sheet
+func (r *TeamRegistry) Create(t Team) (Team, error) {
+	t = normalizeTeam(t)
+	if err := validateTeam(t); err != nil {
+		return Team{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(t, -1); err != nil {
+		return Team{}, err
+	}
+	if r.provision != nil {
+		if err := r.provision.EnsureRoleSheet(t.Sheet); err != nil {
+			return Team{}, fmt.Errorf("failed to provision sheet '%s': %v", t.Sheet, err)
+		}
+	}
+
+	updated := append(append([]Team{}, r.teams...), t)
+	if err := r.store.save(updated); err != nil {
+		return Team{}, err
+	}
+	r.teams = updated
+	log.Printf("Added team %s (sheet %s)", t.Name, t.Sheet)
+	return cloneTeam(t), nil
+}
+
+// Update replaces the team answering to name; a new sheet is provisioned, the old one is left in place
+func (r *TeamRegistry) Update(name string, t Team) (Team, error) {
+	t = normalizeTeam(t)
+	if err := validateTeam(t); err != nil {
+		return Team{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(name)
+	if i < 0 {
+		return Team{}, errTeamNotFound(name)
+	}
+	if err := r.checkUnique(t, i); err != nil {
+		return Team{}, err
+	}
+	if r.provision != nil && !strings.EqualFold(r.teams[i].Sheet, t.Sheet) {
+		if err := r.provision.EnsureRoleSheet(t.Sheet); err != nil {
+			return Team{}, fmt.Errorf("failed to provision sheet '%s': %v", t.Sheet, err)
+		}
+	}
+
+	updated := append([]Team{}, r.teams...)
+	updated[i] = t
+	if err := r.store.save(updated); err != nil {
+		return Team{}, err
+	}
+	r.teams = updated
+	return cloneTeam(t), nil
+}
+
+// Delete removes a team from the registry. Its role sheet and tasks are kept.
+func (r *TeamRegistry) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(name)
+	if i < 0 {
+		return errTeamNotFound(name)
+	}
+	if r.teams[i].matches(config.Get().DefaultRole) {
+		return newError(ErrConflict, "default_team", "team '%s' is the default role and cannot be deleted", r.teams[i].Name)
+	}
+
+	removed := r.teams[i]
+	updated := append(append([]Team{}, r.teams[:i]...), r.teams[i+1:]...)
+	if err := r.store.save(updated); err != nil {
+		return err
+	}
+	r.teams = updated
+	log.Printf("Removed team %s (sheet %s kept)", removed.Name, removed.Sheet)
+	return nil
+}
+
+// Helper: Names, sheets and aliases must not be claimed by another team (caller holds mu)
+func (r *TeamRegistry) checkUnique(t Team, self int) error {
+	for i, other := range r.teams {
+		if i == self {
+			continue
+		}
+		if _, ok := parseArchiveTitle(other.Sheet, t.Sheet); ok {
+			return newError(ErrValidation, "reserved_sheet", "'%s' is named like an archive tab of %s", t.Sheet, other.Sheet)
+		}
+		for _, n := range append([]string{t.Name, t.DisplayName, t.Sheet}, t.Aliases...) {
+			if other.matches(n) {
+				return newError(ErrConflict, "team_name_taken", "'%s' already names team '%s'", n, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Helper: Trim names, default the sheet and display name, drop blank aliases
+func normalizeTeam(t Team) Team {
+	t.Name = strings.TrimSpace(t.Name)
+	t.DisplayName = strings.TrimSpace(t.DisplayName)
+	t.Sheet = strings.TrimSpace(t.Sheet)
+	if t.Sheet == "" {
+		t.Sheet = t.Name
+	}
+	if t.DisplayName == "" {
+		t.DisplayName = t.Name
+	}
+	aliases := []string{}
+	for _, a := range t.Aliases {
+		if a = strings.TrimSpace(a); a != "" && !containsFold(aliases, a) {
+			aliases = append(aliases, a)
+		}
+	}
+	t.Aliases = aliases
+	return t
+}
+
+// Helper: Reject teams that could not be stored or would clash with the bookkeeping tabs
+func validateTeam(t Team) error {
+	if t.Name == "" {
+		return newError(ErrValidation, "validation_failed", "team name is required")
+	}
+	cfg := config.Get()
+	for _, reserved := range []string{config.SheetDBEmployees, config.SheetDBLogs, cfg.Teams.Tab, cfg.Audit.Tab, cfg.Jobs.Tab} {
+		if strings.EqualFold(t.Sheet, reserved) {
+			return newError(ErrValidation, "reserved_sheet", "sheet '%s' is reserved", t.Sheet)
+		}
+	}
+	// "DEV 2025" would be read as the archive of a "DEV" team, whether or not one exists yet
+	if i := strings.LastIndex(t.Sheet, " "); i > 0 {
+		if _, ok := parseArchiveTitle(t.Sheet[:i], t.Sheet); ok {
+			return newError(ErrValidation, "reserved_sheet", "sheet '%s' is named like an archive tab", t.Sheet)
+		}
+	}
+	if strings.ContainsAny(t.Sheet, "'!") {
+		return newError(ErrValidation, "validation_failed", "sheet names cannot contain ' or !")
+	}
+	if w := t.Settings.EditWindow; w != nil {
+		if w.DaysBack < 0 || w.ManagerDaysBack < 0 {
+			return newError(ErrValidation, "validation_failed", "edit_window days must not be negative")
+		}
+		if w.Grace != "" {
+			if d, err := time.ParseDuration(w.Grace); err != nil || d < 0 || d >= 24*time.Hour {
+				return newError(ErrValidation, "validation_failed", "edit_window.grace must be a duration between 0 and 24h, e.g. 2h")
+			}
+		}
+		if err := ValidateTimezone(w.Timezone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Helper: Deep copy
+func cloneTeam(t Team) Team {
+	t.Aliases = append([]string{}, t.Aliases...)
+	if t.Settings.EditWindow != nil {
+		w := *t.Settings.EditWindow
+		t.Settings.EditWindow = &w
+	}
+	return t
+}
+
+func errTeamNotFound(name string) error {
+	return newError(ErrNotFound, "team_not_found", "team '%s' not found", name)
+}
+
+// Helper: Role sheet titles of every team, or config.RoleSheets without a registry
+func (e *Env) roleSheets() []string {
+	teams := e.teams()
+	if teams == nil {
+		return config.Get().RoleSheets
+	}
+	var titles []string
+	for _, t := range teams.List() {
+		titles = append(titles, t.Sheet)
+	}
+	return titles
+}
+
+// Helper: Role sheet a request's role names (the default role when empty). Without a registry
+// the role is taken as a sheet title.
+func (e *Env) resolveRole(role string) (string, error) {
+	name := role
+	if strings.TrimSpace(name) == "" {
+		name = config.Get().DefaultRole
+	}
+	teams := e.teams()
+	if teams == nil {
+		return name, nil
+	}
+	if t, ok := teams.Find(name); ok {
+		return t.Sheet, nil
+	}
+	if role == "" {
+		return "", fmt.Errorf("default sheet '%s' not found", name)
+	}
+	return "", errUnknownRole(role)
+}
+
+// Helper: Team settings' edit window for a role, if it has one
+func (e *Env) teamEditRule(role string) (config.EditWindowRule, string, bool) {
+	teams := e.teams()
+	if teams == nil {
+		return config.EditWindowRule{}, "", false
+	}
+	t, ok := teams.Find(role)
+	if !ok || t.Settings.EditWindow == nil {
+		return config.EditWindowRule{}, "", false
+	}
+	return t.Settings.EditWindow.rule(), t.Name, true
+}
+
+// sheetTeamStore keeps the registry on a tab of the spreadsheet:
+// Name | Display Name | Sheet | Aliases (comma-separated) | Settings (JSON)
+type sheetTeamStore struct {
+	client *config.SheetsClient
+	tab    string
+	rows   int // Data rows last read or written, blanked when the list shrinks
+}
+
+var teamTabHeaders = []interface{}{"Name", "Display Name", "Sheet", "Aliases", "Settings"}
+
+func (s *sheetTeamStore) describe() string {
+	return fmt.Sprintf("the '%s' tab", s.tab)
+}
+
+func (s *sheetTeamStore) load() ([]Team, error) {
+	srv, err := s.client.Service()
+	if err != nil {
+		return nil, err
+	}
+	if err := config.EnsureSheet(srv, s.tab, teamTabHeaders); err != nil {
+		return nil, err
+	}
+	resp, err := srv.Spreadsheets.Values.Get(config.Get().SpreadsheetID, fmt.Sprintf("'%s'!A2:E", s.tab)).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Team
+	for i, row := range resp.Values {
+		cell := func(c int) string {
+			if c < len(row) {
+				return strings.TrimSpace(fmt.Sprintf("%v", row[c]))
+			}
+			return ""
+		}
+		if cell(0) == "" {
+			continue
+		}
+		t := Team{Name: cell(0), DisplayName: cell(1), Sheet: cell(2), Aliases: splitAliases(cell(3))}
+		if settings := cell(4); settings != "" {
+			if err := json.Unmarshal([]byte(settings), &t.Settings); err != nil {
+				return nil, fmt.Errorf("row %d: invalid settings JSON: %v", i+2, err)
+			}
+		}
+		if err := validateTeam(normalizeTeam(t)); err != nil {
+			return nil, fmt.Errorf("row %d: %v", i+2, err)
+		}
+		out = append(out, t)
+	}
+	s.rows = len(resp.Values)
+	return out, nil
+}
+
+func (s *sheetTeamStore) save(teams []Team) error {
+	srv, err := s.client.Service()
+	if err != nil {
+		return err
+	}
+	if err := config.EnsureSheet(srv, s.tab, teamTabHeaders); err != nil {
+		return err
+	}
+
+	values := [][]interface{}{}
+	for _, t := range teams {
+		settings := ""
+		if t.Settings != (TeamSettings{}) {
+			b, err := json.Marshal(t.Settings)
+			if err != nil {
+				return err
+			}
+			settings = string(b)
+		}
+		values = append(values, []interface{}{t.Name, t.DisplayName, t.Sheet, strings.Join(t.Aliases, ", "), settings})
+	}
+	for len(values) < s.rows {
+		values = append(values, []interface{}{"", "", "", "", ""})
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	rng := fmt.Sprintf("'%s'!A2:E%d", s.tab, len(values)+1)
+	_, err = srv.Spreadsheets.Values.Update(config.Get().SpreadsheetID, rng, &sheets.ValueRange{Values: values}).ValueInputOption("RAW").Do()
+	if err != nil {
+		return err
+	}
+	s.rows = len(teams)
+	return nil
+}
+
+// Helper: "Dev, Engineering" -> ["Dev", "Engineering"]
+func splitAliases(v string) []string {
+	out := []string{}
+	for _, a := range strings.Split(v, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// fileTeamStore keeps the registry in a JSON file, or only in memory when path is empty
+type fileTeamStore struct {
+	path string
+	mem  []Team // The registry when path is empty
+}
+
+func (f *fileTeamStore) describe() string {
+	if f.path == "" {
+		return "memory"
+	}
+	return f.path
+}
+
+func (f *fileTeamStore) load() ([]Team, error) {
+	if f.path == "" {
+		return append([]Team{}, f.mem...), nil
+	}
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []Team
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (f *fileTeamStore) save(teams []Team) error {
+	if f.path == "" {
+		f.mem = append([]Team{}, teams...)
+		return nil
+	}
+	data, err := json.MarshalIndent(teams, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".teams-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateTeamReservedSheets(t *testing.T) {
+	tests := []struct {
+		sheet string
+		ok    bool
+	}{
+		{"DEV", true},
+		{"QA 2", true},
+		{"Release 2025 plan", true},
+		{"teams", false},
+		{"Audit", false},
+		{"jobs", false},
+		{"DEV 2025", false},
+		{"Managers 2026-Q3", false},
+	}
+	for _, tt := range tests {
+		err := validateTeam(Team{Name: "x", Sheet: tt.sheet})
+		if tt.ok && err != nil {
+			t.Errorf("sheet %q: %v", tt.sheet, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrValidation) {
+			t.Errorf("sheet %q: got %v, want it reserved", tt.sheet, err)
+		}
+	}
+}
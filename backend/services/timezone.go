@@ -0,0 +1,124 @@
+// services/timezone.go
+package services
+
+import (
+	"go-backend/models"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long employee time zones are reused before the metadata is read again;
+// the Sheets backend would otherwise read the "database" tab on every write
+const zoneCacheTTL = time.Minute
+
+// How long to keep using the last zones read after reading them failed
+const zoneRetryDelay = 10 * time.Second
+
+// zoneCache holds employee time zones by lower-cased name, filled from the metadata store.
+// The lock is not held while reading, so a slow read holds up only the callers with nothing to use.
+type zoneCache struct {
+	mu      sync.Mutex
+	zones   map[string]string
+	fetched time.Time // Last read; zero once a change was made
+	failed  time.Time // Last failed read
+	loading bool      // A read is under way; other callers use the zones they have
+	gen     int       // Bumped on a change, so a read started before it is not taken as fresh
+}
+
+// ValidateTimezone checks an IANA zone name ("" is allowed and means none)
+func ValidateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return newError(ErrValidation, "invalid_timezone", "'%s' is not an IANA time zone, e.g. Asia/Kolkata", name)
+	}
+	return nil
+}
+
+// EmployeeTimezone returns the IANA zone recorded for an employee, "" when none is.
+// When the metadata cannot be read, the zones read last are used (none before the first read).
+func (e *Env) EmployeeTimezone(meta MetadataStore, name string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if e == nil {
+		zones, err := readEmployeeZones(meta)
+		if err != nil {
+			log.Printf("Failed to read employee time zones, using the default zone: %v", err)
+		}
+		return zones[key]
+	}
+
+	c := &e.zones
+	c.mu.Lock()
+	if c.zones != nil && (time.Since(c.fetched) <= zoneCacheTTL || c.loading) || time.Since(c.failed) < zoneRetryDelay {
+		defer c.mu.Unlock()
+		return c.zones[key]
+	}
+	c.loading = true
+	gen := c.gen
+	c.mu.Unlock()
+
+	zones, err := readEmployeeZones(meta)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loading = false
+	if err != nil {
+		c.failed = time.Now()
+		log.Printf("Failed to read employee time zones, keeping the last ones read for %s: %v", zoneRetryDelay, err)
+		return c.zones[key]
+	}
+	c.zones, c.failed = zones, time.Time{}
+	if gen == c.gen {
+		c.fetched = time.Now()
+	}
+	return zones[key]
+}
+
+// Helper: Employee time zones by lower-cased name
+func readEmployeeZones(meta MetadataStore) (map[string]string, error) {
+	employees, err := meta.GetAllEmployeesMetadata()
+	if err != nil {
+		return nil, err
+	}
+	zones := map[string]string{}
+	for _, emp := range employees {
+		zones[strings.ToLower(strings.TrimSpace(emp.EmployeeName))] = emp.Timezone
+	}
+	return zones, nil
+}
+
+// Helper: Read the zones again on next use after an employee record changed
+func (e *Env) forgetEmployeeZones() {
+	if e == nil {
+		return
+	}
+	e.zones.mu.Lock()
+	e.zones.fetched = time.Time{}
+	e.zones.gen++
+	e.zones.mu.Unlock()
+}
+
+// EmployeeLocation returns an employee's zone, or the server's when none is recorded
+func (e *Env) EmployeeLocation(meta MetadataStore, name string) *time.Location {
+	if tz := e.EmployeeTimezone(meta, name); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// EmployeeToday returns the current day in an employee's zone
+func (e *Env) EmployeeToday(meta MetadataStore, name string) models.Date {
+	return models.DateOf(time.Now().In(e.EmployeeLocation(meta, name)))
+}
+
+// Helper: now in an employee's zone and in UTC, both RFC 3339, and the zone's name
+func (e *Env) logTimestamps(meta MetadataStore, name string) (local, utc, zone string) {
+	now := time.Now()
+	loc := e.EmployeeLocation(meta, name)
+	return now.In(loc).Format(time.RFC3339), now.UTC().Format(time.RFC3339), loc.String()
+}
@@ -0,0 +1,55 @@
+package services
+
+import "testing"
+
+// flakyMetadata counts metadata reads and fails them while down
+type flakyMetadata struct {
+	*MemoryStore
+	reads int
+	down  bool
+}
+
+func (f *flakyMetadata) GetAllEmployeesMetadata() ([]EmployeeMetadata, error) {
+	f.reads++
+	if f.down {
+		return nil, errReadFailed
+	}
+	return f.MemoryStore.GetAllEmployeesMetadata()
+}
+
+func TestEmployeeTimezoneKeepsZonesWhenReadFails(t *testing.T) {
+	env := &Env{}
+	meta := &flakyMetadata{MemoryStore: NewMemoryStore(env)}
+	if err := meta.UpsertEmployeeMetadata("Ann", "Asia/Kolkata"); err != nil {
+		t.Fatal(err)
+	}
+
+	if tz := env.EmployeeTimezone(meta, "ann"); tz != "Asia/Kolkata" || meta.reads != 1 {
+		t.Fatalf("first lookup = %q after %d reads", tz, meta.reads)
+	}
+	if env.EmployeeTimezone(meta, "Ann"); meta.reads != 1 {
+		t.Fatalf("a cached lookup read the metadata again (%d reads)", meta.reads)
+	}
+
+	// Expired and the store is down: the last zones stay, and the read is not retried at once
+	env.forgetEmployeeZones()
+	meta.down = true
+	for i := 0; i < 3; i++ {
+		if tz := env.EmployeeTimezone(meta, "Ann"); tz != "Asia/Kolkata" {
+			t.Fatalf("lookup %d with the store down = %q", i, tz)
+		}
+	}
+	if meta.reads != 2 {
+		t.Fatalf("%d reads, want one failed read held off afterwards", meta.reads)
+	}
+
+	// Once the delay has passed the zones are read again
+	env.zones.failed = env.zones.failed.Add(-zoneRetryDelay)
+	meta.down = false
+	if err := meta.MemoryStore.UpsertEmployeeMetadata("Ann", "Europe/Berlin"); err != nil {
+		t.Fatal(err)
+	}
+	if tz := env.EmployeeTimezone(meta, "Ann"); tz != "Europe/Berlin" || meta.reads != 3 {
+		t.Fatalf("lookup after the delay = %q after %d reads", tz, meta.reads)
+	}
+}
@@ -0,0 +1,283 @@
+// services/watch.go
+package services
+
+import (
+	"fmt"
+	"go-backend/config"
+	"go-backend/models"
+	"hash/fnv"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobSheetWatch is the scheduler job looking for edits made directly in the role sheets
+const JobSheetWatch = "sheet-watch"
+
+// Key of the shared snapshot the sheet watch keeps through the scheduler, and the entry in
+// it saying the first pass has taken it (cell keys never start with "#")
+const (
+	watchSnapshotKey = JobSheetWatch + ".snapshot"
+	watchPrimedKey   = "#primed"
+)
+
+// WatchReport summarises one comparison of the role sheets with the last snapshot
+type WatchReport struct {
+	CheckedAt string `json:"checked_at"`
+	Primed    bool   `json:"primed,omitempty"`  // First pass of the deployment: the snapshot was taken, nothing reported
+	Cells     int    `json:"cells"`             // Non-empty cells in the sheets
+	Changed   int    `json:"changed"`           // Cells edited in the sheet since the last pass
+	Skipped   int    `json:"skipped,omitempty"` // Written through the backend or claimed by another pass meanwhile; checked next time
+}
+
+// SheetWatcher notices edits typed straight into the role sheets. Each pass reads the
+// grids, compares every cell with the snapshot and publishes the differences as
+// sheet-sourced cell changes, recording them in the daily log too.
+//
+// The snapshot is shared state of the scheduler (a hash of each cell's lines), so it
+// survives restarts and every instance compares against the same one. Writes through any
+// instance update it (subscribe HandleCell), so only outside edits are reported, and a pass
+// only reports a cell after swapping its snapshot entry from the value it compared with, so
+// two passes never report the same edit. Cache invalidation and the live feed follow the
+// instance that ran the pass; the others catch up through their cache TTL.
+type SheetWatcher struct {
+	sheets   *config.SheetsClient
+	env      *Env
+	logs     LogStore
+	snapshot func(fn func(cells map[string]string) bool) error // Read-modify-write of the shared snapshot
+
+	mu       sync.Mutex             // Guards seen
+	seen     map[string]watchedCell // Lines of each cell as last seen here, for the "before" of a change
+	passMu   sync.Mutex             // One pass at a time on this instance
+	onChange func(role, employeeName string)
+}
+
+// watchedCell is a cell as last seen
+type watchedCell struct {
+	loc   TaskLocation
+	items []models.TaskItem
+}
+
+// Helper: The snapshot entry of a cell: the hash of its lines, then where it is
+func (c watchedCell) entry() string {
+	return linesHash(c.items) + "\x00" + c.loc.Sheet + "\x00" + c.loc.EmployeeName + "\x00" + c.loc.Label
+}
+
+// Helper: The line hash and location in a snapshot entry
+func parseWatchEntry(entry string) (hash string, loc TaskLocation) {
+	parts := strings.SplitN(entry, "\x00", 4)
+	for len(parts) < 4 {
+		parts = append(parts, "")
+	}
+	loc = TaskLocation{Sheet: parts[1], EmployeeName: parts[2], Label: parts[3]}
+	loc.Date, _ = models.ParseDate(parts[3]) // Zero for headers that are not dates
+	return parts[0], loc
+}
+
+// Helper: Hash of a cell's lines, equal exactly when sameLines is
+func linesHash(items []models.TaskItem) string {
+	h := fnv.New64a()
+	for _, it := range items {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\n", it.ID, it.Task, strings.ToLower(it.Status))
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// NewSheetWatcher returns a watcher over the spreadsheet behind client that records the
+// edits it finds in logs, publishes them to env's bus and keeps its snapshot in the scheduler's shared state
+func NewSheetWatcher(client *config.SheetsClient, logs LogStore, scheduler *Scheduler, env *Env) *SheetWatcher {
+	return &SheetWatcher{
+		sheets: client,
+		env:    env,
+		logs:   logs,
+		snapshot: func(fn func(cells map[string]string) bool) error {
+			return scheduler.UpdateState(watchSnapshotKey, fn)
+		},
+		seen: map[string]watchedCell{},
+	}
+}
+
+// SetOnChange registers fn to be called for every cell found edited in the sheet
+func (w *SheetWatcher) SetOnChange(fn func(role, employeeName string)) {
+	w.onChange = fn
+}
+
+// HandleCell keeps the snapshot in step with writes through this backend; subscribe it
+// with EventBus.SubscribeCells
+func (w *SheetWatcher) HandleCell(c CellChange) {
+	if c.Source != EventSourceAPI {
+		return
+	}
+	var d models.Date
+	if c.Date != nil {
+		d = *c.Date
+	}
+	cell := watchedCell{loc: TaskLocation{EmployeeName: c.EmployeeName, Sheet: c.Sheet, Date: d, Label: c.Label}, items: c.Tasks}
+	key := syncKey(c.Sheet, c.EmployeeName, dayKey(d, c.Label))
+
+	w.remember(key, cell)
+	err := w.snapshot(func(cells map[string]string) bool {
+		if cells[watchPrimedKey] == "" {
+			return false // The first pass takes the whole sheet
+		}
+		if len(c.Tasks) == 0 {
+			delete(cells, key)
+		} else {
+			cells[key] = cell.entry()
+		}
+		return true
+	})
+	if err != nil {
+		// The next pass reports this write as a sheet edit
+		log.Printf("Sheet watch: failed to record the write of %s's %s cell: %v", c.EmployeeName, cell.loc.Label, err)
+	}
+}
+
+// Helper: Keep the lines of a cell for the "before" of its next change
+func (w *SheetWatcher) remember(key string, cell watchedCell) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(cell.items) == 0 {
+		delete(w.seen, key)
+	} else {
+		w.seen[key] = cell
+	}
+}
+
+// CheckOnce compares the role sheets with the snapshot and publishes what changed
+func (w *SheetWatcher) CheckOnce() (WatchReport, error) {
+	w.passMu.Lock()
+	defer w.passMu.Unlock()
+
+	report := WatchReport{CheckedAt: time.Now().UTC().Format(time.RFC3339)}
+
+	// The snapshot is read before the sheet, so a write landing in between shows up as a
+	// snapshot entry that moved on and is skipped rather than reported
+	var was map[string]string
+	if err := w.snapshot(func(cells map[string]string) bool {
+		was = copyState(cells)
+		return false
+	}); err != nil {
+		return report, err
+	}
+
+	srv, err := w.sheets.Service()
+	if err != nil {
+		return report, err
+	}
+	layoutMu.RLock()
+	meta, err := srv.Spreadsheets.Get(config.Get().SpreadsheetID).Do()
+	if err != nil {
+		layoutMu.RUnlock()
+		return report, err
+	}
+	// A failed read aborts the pass: a missing tab must not look like deleted cells
+	list, err := w.env.readSheetCells(srv, meta)
+	layoutMu.RUnlock()
+	if err != nil {
+		return report, err
+	}
+
+	current := make(map[string]watchedCell, len(list))
+	for _, sc := range list {
+		d, _ := models.ParseDate(sc.Date) // Zero for headers that are not dates
+		loc := TaskLocation{EmployeeName: sc.EmployeeName, Sheet: sc.Role, Date: d, Label: sc.Date}
+		current[syncKey(sc.Role, sc.EmployeeName, sc.Date)] = watchedCell{loc: loc, items: sc.Items}
+	}
+	report.Cells = len(current)
+
+	w.mu.Lock()
+	seen := w.seen
+	w.seen = current
+	w.mu.Unlock()
+
+	if was[watchPrimedKey] == "" {
+		err := w.snapshot(func(cells map[string]string) bool {
+			if cells[watchPrimedKey] != "" {
+				return false // Another instance got there first
+			}
+			for key, cell := range current {
+				cells[key] = cell.entry()
+			}
+			cells[watchPrimedKey] = report.CheckedAt
+			return true
+		})
+		report.Primed = err == nil
+		return report, err
+	}
+
+	type edit struct {
+		key           string
+		was, now      string // Snapshot entries; now is "" for a cleared cell
+		loc           TaskLocation
+		before, after []models.TaskItem
+	}
+	var edits []edit
+	for key, now := range current {
+		hash, _ := parseWatchEntry(was[key])
+		if was[key] == "" || hash != linesHash(now.items) {
+			edits = append(edits, edit{key: key, was: was[key], now: now.entry(), loc: now.loc, after: now.items})
+		}
+	}
+	for key, entry := range was {
+		if _, ok := current[key]; ok || key == watchPrimedKey {
+			continue
+		}
+		_, loc := parseWatchEntry(entry)
+		edits = append(edits, edit{key: key, was: entry, loc: loc})
+	}
+	for i, e := range edits {
+		// The lines before the edit are known when this instance last saw what the snapshot has
+		if prev, ok := seen[e.key]; ok && e.was != "" {
+			if hash, _ := parseWatchEntry(e.was); hash == linesHash(prev.items) {
+				edits[i].before = prev.items
+			}
+		}
+	}
+
+	// Claim the edits: only entries still as they were read are reported
+	var claimed []edit
+	err = w.snapshot(func(cells map[string]string) bool {
+		claimed = claimed[:0]
+		for _, e := range edits {
+			if cells[e.key] != e.was {
+				continue
+			}
+			if e.now == "" {
+				delete(cells, e.key)
+			} else {
+				cells[e.key] = e.now
+			}
+			claimed = append(claimed, e)
+		}
+		return len(claimed) > 0
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Skipped = len(edits) - len(claimed)
+
+	// Published outside the snapshot update: the bus calls back into HandleCell
+	for _, e := range claimed {
+		w.env.publishCellChange(e.loc, "", EventSourceSheet, e.before, e.after)
+		if w.onChange != nil {
+			w.onChange(e.loc.Sheet, e.loc.EmployeeName)
+		}
+		logSheetEdit(w.logs, e.loc, e.after)
+	}
+	report.Changed = len(claimed)
+	return report, nil
+}
+
+// Helper: Record an edit found in the sheet in the daily log, like the dashboard does after
+// its own writes. Cleared cells and columns that are not dates are left out.
+func logSheetEdit(logs LogStore, loc TaskLocation, after []models.TaskItem) {
+	if logs == nil || loc.Date.IsZero() || len(after) == 0 {
+		return
+	}
+	if err := logs.UpsertDailyLog(loc.EmployeeName, loc.Date.String()); err != nil {
+		log.Printf("Failed to log sheet edit of %s on %s: %v", loc.EmployeeName, loc.Date, err)
+	}
+}
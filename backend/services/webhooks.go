@@ -0,0 +1,681 @@
+// services/webhooks.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-backend/config"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // Out of attempts; retried only on request
+)
+
+// WebhookEndpoint is a URL events are posted to
+type WebhookEndpoint struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret,omitempty"` // Signs deliveries; only returned when the endpoint is created
+	HasSecret   bool     `json:"has_secret"`
+	Events      []string `json:"events"` // Event types sent; empty sends every type
+	Active      bool     `json:"active"`
+	Description string   `json:"description,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+// WebhookAttempt is one try at delivering an event
+type WebhookAttempt struct {
+	At         string `json:"at"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Duration   string `json:"duration"`
+}
+
+// WebhookDelivery is one event on its way to one endpoint
+type WebhookDelivery struct {
+	ID            string           `json:"id"`
+	EndpointID    string           `json:"endpoint_id"`
+	URL           string           `json:"url"`
+	Event         Event            `json:"event"`
+	Status        string           `json:"status"`
+	Attempts      []WebhookAttempt `json:"attempts"`
+	NextAttemptAt *time.Time       `json:"next_attempt_at,omitempty"` // Pending only
+	CreatedAt     string           `json:"created_at"`
+}
+
+// DeliveryFilter narrows the delivery log; zero fields match everything
+type DeliveryFilter struct {
+	EndpointID string
+	Status     string
+	EventType  string
+	Limit      int // Newest first; 0 returns every match
+}
+
+// How long delivery progress may wait before the store file is rewritten; endpoint changes are saved at once
+const webhookSaveDelay = time.Second
+
+// Webhooks posts events to registered endpoints, signed with each endpoint's secret, retrying
+// failures with exponential backoff until they succeed or become dead letters
+type Webhooks struct {
+	cfg    config.WebhooksConfig
+	client *http.Client
+	wake   chan struct{}
+	dirty  chan struct{} // Wakes saveLoop
+
+	mu         sync.Mutex
+	endpoints  []WebhookEndpoint
+	deliveries []WebhookDelivery // Oldest first
+	inFlight   map[string]bool
+	unsaved    bool           // Deliveries changed since the last save
+	attempts   sync.WaitGroup // Attempts in flight, waited for when Run stops
+}
+
+// webhookState is what the store file holds
+type webhookState struct {
+	Endpoints  []WebhookEndpoint `json:"endpoints"`
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+// NewWebhooks loads the endpoints and deliveries kept in cfg.StoreFile
+func NewWebhooks(cfg config.WebhooksConfig) (*Webhooks, error) {
+	w := &Webhooks{
+		cfg:      cfg,
+		client:   webhookClient(cfg),
+		wake:     make(chan struct{}, 1),
+		dirty:    make(chan struct{}, 1),
+		inFlight: map[string]bool{},
+	}
+	if cfg.StoreFile != "" {
+		if err := w.load(); err != nil {
+			return nil, fmt.Errorf("webhooks.store_file: %v", err)
+		}
+	}
+	return w, nil
+}
+
+// Handle queues an event for every active endpoint subscribed to its type; subscribe it to the event bus
+func (w *Webhooks) Handle(ev Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	queued := false
+	for _, ep := range w.endpoints {
+		if !ep.Active || !(len(ep.Events) == 0 || containsFold(ep.Events, ev.Type)) {
+			continue
+		}
+		w.deliveries = append(w.deliveries, WebhookDelivery{
+			ID:            randomID("d", 10),
+			EndpointID:    ep.ID,
+			URL:           ep.URL,
+			Event:         ev,
+			Status:        DeliveryPending,
+			Attempts:      []WebhookAttempt{},
+			NextAttemptAt: &now,
+			CreatedAt:     now.UTC().Format(time.RFC3339Nano),
+		})
+		queued = true
+	}
+	if !queued {
+		return
+	}
+	w.markUnsaved()
+	w.signal()
+}
+
+// Helper: Wake the delivery loop
+func (w *Webhooks) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers due deliveries until ctx is cancelled, then waits for the attempts in flight
+// and saves their outcome; what is still pending goes out after the next start
+func (w *Webhooks) Run(ctx context.Context) {
+	go w.saveLoop(ctx)
+	for {
+		for _, d := range w.due() {
+			w.attempts.Add(1)
+			go func(d WebhookDelivery) {
+				defer w.attempts.Done()
+				w.attempt(d)
+			}(d)
+		}
+
+		wait := w.untilNext()
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			w.attempts.Wait()
+			w.saveUnsaved()
+			return
+		case <-w.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Helper: Save changed deliveries at most once per webhookSaveDelay, until ctx is cancelled
+func (w *Webhooks) saveLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.dirty:
+		}
+		select {
+		case <-ctx.Done():
+			return // Run saves once the last attempts are done
+		case <-time.After(webhookSaveDelay):
+		}
+		w.saveUnsaved()
+	}
+}
+
+// Helper: Note that deliveries changed, to be saved by saveLoop (caller holds mu)
+func (w *Webhooks) markUnsaved() {
+	w.unsaved = true
+	select {
+	case w.dirty <- struct{}{}:
+	default:
+	}
+}
+
+// Helper: Save the deliveries if they changed; a failed save is tried again later
+func (w *Webhooks) saveUnsaved() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unsaved {
+		return
+	}
+	if err := w.save(); err != nil {
+		log.Printf("Failed to save webhook deliveries, retrying: %v", err)
+		w.markUnsaved()
+	}
+}
+
+// Helper: Pending deliveries whose time has come, marked in flight
+func (w *Webhooks) due() []WebhookDelivery {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	var out []WebhookDelivery
+	for _, d := range w.deliveries {
+		if d.Status == DeliveryPending && !w.inFlight[d.ID] && d.NextAttemptAt != nil && !now.Before(*d.NextAttemptAt) {
+			w.inFlight[d.ID] = true
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Helper: How long until the next pending delivery is due (at most a minute)
+func (w *Webhooks) untilNext() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wait := time.Minute
+	for _, d := range w.deliveries {
+		if d.Status == DeliveryPending && !w.inFlight[d.ID] && d.NextAttemptAt != nil {
+			if until := time.Until(*d.NextAttemptAt); until < wait {
+				wait = until
+			}
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// Helper: Post one delivery and record the outcome
+func (w *Webhooks) attempt(d WebhookDelivery) {
+	secret := ""
+	w.mu.Lock()
+	if i := w.endpointIndex(d.EndpointID); i != -1 {
+		secret = w.endpoints[i].Secret
+	}
+	w.mu.Unlock()
+
+	started := time.Now()
+	code, err := w.post(d, secret)
+	attempt := WebhookAttempt{
+		At:         started.UTC().Format(time.RFC3339Nano),
+		StatusCode: code,
+		Duration:   time.Since(started).String(),
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.inFlight, d.ID)
+	i := w.deliveryIndex(d.ID)
+	if i == -1 {
+		return
+	}
+	cur := &w.deliveries[i]
+	cur.Attempts = append(cur.Attempts, attempt)
+	switch {
+	case err == nil:
+		cur.Status, cur.NextAttemptAt = DeliveryDelivered, nil
+	case len(cur.Attempts) >= w.cfg.MaxAttempts:
+		cur.Status, cur.NextAttemptAt = DeliveryDead, nil
+		log.Printf("Webhook delivery %s to %s is a dead letter after %d attempts: %v", cur.ID, redactURL(cur.URL), len(cur.Attempts), err)
+	default:
+		next := time.Now().Add(w.backoff(len(cur.Attempts)))
+		cur.NextAttemptAt = &next
+	}
+	w.prune()
+	w.markUnsaved()
+}
+
+// Helper: Wait after the nth failed attempt: backoff, doubling, capped at max_backoff
+func (w *Webhooks) backoff(n int) time.Duration {
+	d := w.cfg.Backoff
+	for i := 1; i < n && d < w.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > w.cfg.MaxBackoff {
+		d = w.cfg.MaxBackoff
+	}
+	return d
+}
+
+// Helper: POST an event; anything but 2xx fails. With a secret, X-Webhook-Signature is
+// "sha256=" and the hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>".
+func (w *Webhooks) post(d WebhookDelivery, secret string) (int, error) {
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", d.ID)
+	req.Header.Set("X-Webhook-Event", d.Event.Type)
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	if secret != "" {
+		req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(secret, ts, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("answered %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>", as receivers should compute it
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Helper: Drop the oldest delivered deliveries and dead letters beyond history (caller holds mu)
+func (w *Webhooks) prune() {
+	delivered, dead := 0, 0
+	keep := make([]bool, len(w.deliveries))
+	for i := len(w.deliveries) - 1; i >= 0; i-- {
+		switch w.deliveries[i].Status {
+		case DeliveryDelivered:
+			delivered++
+			keep[i] = delivered <= w.cfg.History
+		case DeliveryDead:
+			dead++
+			keep[i] = dead <= w.cfg.History
+		default:
+			keep[i] = true
+		}
+	}
+	out := w.deliveries[:0]
+	for i, d := range w.deliveries {
+		if keep[i] {
+			out = append(out, d)
+		}
+	}
+	w.deliveries = out
+}
+
+// Endpoints lists the registered endpoints, without their secrets
+func (w *Webhooks) Endpoints() []WebhookEndpoint {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := []WebhookEndpoint{}
+	for _, ep := range w.endpoints {
+		out = append(out, ep.public())
+	}
+	return out
+}
+
+// Endpoint returns one endpoint, without its secret
+func (w *Webhooks) Endpoint(id string) (WebhookEndpoint, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := w.endpointIndex(id)
+	if i == -1 {
+		return WebhookEndpoint{}, errEndpointNotFound(id)
+	}
+	return w.endpoints[i].public(), nil
+}
+
+// CreateEndpoint registers an endpoint; a secret is generated when none is given and returned this once
+func (w *Webhooks) CreateEndpoint(ep WebhookEndpoint) (WebhookEndpoint, error) {
+	ep.URL = strings.TrimSpace(ep.URL)
+	if err := validateEndpoint(ep, w.cfg.AllowPrivate); err != nil {
+		return WebhookEndpoint{}, err
+	}
+	ep.ID = randomID("w", 6)
+	if ep.Secret == "" {
+		ep.Secret = randomID("whsec_", 16)
+	}
+	ep.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	ep.Events = normalizeEventTypes(ep.Events)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.endpoints = append(w.endpoints, ep)
+	if err := w.save(); err != nil {
+		w.endpoints = w.endpoints[:len(w.endpoints)-1]
+		return WebhookEndpoint{}, fmt.Errorf("failed to save webhooks: %v", err)
+	}
+	out := ep.public()
+	out.Secret = ep.Secret
+	return out, nil
+}
+
+// UpdateEndpoint replaces an endpoint's URL, events, active flag and description; an empty secret keeps the current one
+func (w *Webhooks) UpdateEndpoint(id string, ep WebhookEndpoint) (WebhookEndpoint, error) {
+	ep.URL = strings.TrimSpace(ep.URL)
+	if err := validateEndpoint(ep, w.cfg.AllowPrivate); err != nil {
+		return WebhookEndpoint{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := w.endpointIndex(id)
+	if i == -1 {
+		return WebhookEndpoint{}, errEndpointNotFound(id)
+	}
+	before := w.endpoints[i]
+	ep.ID, ep.CreatedAt = before.ID, before.CreatedAt
+	if ep.Secret == "" {
+		ep.Secret = before.Secret
+	}
+	ep.Events = normalizeEventTypes(ep.Events)
+	w.endpoints[i] = ep
+	if err := w.save(); err != nil {
+		w.endpoints[i] = before
+		return WebhookEndpoint{}, fmt.Errorf("failed to save webhooks: %v", err)
+	}
+	return ep.public(), nil
+}
+
+// DeleteEndpoint removes an endpoint; its pending deliveries become dead letters
+func (w *Webhooks) DeleteEndpoint(id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := w.endpointIndex(id)
+	if i == -1 {
+		return errEndpointNotFound(id)
+	}
+	w.endpoints = append(w.endpoints[:i], w.endpoints[i+1:]...)
+	for j := range w.deliveries {
+		if d := &w.deliveries[j]; d.EndpointID == id && d.Status == DeliveryPending {
+			d.Status, d.NextAttemptAt = DeliveryDead, nil
+		}
+	}
+	if err := w.save(); err != nil {
+		return fmt.Errorf("failed to save webhooks: %v", err)
+	}
+	return nil
+}
+
+// Deliveries returns the delivery log, newest first
+func (w *Webhooks) Deliveries(f DeliveryFilter) []WebhookDelivery {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := []WebhookDelivery{}
+	for i := len(w.deliveries) - 1; i >= 0; i-- {
+		d := w.deliveries[i]
+		if (f.EndpointID != "" && d.EndpointID != f.EndpointID) ||
+			(f.Status != "" && !strings.EqualFold(d.Status, f.Status)) ||
+			(f.EventType != "" && !strings.EqualFold(d.Event.Type, f.EventType)) {
+			continue
+		}
+		d.Attempts = append([]WebhookAttempt{}, d.Attempts...)
+		out = append(out, d)
+		if f.Limit > 0 && len(out) == f.Limit {
+			break
+		}
+	}
+	return out
+}
+
+// Retry sends a dead letter (or a delivered event) again, starting over its attempts
+func (w *Webhooks) Retry(id string) (WebhookDelivery, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := w.deliveryIndex(id)
+	if i == -1 {
+		return WebhookDelivery{}, newError(ErrNotFound, "delivery_not_found", "delivery '%s' not found", id)
+	}
+	d := &w.deliveries[i]
+	if d.Status == DeliveryPending {
+		return WebhookDelivery{}, newError(ErrConflict, "delivery_pending", "delivery '%s' is still being attempted", id)
+	}
+	j := w.endpointIndex(d.EndpointID)
+	if j == -1 {
+		return WebhookDelivery{}, newError(ErrConflict, "endpoint_deleted", "the endpoint of delivery '%s' was deleted", id)
+	}
+
+	now := time.Now()
+	d.Status, d.NextAttemptAt, d.Attempts = DeliveryPending, &now, []WebhookAttempt{}
+	d.URL = w.endpoints[j].URL
+	if err := w.save(); err != nil {
+		return WebhookDelivery{}, fmt.Errorf("failed to save webhooks: %v", err)
+	}
+	w.signal()
+	return *d, nil
+}
+
+// Helper: Index of an endpoint by ID, -1 when missing (caller holds mu)
+func (w *Webhooks) endpointIndex(id string) int {
+	for i, ep := range w.endpoints {
+		if ep.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Helper: Index of a delivery by ID, -1 when missing (caller holds mu)
+func (w *Webhooks) deliveryIndex(id string) int {
+	for i, d := range w.deliveries {
+		if d.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Helper: The endpoint as the API shows it, without the secret
+func (ep WebhookEndpoint) public() WebhookEndpoint {
+	ep.HasSecret = ep.Secret != ""
+	ep.Secret = ""
+	ep.Events = append([]string{}, ep.Events...)
+	return ep
+}
+
+// Helper: Reject URLs that are not http(s) and unknown event types
+func validateEndpoint(ep WebhookEndpoint, allowPrivate bool) error {
+	u, err := url.Parse(ep.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return newError(ErrValidation, "validation_failed", "url must be an http(s) URL")
+	}
+	if !allowPrivate {
+		if err := checkWebhookHost(u.Hostname()); err != nil {
+			return err
+		}
+	}
+	for _, typ := range ep.Events {
+		if !containsFold(EventTypes, typ) {
+			return newError(ErrValidation, "validation_failed", "unknown event type '%s', expected one of %s", typ, strings.Join(EventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// Helper: Refuse a host that is, or resolves to, an address webhooks must not reach
+func checkWebhookHost(host string) error {
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return newError(ErrValidation, "validation_failed", "url host '%s' does not resolve", host)
+		}
+		ips = ips[:0]
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+	for _, ip := range ips {
+		if blockedWebhookIP(ip) {
+			return newError(ErrValidation, "url_not_allowed",
+				"url must not point at a private, loopback or link-local address (%s resolves to %s); set webhooks.allow_private to allow it", host, ip)
+		}
+	}
+	return nil
+}
+
+// Helper: Whether webhooks need webhooks.allow_private to reach ip: loopback, private,
+// link-local (where cloud metadata services live) and unspecified addresses
+func blockedWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// Helper: The delivery client. Unless private addresses are allowed, it checks the address
+// it actually connects to, so a host re-pointed after registration (or a redirect) is refused too.
+func webhookClient(cfg config.WebhooksConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !cfg.AllowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedWebhookIP(ip) {
+				return fmt.Errorf("refusing to connect to %s: private, loopback or link-local address (see webhooks.allow_private)", host)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: cfg.Timeout, Transport: transport}
+}
+
+// Helper: Lower-cased, de-duplicated and sorted event types
+func normalizeEventTypes(types []string) []string {
+	out := []string{}
+	for _, typ := range types {
+		typ = strings.ToLower(strings.TrimSpace(typ))
+		if !containsFold(out, typ) {
+			out = append(out, typ)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Helper: "not found" for an unknown endpoint
+func errEndpointNotFound(id string) error {
+	return newError(ErrNotFound, "endpoint_not_found", "webhook endpoint '%s' not found", id)
+}
+
+// Helper: Read the store file; a missing file is no endpoints
+func (w *Webhooks) load() error {
+	data, err := os.ReadFile(w.cfg.StoreFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var st webhookState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	w.endpoints, w.deliveries = st.Endpoints, st.Deliveries
+	return nil
+}
+
+// Helper: Write the store file (caller holds mu); the rename keeps it whole if we crash midway
+func (w *Webhooks) save() error {
+	if w.cfg.StoreFile == "" {
+		w.unsaved = false
+		return nil
+	}
+	data, err := json.MarshalIndent(webhookState{Endpoints: w.endpoints, Deliveries: w.deliveries}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(w.cfg.StoreFile), ".webhooks-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), w.cfg.StoreFile); err != nil {
+		return err
+	}
+	w.unsaved = false
+	return nil
+}
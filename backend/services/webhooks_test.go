@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"go-backend/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidateEndpointURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantCode     string // "" when the URL is accepted
+	}{
+		{"https://93.184.216.34/hook", false, ""},
+		{"ftp://93.184.216.34/hook", false, "validation_failed"},
+		{"https:///hook", false, "validation_failed"},
+		{"http://127.0.0.1:8080/hook", false, "url_not_allowed"},
+		{"http://localhost/hook", false, "url_not_allowed"},
+		{"http://[::1]/hook", false, "url_not_allowed"},
+		{"http://10.1.2.3/hook", false, "url_not_allowed"},
+		{"http://192.168.0.10/hook", false, "url_not_allowed"},
+		{"http://169.254.169.254/latest/meta-data", false, "url_not_allowed"},
+		{"http://[fe80::1]/hook", false, "url_not_allowed"},
+		{"http://0.0.0.0/hook", false, "url_not_allowed"},
+		{"http://127.0.0.1:8080/hook", true, ""},
+		{"http://169.254.169.254/latest/meta-data", true, ""},
+	}
+	for _, tt := range tests {
+		err := validateEndpoint(WebhookEndpoint{URL: tt.url}, tt.allowPrivate)
+		got := ""
+		if e := AsError(err); e != nil {
+			got = e.Code
+		} else if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.url, err)
+			continue
+		}
+		if got != tt.wantCode {
+			t.Errorf("%s (allow_private %v): code %q, want %q (%v)", tt.url, tt.allowPrivate, got, tt.wantCode, err)
+		}
+	}
+}
+
+func TestWebhookDeliveryRefusesPrivateAddress(t *testing.T) {
+	received := make(chan struct{}, 1)
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer hs.Close()
+
+	w, err := NewWebhooks(config.WebhooksConfig{MaxAttempts: 1, Timeout: time.Second, History: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Registered while allowed, e.g. before the host was re-pointed at a private address
+	w.endpoints = append(w.endpoints, WebhookEndpoint{ID: "w1", URL: hs.URL, Active: true})
+
+	code, err := w.post(WebhookDelivery{ID: "d1", URL: hs.URL, Event: Event{Type: EventTaskCreated}}, "")
+	if err == nil || code != 0 {
+		t.Fatalf("post = %d, %v; want the connection refused", code, err)
+	}
+	select {
+	case <-received:
+		t.Fatal("the private receiver was reached")
+	default:
+	}
+}
+
+func TestWebhooksBatchDeliverySaves(t *testing.T) {
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer hs.Close()
+
+	file := filepath.Join(t.TempDir(), "webhooks.json")
+	w, err := NewWebhooks(config.WebhooksConfig{StoreFile: file, MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Second,
+		Timeout: time.Second, History: 10, AllowPrivate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.CreateEndpoint(WebhookEndpoint{URL: hs.URL, Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	saved := func() webhookState {
+		var st webhookState
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &st); err != nil {
+			t.Fatal(err)
+		}
+		return st
+	}
+
+	// Events are queued without rewriting the file each time
+	for i := 0; i < 3; i++ {
+		w.Handle(Event{ID: "e", Type: EventTaskCreated})
+	}
+	if n := len(saved().Deliveries); n != 0 {
+		t.Fatalf("%d deliveries saved right away, want the saves batched", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st := saved()
+		delivered := 0
+		for _, d := range st.Deliveries {
+			if d.Status == DeliveryDelivered {
+				delivered++
+			}
+		}
+		if delivered == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("saved deliveries %+v, want 3 delivered", st.Deliveries)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	cancel()
+	<-done
+}
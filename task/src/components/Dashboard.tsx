@@ -1,6 +1,6 @@
 import { useEffect, useState, useMemo } from 'react';
 import { Users, RefreshCw, Edit2, X, Calendar, CheckCircle2, Circle, Clock, Search, ArrowUpDown, LayoutDashboard, Database, ChevronDown, ChevronUp } from 'lucide-react';
-import { api, EmployeeHistory, EmployeeMetadata, DailyLog } from '../lib/api';
+import { api, isoDate, subscribeEvents, EmployeeHistory, EmployeeMetadata, DailyLog, SheetStatus } from '../lib/api';
 
 interface MergedEmployee extends EmployeeHistory {
   metadata?: EmployeeMetadata;
@@ -29,6 +29,7 @@ export default function Dashboard() {
   const [employees, setEmployees] = useState<MergedEmployee[]>([]);
   const [isLoading, setIsLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
+  const [failedSheets, setFailedSheets] = useState<SheetStatus[]>([]);
   const [editingTask, setEditingTask] = useState<EditingTask | null>(null);
   const [isSaving, setIsSaving] = useState(false);
   const [todayStr, setTodayStr] = useState('');
@@ -44,25 +45,20 @@ export default function Dashboard() {
   const [expandedStats, setExpandedStats] = useState<{dev: boolean, managers: boolean}>({ dev: false, managers: false });
 
   useEffect(() => {
-    const now = new Date();
     // Today
-    const day = now.getDate().toString().padStart(2, '0');
-    const month = now.toLocaleString('en-US', { month: 'short' });
-    const weekday = now.toLocaleString('en-US', { weekday: 'short' });
-    setTodayStr(`${weekday} ${day}-${month}`);
+    setTodayStr(isoDate());
 
     // Yesterday
     const yest = new Date();
     yest.setDate(yest.getDate() - 1);
-    const yDay = yest.getDate().toString().padStart(2, '0');
-    const yMonth = yest.toLocaleString('en-US', { month: 'short' });
-    const yWeekday = yest.toLocaleString('en-US', { weekday: 'short' });
-    setYesterdayStr(`${yWeekday} ${yDay}-${yMonth}`);
+    setYesterdayStr(isoDate(yest));
   }, []);
 
-  const fetchData = async () => {
-    setIsLoading(true);
+  // quiet: refresh in place, for live updates, instead of showing the loading screen
+  const fetchData = async (quiet = false) => {
+    if (!quiet) setIsLoading(true);
     setError(null);
+    setFailedSheets([]);
 
     try {
       const [backendData, dbMetadata, dbLogs] = await Promise.all([
@@ -71,7 +67,11 @@ export default function Dashboard() {
         api.getDailyLogs().catch(err => { console.warn(err); return []; })
       ]);
 
-      const mergedData = backendData.map((sheetEmp) => {
+      if (backendData.partial) {
+        setFailedSheets(backendData.sheets.filter((s) => !s.ok));
+      }
+
+      const mergedData = backendData.employees.map((sheetEmp) => {
         const meta = dbMetadata.find(
           (dbEmp) => dbEmp.employee_name.toLowerCase() === sheetEmp.employee_name.toLowerCase()
         );
@@ -172,6 +172,19 @@ export default function Dashboard() {
 
   useEffect(() => { fetchData(); }, []);
 
+  // Live updates: reload shortly after a burst of changes, or right away after a reset
+  useEffect(() => {
+    let timer: ReturnType<typeof setTimeout> | undefined;
+    const unsubscribe = subscribeEvents((type) => {
+      clearTimeout(timer);
+      timer = setTimeout(() => fetchData(true), type === 'reset' ? 0 : 500);
+    });
+    return () => {
+      clearTimeout(timer);
+      unsubscribe();
+    };
+  }, []);
+
   const formatDate = (isoString?: string) => isoString ? new Date(isoString).toLocaleString('en-US', { month: 'short', day: 'numeric', hour: '2-digit', minute: '2-digit' }) : 'N/A';
   // const formatTime = (isoString?: string) => isoString ? new Date(isoString).toLocaleTimeString('en-US', { hour: '2-digit', minute: '2-digit' }) : '';
 
@@ -191,7 +204,7 @@ export default function Dashboard() {
             <div className="p-2 bg-red-600 rounded-lg shadow-lg"><Users className="text-white" size={20} /></div>
             <div><h1 className="text-2xl font-bold text-gray-800">Team Overview</h1><p className="text-gray-500 text-xs font-medium">Dev & Manager Tasks</p></div>
           </div>
-          <button onClick={fetchData} className="flex items-center gap-2 px-3 py-1.5 bg-white text-red-600 border border-red-200 rounded-lg hover:bg-red-50 shadow-sm text-sm font-semibold"><RefreshCw size={16} /> Refresh</button>
+          <button onClick={() => fetchData()} className="flex items-center gap-2 px-3 py-1.5 bg-white text-red-600 border border-red-200 rounded-lg hover:bg-red-50 shadow-sm text-sm font-semibold"><RefreshCw size={16} /> Refresh</button>
         </div>
 
         <div className="flex gap-2 border-b border-gray-200">
@@ -204,6 +217,12 @@ export default function Dashboard() {
       </div>
 
       {error && <div className="mb-6 bg-red-50 border border-red-200 rounded-lg p-3 text-sm text-red-700">Backend Error: {error}</div>}
+      {failedSheets.length > 0 && (
+        <div className="mb-6 bg-amber-50 border border-amber-200 rounded-lg p-3 text-sm text-amber-800">
+          Some teams could not be loaded, so this view is incomplete:{' '}
+          {failedSheets.map((s) => `${s.sheet}${s.error ? ` (${s.error})` : ''}`).join(', ')}
+        </div>
+      )}
 
       {/* DASHBOARD VIEW */}
       {activeTab === 'dashboard' && (
@@ -307,22 +326,23 @@ export default function Dashboard() {
                           const isToday = day.date === todayStr;
                           const isYesterday = day.date === yesterdayStr;
                           const canEdit = isToday || isYesterday;
-                          const dayLog = employee.logs?.find(l => l.task_date === day.date);
+                          // Older logs were keyed by the column header
+                          const dayLog = employee.logs?.find(l => l.task_date === day.date || l.task_date === day.label);
                           return (
                             <div key={dIdx} className={`w-64 flex-shrink-0 transition-opacity ${canEdit ? 'opacity-100' : 'opacity-60 grayscale-[0.3]'}`}>
                               <div className={`flex items-center justify-between mb-2 pb-1 border-b ${isToday ? 'border-red-200' : 'border-gray-100'}`}>
                                 <div className={`flex items-center gap-1.5 text-xs font-bold ${isToday ? 'text-red-700' : isYesterday ? 'text-gray-700' : 'text-gray-500'}`}>
                                   <Calendar size={12} />
-                                  {day.date} {isToday && <span className="text-white bg-blue-800 text-red-700 px-1.5 py-0.5 rounded text-[10px]">TODAY</span>} {isYesterday && <span className="text-white bg-red-500 text-red-700 px-1.5 py-0.5 rounded text-[10px]">YEST</span>}
+                                  {day.label} {isToday && <span className="text-white bg-blue-800 text-red-700 px-1.5 py-0.5 rounded text-[10px]">TODAY</span>} {isYesterday && <span className="text-white bg-red-500 text-red-700 px-1.5 py-0.5 rounded text-[10px]">YEST</span>}
                                 </div>
                                 {dayLog && <span className="text-[9px] text-gray-400 font-mono">Created {formatDate(dayLog.created_at)} <br /> Updated {formatDate(dayLog.updated_at)}</span>}
                               </div>
                               
                               {/* Fixed Height Container for Tasks */}
                               <div className="space-y-1.5 h-32 overflow-y-auto pr-1 custom-scrollbar">
-                                {day.todo.map((task, i) => <TaskCard key={`t-${i}`} task={task} status="todo" canEdit={canEdit} onClick={() => canEdit && setEditingTask({ employeeName: employee.employee_name, sheetName: employee.sheet_name, date: day.date, taskText: task, currentStatus: 'todo' })} />)}
-                                {day.pending.map((task, i) => <TaskCard key={`p-${i}`} task={task} status="pending" canEdit={canEdit} onClick={() => canEdit && setEditingTask({ employeeName: employee.employee_name, sheetName: employee.sheet_name, date: day.date, taskText: task, currentStatus: 'pending' })} />)}
-                                {day.complete.map((task, i) => <TaskCard key={`c-${i}`} task={task} status="complete" canEdit={canEdit} onClick={() => canEdit && setEditingTask({ employeeName: employee.employee_name, sheetName: employee.sheet_name, date: day.date, taskText: task, currentStatus: 'complete' })} />)}
+                                {day.todo.map((task, i) => <TaskCard key={`t-${i}`} task={task} status="todo" canEdit={canEdit} onClick={() => canEdit && setEditingTask({ employeeName: employee.employee_name, sheetName: employee.sheet_name, date: day.date ?? '', taskText: task, currentStatus: 'todo' })} />)}
+                                {day.pending.map((task, i) => <TaskCard key={`p-${i}`} task={task} status="pending" canEdit={canEdit} onClick={() => canEdit && setEditingTask({ employeeName: employee.employee_name, sheetName: employee.sheet_name, date: day.date ?? '', taskText: task, currentStatus: 'pending' })} />)}
+                                {day.complete.map((task, i) => <TaskCard key={`c-${i}`} task={task} status="complete" canEdit={canEdit} onClick={() => canEdit && setEditingTask({ employeeName: employee.employee_name, sheetName: employee.sheet_name, date: day.date ?? '', taskText: task, currentStatus: 'complete' })} />)}
                                 {!day.todo.length && !day.pending.length && !day.complete.length && <div className="h-full flex items-center justify-center text-gray-300 text-[10px] border border-dashed border-gray-100 rounded">Empty</div>}
                               </div>
                             </div>
@@ -1,6 +1,6 @@
 import { useState, useEffect, useRef } from 'react';
 import { Plus, Trash2, Save, Loader2, Search, User, FileText, Users, ChevronDown } from 'lucide-react';
-import { api } from '../lib/api';
+import { api, isoDate } from '../lib/api';
 
 type TaskStatus = 'todo' | 'pending' | 'complete';
 type Role = 'Dev' | 'Managers';
@@ -39,7 +39,7 @@ export default function EmployeeForm() {
       try {
         const data = await api.getAllTasks();
         // Extract unique names and their roles
-        const empList = data.map(e => ({
+        const empList = data.employees.map(e => ({
           name: e.employee_name,
           role: e.sheet_name
         }));
@@ -116,11 +116,7 @@ export default function EmployeeForm() {
     try {
       const data = await api.getEmployeeTasks(employeeName);
       
-      const now = new Date();
-      const day = now.getDate().toString().padStart(2, '0');
-      const month = now.toLocaleString('en-US', { month: 'short' });
-      const weekday = now.toLocaleString('en-US', { weekday: 'short' });
-      const todayStr = `${weekday} ${day}-${month}`;
+      const todayStr = isoDate();
 
       const todayEntry = data.history.find(h => h.date === todayStr);
 
@@ -163,11 +159,7 @@ export default function EmployeeForm() {
       const nonEmptyTasks = tasks.filter(t => t.description.trim() !== '');
       if (nonEmptyTasks.length === 0) throw new Error('Please add at least one task description');
 
-      const now = new Date();
-      const day = now.getDate().toString().padStart(2, '0');
-      const month = now.toLocaleString('en-US', { month: 'short' });
-      const weekday = now.toLocaleString('en-US', { weekday: 'short' });
-      const todayStr = `${weekday} ${day}-${month}`;
+      const todayStr = isoDate();
 
       const targetRole = role === 'Dev' ? 'DEV' : 'Managers';
       
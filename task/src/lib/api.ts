@@ -4,6 +4,13 @@ const BACKEND_URL = import.meta.env.VITE_BACKEND_URL || 'http://localhost:8080';
 
 console.log('Using backend URL:', BACKEND_URL);
 
+// Local calendar day as "2025-01-02", the format the backend uses for dates
+export const isoDate = (d: Date = new Date()): string => {
+  const month = (d.getMonth() + 1).toString().padStart(2, '0');
+  const day = d.getDate().toString().padStart(2, '0');
+  return `${d.getFullYear()}-${month}-${day}`;
+};
+
 export interface TaskItem {
   task: string;
   status: 'todo' | 'pending' | 'complete';
@@ -11,48 +18,103 @@ export interface TaskItem {
 
 export interface TaskRequest {
   employee_name: string;
-  role: 'Dev' | 'Managers';
-  date?: string; // Optional: "Mon 02-Jan"
+  role: string; // Team name or alias, e.g. 'Dev' or 'Managers'
+  date?: string; // Optional: "2025-01-02", defaults to today
   tasks: TaskItem[];
 }
 
+export interface Team {
+  name: string;
+  display_name: string;
+  sheet: string;
+  aliases: string[];
+  settings: Record<string, unknown>;
+}
+
 export interface DayTasks {
-  date: string;
+  date: string | null; // "2025-01-02"; null when the sheet column is not a date
+  label: string; // Column header as written in the sheet
   todo: string[];
   pending: string[];
   complete: string[];
 }
 
+export interface SheetStatus {
+  sheet: string;
+  ok: boolean;
+  error?: string;
+}
+
 export interface EmployeeHistory {
   employee_name: string;
   sheet_name: string;
   history: DayTasks[];
+  // Single-employee lookups only
+  sheets?: SheetStatus[];
+  partial?: boolean;
+}
+
+export interface TeamTasks {
+  employees: EmployeeHistory[];
+  sheets: SheetStatus[];
+  partial: boolean; // Some sheets failed to load; employees is incomplete
 }
 
 export interface EmployeeMetadata {
   id: string;
   employee_name: string;
+  timezone: string; // IANA zone, '' = server zone
   // Removed employee_id and project_name
 }
 
 export interface DailyLog {
   employee_name: string;
   task_date: string;
-  created_at: string;
+  created_at: string; // In the employee's zone
   updated_at: string;
+  timezone: string;
+  created_at_utc: string;
+  updated_at_utc: string;
+}
+
+// Backend errors are application/problem+json: { status, code, detail, ... }
+export interface Problem {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  code: string;
+}
+
+export class ApiError extends Error {
+  problem: Problem;
+
+  constructor(problem: Problem) {
+    super(problem.detail || problem.title);
+    this.problem = problem;
+  }
+}
+
+async function toError(response: Response, fallback: string): Promise<Error> {
+  if (response.headers.get('Content-Type')?.includes('application/problem+json')) {
+    return new ApiError(await response.json());
+  }
+  const text = await response.text();
+  return new Error(text || fallback);
 }
 
 export const api = {
   // Sheets
-  async getAllTasks(): Promise<EmployeeHistory[]> {
+  async getAllTasks(): Promise<TeamTasks> {
     const response = await fetch(`${BACKEND_URL}/employees/tasks`);
-    if (!response.ok) throw new Error('Failed to fetch tasks');
+    if (!response.ok) throw await toError(response, 'Failed to fetch tasks');
     return response.json();
   },
 
   async getEmployeeTasks(name: string): Promise<EmployeeHistory> {
     const response = await fetch(`${BACKEND_URL}/employee/${encodeURIComponent(name)}/tasks`);
-    if (!response.ok) throw new Error('Failed to fetch employee tasks');
+    if (!response.ok) throw await toError(response, 'Failed to fetch employee tasks');
     return response.json();
   },
 
@@ -62,29 +124,36 @@ export const api = {
       headers: { 'Content-Type': 'application/json' },
       body: JSON.stringify(data),
     });
-    if (!response.ok) throw new Error(await response.text());
+    if (!response.ok) throw await toError(response, 'Failed to update tasks');
+  },
+
+  // Teams
+  async getTeams(): Promise<Team[]> {
+    const response = await fetch(`${BACKEND_URL}/teams`);
+    if (!response.ok) throw await toError(response, 'Failed to fetch teams');
+    return response.json();
   },
 
   // Metadata
   async getMetadata(): Promise<EmployeeMetadata[]> {
     const response = await fetch(`${BACKEND_URL}/metadata`);
-    if (!response.ok) throw new Error('Failed to fetch metadata');
+    if (!response.ok) throw await toError(response, 'Failed to fetch metadata');
     return response.json();
   },
 
-  async upsertMetadata(data: { employee_name: string }): Promise<void> {
+  async upsertMetadata(data: { employee_name: string; timezone?: string }): Promise<void> {
     const response = await fetch(`${BACKEND_URL}/metadata`, {
       method: 'POST',
       headers: { 'Content-Type': 'application/json' },
       body: JSON.stringify(data),
     });
-    if (!response.ok) throw new Error('Failed to upsert metadata');
+    if (!response.ok) throw await toError(response, 'Failed to upsert metadata');
   },
 
   // Daily Logs
   async getDailyLogs(): Promise<DailyLog[]> {
     const response = await fetch(`${BACKEND_URL}/logs`);
-    if (!response.ok) throw new Error('Failed to fetch logs');
+    if (!response.ok) throw await toError(response, 'Failed to fetch logs');
     return response.json();
   },
 
@@ -94,6 +163,42 @@ export const api = {
       headers: { 'Content-Type': 'application/json' },
       body: JSON.stringify({ employee_name: name, task_date: date }),
     });
-    if (!response.ok) throw new Error('Failed to update daily log');
+    if (!response.ok) throw await toError(response, 'Failed to update daily log');
   }
-};
\ No newline at end of file
+};
+// Live feed from GET /events (Server-Sent Events)
+export type FeedEventType = 'cell.changed' | 'employee.created' | 'reset';
+
+// Calls onEvent for every change until the returned function is called. The browser resumes a
+// dropped stream with the Last-Event-ID header; when it gives up (e.g. the backend restarted),
+// the stream is reopened from the last ID seen with ?last_event_id. A 'reset' means changes
+// were missed and the data should be reloaded.
+export function subscribeEvents(onEvent: (type: FeedEventType) => void): () => void {
+  let lastId = '';
+  let source: EventSource | null = null;
+  let retry: ReturnType<typeof setTimeout> | undefined;
+  let closed = false;
+
+  const open = () => {
+    const query = lastId ? `?last_event_id=${encodeURIComponent(lastId)}` : '';
+    source = new EventSource(`${BACKEND_URL}/events${query}`);
+    (['cell.changed', 'employee.created', 'reset'] as FeedEventType[]).forEach((type) => {
+      source?.addEventListener(type, (e) => {
+        const id = (e as MessageEvent).lastEventId;
+        if (id) lastId = id;
+        onEvent(type);
+      });
+    });
+    source.onerror = () => {
+      if (source?.readyState !== EventSource.CLOSED || closed) return;
+      retry = setTimeout(open, 5000);
+    };
+  };
+
+  open();
+  return () => {
+    closed = true;
+    clearTimeout(retry);
+    source?.close();
+  };
+}